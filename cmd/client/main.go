@@ -330,16 +330,35 @@ func (c *ImprovedMCPClient) CallTool(name string, arguments map[string]interface
 		return fmt.Errorf("工具调用失败: %s", response.Error.Message)
 	}
 
-	fmt.Printf("✓ 工具调用成功: %s\n", name)
-
 	// 显示结果
 	if response.Result != nil {
 		resultBytes, _ := json.Marshal(response.Result)
 		var result mcp.ToolCallResult
 
 		if err := json.Unmarshal(resultBytes, &result); err == nil {
+			if result.IsError {
+				fmt.Printf("✗ 工具执行出错: %s\n", name)
+			} else {
+				fmt.Printf("✓ 工具调用成功: %s\n", name)
+			}
 			for _, content := range result.Content {
-				fmt.Printf("   结果: %s\n", content.Text)
+				switch content.Type {
+				case mcp.ContentTypeImage, mcp.ContentTypeAudio:
+					fmt.Printf("   结果: [%s %s, %d字节base64]\n", content.Type, content.MimeType, len(content.Data))
+				case mcp.ContentTypeResource:
+					if content.Resource == nil {
+						continue
+					}
+					fmt.Printf("   结果: [内嵌资源 %s %s]\n", content.Resource.URI, content.Resource.MimeType)
+				case mcp.ContentTypeResourceLink:
+					fmt.Printf("   结果: [资源链接 %s %s]\n", content.Name, content.URI)
+				default:
+					fmt.Printf("   结果: %s\n", content.Text)
+				}
+			}
+			if result.StructuredContent != nil {
+				structured, _ := json.MarshalIndent(result.StructuredContent, "   ", "  ")
+				fmt.Printf("   结构化结果: %s\n", structured)
 			}
 		}
 	}
@@ -382,8 +401,12 @@ func (c *ImprovedMCPClient) ReadResource(uri string) error {
 		var result mcp.ResourceReadResult
 
 		if err := json.Unmarshal(resultBytes, &result); err == nil {
-			fmt.Printf("   MIME类型: %s\n", result.MimeType)
 			for _, content := range result.Contents {
+				fmt.Printf("   MIME类型: %s\n", content.MimeType)
+				if content.Blob != "" {
+					fmt.Printf("   二进制内容: %d 字节(base64)\n", len(content.Blob))
+					continue
+				}
 				fmt.Printf("   内容: %s\n", content.Text)
 			}
 		}
@@ -571,6 +594,9 @@ func (c *ImprovedMCPClient) showHelp() {
 		• db_connect      连接到数据库
 		• db_query        执行数据库查询
 		• db_execute      执行数据库操作
+		• db_list_tables  列出表和视图
+		• db_describe_table 查看表结构（列、主键、索引、外键）
+		• db_relationships 查看表之间的外键关系
 
 	🤖 AI工具:
 		• ai_query        使用AI进行智能查询和回答
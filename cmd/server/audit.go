@@ -0,0 +1,151 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"mcp-ai-server/internal/config"
+	"mcp-ai-server/internal/logger"
+)
+
+// runAuditCommand 实现 audit 子命令，按条件查询审计日志
+func runAuditCommand(args []string) error {
+	flags := flag.NewFlagSet("audit", flag.ExitOnError)
+	var (
+		configPath = flags.String("config", "configs/config.yaml", "配置文件路径")
+		file       = flags.String("file", "", "审计日志路径，默认使用配置文件中的 audit.path")
+		tool       = flags.String("tool", "", "只显示指定工具的调用")
+		principal  = flags.String("principal", "", "只显示指定认证主体的调用")
+		session    = flags.String("session", "", "只显示指定会话的调用")
+		since      = flags.String("since", "", "起始时间，RFC3339格式或相对时长（如 24h）")
+		until      = flags.String("until", "", "结束时间，RFC3339格式或相对时长")
+		errorsOnly = flags.Bool("errors", false, "只显示失败的调用")
+		limit      = flags.Int("limit", 50, "最多显示最近的多少条记录，0表示全部")
+		asJSON     = flags.Bool("json", false, "以JSON Lines格式输出")
+	)
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "用法: mcp-server audit [选项]")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+
+	path := *file
+	if path == "" {
+		auditConfig, err := config.NewAuditConfigManager(*configPath)
+		if err != nil {
+			return err
+		}
+		path = auditConfig.GetSettings().Path
+	}
+
+	filter := logger.AuditFilter{
+		Tool:       *tool,
+		Principal:  *principal,
+		Session:    *session,
+		ErrorsOnly: *errorsOnly,
+	}
+	var err error
+	if filter.Since, err = parseAuditTime(*since); err != nil {
+		return err
+	}
+	if filter.Until, err = parseAuditTime(*until); err != nil {
+		return err
+	}
+
+	// 只保留最近的limit条
+	var records []logger.AuditRecord
+	err = logger.ReadAuditRecords(path, filter, func(record logger.AuditRecord) bool {
+		records = append(records, record)
+		if *limit > 0 && len(records) > *limit {
+			records = records[1:]
+		}
+		return true
+	})
+	if err != nil {
+		return err
+	}
+
+	if *asJSON {
+		encoder := json.NewEncoder(os.Stdout)
+		for _, record := range records {
+			encoder.Encode(record)
+		}
+		return nil
+	}
+
+	writer := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(writer, "时间\t调用方\t工具\t耗时(ms)\t状态\t副作用")
+	for _, record := range records {
+		status := "ok"
+		if record.IsError {
+			status = "error: " + firstLine(record.Error, 60)
+		}
+		fmt.Fprintf(writer, "%s\t%s\t%s\t%.1f\t%s\t%s\n",
+			record.Time.Local().Format("2006-01-02 15:04:05"),
+			auditCaller(record), auditTool(record), record.DurationMS, status, sideEffectSummary(record.SideEffect))
+	}
+	return writer.Flush()
+}
+
+// parseAuditTime 解析RFC3339时间或相对于当前时间的时长
+func parseAuditTime(value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	if duration, err := time.ParseDuration(value); err == nil {
+		return time.Now().Add(-duration), nil
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("无效的时间: %s", value)
+	}
+	return t, nil
+}
+
+// auditCaller 调用方的展示名称，优先使用认证主体
+func auditCaller(record logger.AuditRecord) string {
+	switch {
+	case record.Principal != "":
+		return record.Principal
+	case record.Client != "":
+		return record.Client
+	default:
+		return "-"
+	}
+}
+
+// auditTool 工具名称，嵌套调用前加上发起调用的工具
+func auditTool(record logger.AuditRecord) string {
+	if record.ParentTool != "" {
+		return record.ParentTool + " > " + record.Tool
+	}
+	return record.Tool
+}
+
+// sideEffectSummary 将副作用压缩为一行
+func sideEffectSummary(effect map[string]interface{}) string {
+	if len(effect) == 0 {
+		return "-"
+	}
+	var parts []string
+	for _, key := range []string{"action", "path", "bytes", "alias", "sql", "rows_affected", "command", "args"} {
+		if value, ok := effect[key]; ok {
+			parts = append(parts, fmt.Sprintf("%s=%v", key, value))
+		}
+	}
+	return firstLine(strings.Join(parts, " "), 100)
+}
+
+// firstLine 取第一行并限制长度
+func firstLine(text string, limit int) string {
+	text, _, _ = strings.Cut(text, "\n")
+	if runes := []rune(text); len(runes) > limit {
+		return string(runes[:limit]) + "..."
+	}
+	return text
+}
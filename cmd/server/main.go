@@ -1,6 +1,7 @@
 package main
 
 import (
+	"crypto/tls"
 	"flag"
 	"fmt"
 	"log"
@@ -8,16 +9,31 @@ import (
 	"os/signal"
 	"syscall"
 
+	"mcp-ai-server/internal/config"
+	"mcp-ai-server/internal/logger"
 	"mcp-ai-server/internal/mcp"
 	"mcp-ai-server/internal/tools"
 )
 
 func main() {
+	// 沙箱辅助进程：设置资源限制后执行目标命令，不会返回
+	if len(os.Args) > 1 && os.Args[1] == tools.SandboxHelperCommand {
+		tools.RunSandboxHelper(os.Args[2:])
+	}
+
+	// 子命令
+	if len(os.Args) > 1 && os.Args[1] == "audit" {
+		if err := runAuditCommand(os.Args[2:]); err != nil {
+			log.Fatalf("查询审计日志失败: %v", err)
+		}
+		return
+	}
+
 	// 解析命令行参数
 	var (
 		help = flag.Bool("help", false, "显示帮助信息")
-		port = flag.Int("port", 8081, "WebSocket端口（如果使用WebSocket模式）")
-		mode = flag.String("mode", "stdio", "运行模式：stdio 或 websocket")
+		port = flag.Int("port", 8081, "监听端口（websocket和http模式）")
+		mode = flag.String("mode", "stdio", "运行模式：stdio、websocket 或 http")
 	)
 	flag.Parse()
 
@@ -26,22 +42,48 @@ func main() {
 		return
 	}
 
+	configPath := "configs/config.yaml"
+
+	// 命令行显式指定的端口优先于配置文件
+	portFlagSet := false
+	flag.Visit(func(f *flag.Flag) {
+		if f.Name == "port" {
+			portFlagSet = true
+		}
+	})
+
+	// 在输出任何日志之前安装脱敏器
+	redactionConfig, err := config.NewRedactionConfigManager(configPath)
+	if err != nil {
+		log.Fatalf("加载脱敏配置失败: %v", err)
+	}
+	logger.SetRedactor(newRedactor(configPath, redactionConfig))
+	log.SetOutput(logger.Stderr)
+
 	// 创建工具管理器
-	toolManager, err := tools.NewToolManager("configs/config.yaml")
+	toolManager, err := tools.NewToolManager(configPath)
 	if err != nil {
 		log.Fatalf("创建工具管理器失败: %v", err)
 	}
 
+	// 加载服务器配置
+	serverConfig, err := config.NewServerConfigManager(configPath)
+	if err != nil {
+		log.Fatalf("加载服务器配置失败: %v", err)
+	}
+
 	// 根据模式创建服务器
 	var server mcp.Server
 
 	switch *mode {
 	case "stdio":
 		// 在stdio模式下，将日志输出到stderr避免干扰JSON通信
-		log.SetOutput(os.Stderr)
-		server, err = createStdioServer(toolManager)
+		log.SetOutput(logger.Stderr)
+		server, err = createStdioServer(toolManager, serverConfig)
 	case "websocket":
-		server, err = createWebSocketServer(toolManager, *port)
+		server, err = createWebSocketServer(toolManager, serverConfig, configPath, *port, portFlagSet)
+	case "http":
+		server, err = createHTTPServer(toolManager, serverConfig, configPath, *port, portFlagSet)
 	default:
 		log.Fatalf("不支持的运行模式: %s", *mode)
 	}
@@ -67,44 +109,294 @@ func main() {
 }
 
 // createStdioServer 创建stdio服务器
-func createStdioServer(toolManager *tools.ToolManager) (mcp.Server, error) {
+func createStdioServer(toolManager *tools.ToolManager, serverConfig *config.ServerConfigManager) (mcp.Server, error) {
 	stdioServer := mcp.NewStdioServer(os.Stdin, os.Stdout)
-
-	// 注册所有工具
-	for _, tool := range toolManager.GetTools() {
-		if err := stdioServer.RegisterTool(tool); err != nil {
-			return nil, fmt.Errorf("注册工具失败: %v", err)
-		}
-		log.Printf("已注册工具: %s", tool.Name)
+	if err := setupServer(stdioServer, stdioServer.BaseServer, toolManager, serverConfig); err != nil {
+		return nil, err
 	}
-
-	// 设置工具执行器
-	stdioServer.SetToolExecutor(toolManager)
-	log.Println("已设置工具执行器")
-
 	return stdioServer, nil
 }
 
 // createWebSocketServer 创建WebSocket服务器
-func createWebSocketServer(toolManager *tools.ToolManager, port int) (mcp.Server, error) {
+func createWebSocketServer(toolManager *tools.ToolManager, serverConfig *config.ServerConfigManager, configPath string, port int, portFlagSet bool) (mcp.Server, error) {
+	websocketConfig, err := config.NewWebSocketConfigManager(configPath)
+	if err != nil {
+		return nil, fmt.Errorf("加载WebSocket配置失败: %v", err)
+	}
+	settings := websocketConfig.GetSettings()
+	if !portFlagSet && settings.Port > 0 {
+		port = settings.Port
+	}
+
 	websocketServer := mcp.NewWebSocketServer(port)
 	if websocketServer == nil {
 		return nil, fmt.Errorf("创建WebSocket服务器失败")
 	}
+	websocketServer.SetListenAddress(settings.Host)
+	websocketServer.SetPath(settings.Path)
+	websocketServer.SetAllowedOrigins(websocketConfig.GetAllowedOrigins())
+
+	if settings.TLS.Enabled {
+		tlsConfig, err := newServerTLSConfig(settings.TLS)
+		if err != nil {
+			return nil, err
+		}
+		websocketServer.SetTLSConfig(tlsConfig)
+		websocketServer.SetClientPrincipals(settings.TLS.ClientPrincipals)
+		log.Printf("WebSocket启用TLS，客户端证书校验: %s", settings.TLS.ClientAuth)
+	}
+
+	authenticator, metadata, err := loadAuthenticator(configPath)
+	if err != nil {
+		return nil, err
+	}
+	if authenticator != nil {
+		websocketServer.SetAuthenticator(authenticator)
+		websocketServer.SetResourceMetadata(metadata)
+		log.Println("WebSocket连接需要认证")
+	}
+
+	if err := setupServer(websocketServer, websocketServer.BaseServer, toolManager, serverConfig); err != nil {
+		return nil, err
+	}
+	return websocketServer, nil
+}
+
+// createHTTPServer 创建Streamable HTTP服务器
+func createHTTPServer(toolManager *tools.ToolManager, serverConfig *config.ServerConfigManager, configPath string, port int, portFlagSet bool) (mcp.Server, error) {
+	httpConfig, err := config.NewHTTPConfigManager(configPath)
+	if err != nil {
+		return nil, fmt.Errorf("加载HTTP配置失败: %v", err)
+	}
+	settings := httpConfig.GetSettings()
+	if !portFlagSet && settings.Port > 0 {
+		port = settings.Port
+	}
+
+	httpServer := mcp.NewHTTPServer(port)
+	httpServer.SetListenAddress(settings.Host)
+	httpServer.SetAllowedOrigins(httpConfig.GetAllowedOrigins())
+
+	if settings.TLS.Enabled {
+		tlsConfig, err := newServerTLSConfig(settings.TLS)
+		if err != nil {
+			return nil, err
+		}
+		httpServer.SetTLSConfig(tlsConfig)
+		httpServer.SetClientPrincipals(settings.TLS.ClientPrincipals)
+		log.Printf("HTTP启用TLS，客户端证书校验: %s", settings.TLS.ClientAuth)
+	}
+
+	authenticator, metadata, err := loadAuthenticator(configPath)
+	if err != nil {
+		return nil, err
+	}
+	if authenticator != nil {
+		httpServer.SetAuthenticator(authenticator)
+		httpServer.SetResourceMetadata(metadata)
+		log.Println("HTTP请求需要认证")
+	}
+
+	if err := setupServer(httpServer, httpServer.BaseServer, toolManager, serverConfig); err != nil {
+		return nil, err
+	}
+	return httpServer, nil
+}
+
+// setupServer 注册工具、资源处理器、提示词和工具执行器，所有传输模式共用
+func setupServer(server mcp.Server, base *mcp.BaseServer, toolManager *tools.ToolManager, serverConfig *config.ServerConfigManager) error {
+	configureBaseServer(base, serverConfig)
 
 	// 注册所有工具
 	for _, tool := range toolManager.GetTools() {
-		if err := websocketServer.RegisterTool(tool); err != nil {
-			return nil, fmt.Errorf("注册工具失败: %v", err)
+		if err := server.RegisterTool(tool); err != nil {
+			return fmt.Errorf("注册工具失败: %v", err)
 		}
 		log.Printf("已注册工具: %s", tool.Name)
 	}
 
+	// 运行时启用、禁用、添加或移除工具时同步工具列表并通知所有会话
+	toolManager.SetToolsChangedHandler(func() {
+		if err := base.ReplaceTools(toolManager.GetTools()); err != nil {
+			log.Printf("同步工具列表失败: %v", err)
+		}
+	})
+
+	// 注册资源处理器
+	if err := registerResourceHandlers(server, toolManager); err != nil {
+		return err
+	}
+
+	// 注册提示词
+	for _, prompt := range toolManager.GetPrompts() {
+		if err := server.RegisterPrompt(prompt); err != nil {
+			return fmt.Errorf("注册提示词失败: %v", err)
+		}
+	}
+	base.SetPromptProvider(toolManager)
+
 	// 设置工具执行器
-	websocketServer.SetToolExecutor(toolManager)
+	base.SetToolExecutor(toolManager)
 	log.Println("已设置工具执行器")
 
-	return websocketServer, nil
+	return nil
+}
+
+// configureBaseServer 应用配置文件中的服务器设置
+func configureBaseServer(base *mcp.BaseServer, serverConfig *config.ServerConfigManager) {
+	settings := serverConfig.GetServerSettings()
+	if settings.Name != "" && settings.Version != "" {
+		base.SetServerInfo(&mcp.ServerInfo{
+			Name:    settings.Name,
+			Version: settings.Version,
+		})
+	}
+	base.SetMaxInFlight(serverConfig.GetMaxInFlightRequests())
+	base.SetMaxQueued(serverConfig.GetMaxQueuedRequests())
+	log.Printf("每个连接最大并发请求数: %d，最大排队请求数: %d", serverConfig.GetMaxInFlightRequests(), serverConfig.GetMaxQueuedRequests())
+}
+
+// newServerTLSConfig 根据TLS设置创建服务端TLS配置，网络传输层共用
+func newServerTLSConfig(settings config.TLSSettings) (*tls.Config, error) {
+	return mcp.NewServerTLSConfig(settings.CertFile, settings.KeyFile, settings.ClientCAFile, clientAuthType(settings.ClientAuth))
+}
+
+// loadAuthenticator 加载认证配置，未启用认证时返回nil认证器
+func loadAuthenticator(configPath string) (mcp.Authenticator, *mcp.ProtectedResourceMetadata, error) {
+	authConfig, err := config.NewAuthConfigManager(configPath)
+	if err != nil {
+		return nil, nil, fmt.Errorf("加载认证配置失败: %v", err)
+	}
+	if !authConfig.IsEnabled() {
+		return nil, nil, nil
+	}
+	authenticator, err := newAuthenticator(authConfig)
+	if err != nil {
+		return nil, nil, err
+	}
+	return authenticator, newResourceMetadata(authConfig), nil
+}
+
+// clientAuthType 将配置中的客户端证书校验方式转换为TLS设置
+func clientAuthType(clientAuth string) tls.ClientAuthType {
+	switch clientAuth {
+	case config.ClientAuthRequire:
+		return tls.RequireAndVerifyClientCert
+	case config.ClientAuthOptional:
+		return tls.VerifyClientCertIfGiven
+	default:
+		return tls.NoClientCert
+	}
+}
+
+// newAuthenticator 根据认证配置创建认证器，静态令牌优先于JWT
+func newAuthenticator(authConfig *config.AuthConfigManager) (mcp.Authenticator, error) {
+	var chain mcp.ChainAuthenticator
+
+	if tokens := authConfig.GetTokens(); len(tokens) > 0 {
+		staticTokens := make([]mcp.StaticToken, 0, len(tokens))
+		for _, token := range tokens {
+			staticTokens = append(staticTokens, mcp.StaticToken{
+				Name:   token.Name,
+				Token:  token.Token,
+				Scopes: token.Scopes,
+			})
+		}
+		chain = append(chain, mcp.NewTokenAuthenticator(staticTokens))
+	}
+
+	if jwt := authConfig.GetJWTSettings(); jwt.Enabled {
+		audience := jwt.Audience
+		if len(audience) == 0 {
+			// 未配置audience时使用资源标识，符合MCP授权规范对令牌受众的要求
+			if metadata := authConfig.GetResourceMetadata(); metadata != nil {
+				audience = []string{metadata.Resource}
+			}
+		}
+		jwtAuthenticator, err := mcp.NewJWTAuthenticator(mcp.JWTConfig{
+			Issuer:         jwt.Issuer,
+			Audience:       audience,
+			JWKSFile:       jwt.JWKSFile,
+			JWKSURL:        jwt.JWKSURL,
+			Leeway:         authConfig.GetJWTLeeway(),
+			RequiredScopes: jwt.RequiredScopes,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("创建JWT认证器失败: %v", err)
+		}
+		chain = append(chain, jwtAuthenticator)
+	}
+
+	if len(chain) == 0 {
+		return nil, fmt.Errorf("已启用认证，但没有配置可用的令牌或JWT校验")
+	}
+	return chain, nil
+}
+
+// newResourceMetadata 根据认证配置创建受保护资源元数据，未配置时返回nil
+func newResourceMetadata(authConfig *config.AuthConfigManager) *mcp.ProtectedResourceMetadata {
+	settings := authConfig.GetResourceMetadata()
+	if settings == nil {
+		return nil
+	}
+	return &mcp.ProtectedResourceMetadata{
+		Resource:               settings.Resource,
+		AuthorizationServers:   settings.AuthorizationServers,
+		ScopesSupported:        settings.ScopesSupported,
+		BearerMethodsSupported: []string{"header"},
+	}
+}
+
+// newRedactor 根据脱敏配置创建脱敏器，未启用时返回nil
+// 除规则外，配置文件中的API密钥、数据库密码和认证令牌按字面值脱敏
+func newRedactor(configPath string, redactionConfig *config.RedactionConfigManager) *logger.Redactor {
+	if !redactionConfig.IsEnabled() {
+		return nil
+	}
+
+	var patterns []logger.RedactPattern
+	if redactionConfig.UseBuiltinPatterns() {
+		patterns = logger.DefaultRedactPatterns()
+	}
+	for _, pattern := range redactionConfig.GetPatterns() {
+		patterns = append(patterns, logger.RedactPattern{
+			Name:        pattern.Name,
+			Pattern:     pattern.Regexp(),
+			Replacement: pattern.Replacement,
+		})
+	}
+
+	var secrets []string
+	if aiConfig, err := config.NewAIConfigManager(configPath); err == nil {
+		for _, name := range []string{"openai", "anthropic"} {
+			if provider, enabled := aiConfig.GetProvider(name); enabled {
+				secrets = append(secrets, provider.APIKey)
+			}
+		}
+	}
+	if databaseConfig, err := config.NewDatabaseConfigManager(configPath); err == nil {
+		for _, dsn := range databaseConfig.GetDSNs() {
+			secrets = append(secrets, logger.DSNPassword(dsn))
+		}
+	}
+	if authConfig, err := config.NewAuthConfigManager(configPath); err == nil {
+		for _, token := range authConfig.GetTokens() {
+			secrets = append(secrets, token.Token)
+		}
+	}
+
+	return logger.NewRedactor(patterns, secrets)
+}
+
+// registerResourceHandlers 注册内置资源处理器 (file://, db://)
+func registerResourceHandlers(server mcp.Server, toolManager *tools.ToolManager) error {
+	for scheme, handler := range toolManager.GetResourceHandlers() {
+		if err := server.RegisterResourceHandler(scheme, handler); err != nil {
+			return fmt.Errorf("注册资源处理器失败: %v", err)
+		}
+		log.Printf("已注册资源处理器: %s://", scheme)
+	}
+	return nil
 }
 
 // showHelp 显示帮助信息
@@ -117,21 +409,24 @@ func showHelp() {
 
 	📖 用法:
 	mcp-server [选项]
+	mcp-server audit [-tool 名称] [-principal 主体] [-since 24h] [-errors] [-limit N] [-json]
 
 	🔧 选项:
 	-help    显示此帮助信息
-	-mode    运行模式 (stdio|websocket) [默认: stdio]
-	-port    WebSocket端口 [默认: 8081]
+	-mode    运行模式 (stdio|websocket|http) [默认: stdio]
+	-port    监听端口，websocket和http模式使用 [默认: 8081，websocket模式未指定时使用配置文件中的端口]
 	-config  配置文件路径 [默认: configs/config.yaml]
 
 	🌐 运行模式:
 	stdio     通过标准输入输出通信，适合本地集成和CLI工具
 	websocket 通过网络WebSocket通信，支持远程访问和API集成
+	http      Streamable HTTP传输，POST /mcp 提交请求，支持SSE推送和断线续传
 
 	💡 使用示例:
 	mcp-server                           # 使用默认stdio模式
 	mcp-server -mode websocket          # 使用WebSocket模式
 	mcp-server -mode websocket -port 9000  # 指定WebSocket端口
+	mcp-server -mode http -port 8080       # 使用Streamable HTTP模式
 	mcp-server -config ./my-config.yaml    # 使用自定义配置文件
 
 	🛠️ 可用工具列表:
@@ -160,16 +455,27 @@ func showHelp() {
 	• db_connect      连接到数据库
 	• db_query        执行数据库查询
 	• db_execute      执行数据库操作
+	• db_list_tables  列出表和视图
+	• db_describe_table 查看表结构（列、主键、索引、外键）
+	• db_relationships 查看表之间的外键关系
 
 	🤖 AI工具 (Ollama集成):
 	• ai_query        使用AI进行智能查询和回答
 	• ai_analyze_data 使用AI分析数据并提供洞察
 	• ai_generate_query 根据自然语言描述生成SQL查询
 
+	📚 资源:
+	• file:///{path}        读取本地文件 (二进制以base64返回)
+	• db://{alias}/{table}  读取数据库表的样例数据
+
+	💬 提示词 (prompts/list, prompts/get):
+	• generate_sql, match_table, analyze_*, file_manager_plan
+	• 可在配置文件的 prompts 段中覆盖或新增
+
 	⚙️ 配置说明:
 	配置文件: configs/config.yaml
 	支持热重载: 否 (需要重启服务器)
-	默认端口: 8081 (WebSocket模式)
+	默认端口: 8081 (WebSocket模式，监听地址、路径、来源和TLS见配置文件 websocket 段)
 	健康检查: http://localhost:8081/health (WebSocket模式)
 
 	🔒 安全特性:
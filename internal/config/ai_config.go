@@ -29,6 +29,17 @@ type AIToolsConfig struct {
 	Ollama          ProviderConfig            `yaml:"ollama"`
 	OpenAI          ProviderConfig            `yaml:"openai"`
 	Anthropic       ProviderConfig            `yaml:"anthropic"`
+	Sampling        SamplingConfig            `yaml:"sampling"`
+}
+
+// SamplingConfig 客户端采样配置，通过MCP客户端的模型生成回复
+type SamplingConfig struct {
+	Enabled              bool     `yaml:"enabled"`
+	SystemPrompt         string   `yaml:"system_prompt"`
+	IncludeContext       string   `yaml:"include_context"`
+	CostPriority         *float64 `yaml:"cost_priority"`
+	SpeedPriority        *float64 `yaml:"speed_priority"`
+	IntelligencePriority *float64 `yaml:"intelligence_priority"`
 }
 
 // FunctionModel 功能特定模型配置
@@ -120,6 +131,11 @@ func (m *AIConfigManager) GetProvider(name string) (*ProviderConfig, bool) {
 	}
 }
 
+// GetSamplingConfig 获取客户端采样配置
+func (m *AIConfigManager) GetSamplingConfig() *SamplingConfig {
+	return &m.config.Sampling
+}
+
 // GetDefaultProvider 获取默认提供商
 func (m *AIConfigManager) GetDefaultProvider() string {
 	return m.config.DefaultProvider
@@ -154,6 +170,9 @@ func (m *AIConfigManager) GetAvailableProviders() []string {
 	if m.config.Anthropic.Enabled {
 		providers = append(providers, "anthropic")
 	}
+	if m.config.Sampling.Enabled {
+		providers = append(providers, "sampling")
+	}
 
 	return providers
 }
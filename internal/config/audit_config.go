@@ -0,0 +1,92 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// 审计日志默认设置
+const (
+	DefaultAuditPath              = "logs/audit.jsonl"
+	DefaultAuditMaxSize           = ByteSize(50 << 20)
+	DefaultAuditMaxBackups        = 10
+	DefaultAuditMaxArgumentLength = 1024
+)
+
+// defaultAuditRedactKeys 参数名包含这些词时记录为已脱敏
+var defaultAuditRedactKeys = []string{"password", "passwd", "secret", "token", "api_key", "apikey", "authorization", "auth_info", "cookie", "credential", "dsn"}
+
+// AuditSettings 工具调用审计设置
+type AuditSettings struct {
+	Enabled bool   `yaml:"enabled"`
+	Path    string `yaml:"path"`
+	// MaxSize 单个日志文件的大小上限，超过后轮转
+	MaxSize ByteSize `yaml:"max_size"`
+	// MaxBackups 保留的轮转文件数
+	MaxBackups int `yaml:"max_backups"`
+	// RedactKeys 参数名（不区分大小写）包含其中任意一项时不记录参数值
+	RedactKeys []string `yaml:"redact_keys"`
+	// MaxArgumentLength 单个参数值记录的最大长度，超出部分截断
+	MaxArgumentLength int `yaml:"max_argument_length"`
+}
+
+// AuditConfig 审计配置结构
+type AuditConfig struct {
+	Audit AuditSettings `yaml:"audit"`
+}
+
+// AuditConfigManager 审计配置管理器
+type AuditConfigManager struct {
+	config *AuditConfig
+}
+
+// NewAuditConfigManager 创建审计配置管理器
+func NewAuditConfigManager(configPath string) (*AuditConfigManager, error) {
+	// 读取配置文件
+	data, err := os.ReadFile(configPath)
+	if err != nil {
+		return nil, fmt.Errorf("读取配置文件失败: %v", err)
+	}
+
+	var config AuditConfig
+	if err := yaml.Unmarshal(data, &config); err != nil {
+		return nil, fmt.Errorf("解析配置文件失败: %v", err)
+	}
+
+	settings := &config.Audit
+	if settings.Path == "" {
+		settings.Path = DefaultAuditPath
+	}
+	if settings.MaxSize <= 0 {
+		settings.MaxSize = DefaultAuditMaxSize
+	}
+	if settings.MaxBackups <= 0 {
+		settings.MaxBackups = DefaultAuditMaxBackups
+	}
+	if settings.MaxArgumentLength <= 0 {
+		settings.MaxArgumentLength = DefaultAuditMaxArgumentLength
+	}
+	if len(settings.RedactKeys) == 0 {
+		settings.RedactKeys = append([]string(nil), defaultAuditRedactKeys...)
+	}
+	for i, key := range settings.RedactKeys {
+		settings.RedactKeys[i] = strings.ToLower(key)
+	}
+
+	return &AuditConfigManager{
+		config: &config,
+	}, nil
+}
+
+// IsEnabled 是否记录审计日志
+func (m *AuditConfigManager) IsEnabled() bool {
+	return m.config.Audit.Enabled
+}
+
+// GetSettings 获取审计设置
+func (m *AuditConfigManager) GetSettings() *AuditSettings {
+	return &m.config.Audit
+}
@@ -0,0 +1,123 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// 默认允许的时钟偏差
+const DefaultJWTLeeway = 60 * time.Second
+
+// AuthToken 静态API令牌
+type AuthToken struct {
+	// Name 令牌对应的调用方名称，作为认证后的主体标识
+	Name string `yaml:"name"`
+	// Token 令牌值，支持 "${ENV_NAME}" 从环境变量读取
+	Token  string   `yaml:"token"`
+	Scopes []string `yaml:"scopes"`
+}
+
+// JWTSettings JWT访问令牌校验设置
+type JWTSettings struct {
+	Enabled        bool     `yaml:"enabled"`
+	Issuer         string   `yaml:"issuer"`
+	Audience       []string `yaml:"audience"`
+	JWKSFile       string   `yaml:"jwks_file"`
+	JWKSURL        string   `yaml:"jwks_url"`
+	Leeway         int      `yaml:"leeway"`
+	RequiredScopes []string `yaml:"required_scopes"`
+}
+
+// ResourceMetadataSettings 受保护资源元数据 (RFC 9728)
+type ResourceMetadataSettings struct {
+	Resource             string   `yaml:"resource"`
+	AuthorizationServers []string `yaml:"authorization_servers"`
+	ScopesSupported      []string `yaml:"scopes_supported"`
+}
+
+// AuthSettings 网络传输的认证设置
+type AuthSettings struct {
+	Enabled          bool                     `yaml:"enabled"`
+	Tokens           []AuthToken              `yaml:"tokens"`
+	JWT              JWTSettings              `yaml:"jwt"`
+	ResourceMetadata ResourceMetadataSettings `yaml:"resource_metadata"`
+}
+
+// AuthConfig 认证配置结构
+type AuthConfig struct {
+	Auth AuthSettings `yaml:"auth"`
+}
+
+// AuthConfigManager 认证配置管理器
+type AuthConfigManager struct {
+	config *AuthConfig
+}
+
+// NewAuthConfigManager 创建认证配置管理器
+func NewAuthConfigManager(configPath string) (*AuthConfigManager, error) {
+	// 读取配置文件
+	data, err := os.ReadFile(configPath)
+	if err != nil {
+		return nil, fmt.Errorf("读取配置文件失败: %v", err)
+	}
+
+	var config AuthConfig
+	if err := yaml.Unmarshal(data, &config); err != nil {
+		return nil, fmt.Errorf("解析配置文件失败: %v", err)
+	}
+
+	// 令牌可以从环境变量读取，环境变量未设置时令牌为空，不会被接受
+	for i, token := range config.Auth.Tokens {
+		if strings.HasPrefix(token.Token, "${") && strings.HasSuffix(token.Token, "}") {
+			config.Auth.Tokens[i].Token = os.Getenv(strings.Trim(token.Token, "${}"))
+		}
+		if config.Auth.Tokens[i].Name == "" {
+			return nil, fmt.Errorf("第 %d 个认证令牌缺少name", i+1)
+		}
+	}
+
+	return &AuthConfigManager{
+		config: &config,
+	}, nil
+}
+
+// IsEnabled 是否启用认证
+func (m *AuthConfigManager) IsEnabled() bool {
+	return m.config.Auth.Enabled
+}
+
+// GetTokens 获取静态API令牌，值为空的令牌已被跳过
+func (m *AuthConfigManager) GetTokens() []AuthToken {
+	var tokens []AuthToken
+	for _, token := range m.config.Auth.Tokens {
+		if token.Token != "" {
+			tokens = append(tokens, token)
+		}
+	}
+	return tokens
+}
+
+// GetJWTSettings 获取JWT校验设置
+func (m *AuthConfigManager) GetJWTSettings() *JWTSettings {
+	return &m.config.Auth.JWT
+}
+
+// GetJWTLeeway 获取JWT时间校验允许的时钟偏差，未配置时使用默认值
+func (m *AuthConfigManager) GetJWTLeeway() time.Duration {
+	if m.config.Auth.JWT.Leeway <= 0 {
+		return DefaultJWTLeeway
+	}
+	return time.Duration(m.config.Auth.JWT.Leeway) * time.Second
+}
+
+// GetResourceMetadata 获取受保护资源元数据，未配置resource时返回nil
+func (m *AuthConfigManager) GetResourceMetadata() *ResourceMetadataSettings {
+	if m.config.Auth.ResourceMetadata.Resource == "" {
+		return nil
+	}
+	return &m.config.Auth.ResourceMetadata
+}
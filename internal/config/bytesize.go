@@ -0,0 +1,77 @@
+package config
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ByteSize 字节数，配置中可以写整数或带单位的字符串（如 "10MB"、"512KB"）
+type ByteSize int64
+
+// 字节单位，按1024进制换算
+var byteUnits = []struct {
+	suffix string
+	size   int64
+}{
+	{"TB", 1 << 40},
+	{"GB", 1 << 30},
+	{"MB", 1 << 20},
+	{"KB", 1 << 10},
+	{"B", 1},
+}
+
+// ParseByteSize 解析带单位的字节数
+func ParseByteSize(value string) (ByteSize, error) {
+	s := strings.ToUpper(strings.TrimSpace(value))
+	if s == "" {
+		return 0, fmt.Errorf("空的大小配置")
+	}
+
+	multiplier := int64(1)
+	for _, unit := range byteUnits {
+		if strings.HasSuffix(s, unit.suffix) {
+			s = strings.TrimSpace(strings.TrimSuffix(s, unit.suffix))
+			multiplier = unit.size
+			break
+		}
+	}
+
+	number, err := strconv.ParseFloat(s, 64)
+	if err != nil || number < 0 {
+		return 0, fmt.Errorf("无效的大小配置: %s", value)
+	}
+	return ByteSize(number * float64(multiplier)), nil
+}
+
+// UnmarshalYAML 支持整数和带单位的字符串两种写法
+func (b *ByteSize) UnmarshalYAML(value *yaml.Node) error {
+	var number int64
+	if err := value.Decode(&number); err == nil {
+		*b = ByteSize(number)
+		return nil
+	}
+
+	var text string
+	if err := value.Decode(&text); err != nil {
+		return err
+	}
+	size, err := ParseByteSize(text)
+	if err != nil {
+		return err
+	}
+	*b = size
+	return nil
+}
+
+// String 以便于阅读的单位输出
+func (b ByteSize) String() string {
+	for _, unit := range byteUnits {
+		if int64(b) >= unit.size && int64(b)%unit.size == 0 {
+			return fmt.Sprintf("%d%s", int64(b)/unit.size, unit.suffix)
+		}
+	}
+	return fmt.Sprintf("%dB", int64(b))
+}
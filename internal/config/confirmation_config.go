@@ -0,0 +1,82 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// 默认等待用户确认的时间
+const DefaultConfirmationTimeout = 5 * time.Minute
+
+// 客户端不支持elicitation时的处理方式
+const (
+	ConfirmationFallbackAllow = "allow"
+	ConfirmationFallbackDeny  = "deny"
+)
+
+// ConfirmationSettings 破坏性操作的确认设置
+type ConfirmationSettings struct {
+	// Tools 需要确认的工具，值为false或未列出的工具直接执行
+	Tools map[string]bool `yaml:"tools"`
+	// OnUnsupported 客户端不支持elicitation时的处理方式: allow 直接执行，deny 拒绝执行
+	OnUnsupported string `yaml:"on_unsupported"`
+	// Timeout 等待用户确认的秒数
+	Timeout int `yaml:"timeout"`
+}
+
+// ConfirmationConfig 确认配置结构
+type ConfirmationConfig struct {
+	Confirmation ConfirmationSettings `yaml:"confirmation"`
+}
+
+// ConfirmationConfigManager 确认配置管理器
+type ConfirmationConfigManager struct {
+	config *ConfirmationConfig
+}
+
+// NewConfirmationConfigManager 创建确认配置管理器
+func NewConfirmationConfigManager(configPath string) (*ConfirmationConfigManager, error) {
+	// 读取配置文件
+	data, err := os.ReadFile(configPath)
+	if err != nil {
+		return nil, fmt.Errorf("读取配置文件失败: %v", err)
+	}
+
+	var config ConfirmationConfig
+	if err := yaml.Unmarshal(data, &config); err != nil {
+		return nil, fmt.Errorf("解析配置文件失败: %v", err)
+	}
+
+	switch config.Confirmation.OnUnsupported {
+	case "":
+		config.Confirmation.OnUnsupported = ConfirmationFallbackAllow
+	case ConfirmationFallbackAllow, ConfirmationFallbackDeny:
+	default:
+		return nil, fmt.Errorf("无效的on_unsupported配置: %s", config.Confirmation.OnUnsupported)
+	}
+
+	return &ConfirmationConfigManager{
+		config: &config,
+	}, nil
+}
+
+// RequiresConfirmation 判断工具执行前是否需要用户确认
+func (m *ConfirmationConfigManager) RequiresConfirmation(tool string) bool {
+	return m.config.Confirmation.Tools[tool]
+}
+
+// DenyWhenUnsupported 客户端不支持elicitation时是否拒绝执行需要确认的工具
+func (m *ConfirmationConfigManager) DenyWhenUnsupported() bool {
+	return m.config.Confirmation.OnUnsupported == ConfirmationFallbackDeny
+}
+
+// GetTimeout 获取等待用户确认的时间，未配置时使用默认值
+func (m *ConfirmationConfigManager) GetTimeout() time.Duration {
+	if m.config.Confirmation.Timeout <= 0 {
+		return DefaultConfirmationTimeout
+	}
+	return time.Duration(m.config.Confirmation.Timeout) * time.Second
+}
@@ -3,6 +3,7 @@ package config
 import (
 	"fmt"
 	"os"
+	"time"
 
 	"gopkg.in/yaml.v3"
 )
@@ -23,11 +24,21 @@ type DatabaseConnectionsConfig struct {
 	Connections     map[string]DatabaseConnectionConfig   `yaml:",inline"`
 }
 
+// DefaultSchemaCacheTTL 未配置时数据库结构缓存的有效期（秒）
+const DefaultSchemaCacheTTL = 300
+
+// DatabaseSchemaCacheConfig 数据库结构缓存配置
+type DatabaseSchemaCacheConfig struct {
+	// TTL 缓存有效期（秒），0表示不缓存
+	TTL *int `yaml:"ttl"`
+}
+
 // DatabaseConfig 数据库配置结构
 type DatabaseConfig struct {
 	Tools struct {
 		Database struct {
 			Connections DatabaseConnectionsConfig `yaml:"connections"`
+			SchemaCache DatabaseSchemaCacheConfig `yaml:"schema_cache"`
 		} `yaml:"database"`
 	} `yaml:"tools"`
 }
@@ -117,3 +128,30 @@ func (dcm *DatabaseConfigManager) GetAvailableAliases() []string {
 	}
 	return aliases
 }
+
+// GetDSNs 获取所有已配置连接的DSN
+func (dcm *DatabaseConfigManager) GetDSNs() []string {
+	if dcm.config == nil {
+		return nil
+	}
+
+	var dsns []string
+	for _, conn := range dcm.config.Tools.Database.Connections.Connections {
+		if conn.DSN != "" {
+			dsns = append(dsns, conn.DSN)
+		}
+	}
+	return dsns
+}
+
+// GetSchemaCacheTTL 获取数据库结构缓存的有效期，0表示不缓存
+func (dcm *DatabaseConfigManager) GetSchemaCacheTTL() time.Duration {
+	if dcm.config == nil {
+		return DefaultSchemaCacheTTL * time.Second
+	}
+	ttl := dcm.config.Tools.Database.SchemaCache.TTL
+	if ttl == nil || *ttl < 0 {
+		return DefaultSchemaCacheTTL * time.Second
+	}
+	return time.Duration(*ttl) * time.Second
+}
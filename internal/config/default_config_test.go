@@ -0,0 +1,34 @@
+package config
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+// TestDefaultConfigLoads 仓库自带的配置文件能被每个配置管理器加载
+func TestDefaultConfigLoads(t *testing.T) {
+	configPath := filepath.Join("..", "..", "configs", "config.yaml")
+
+	loaders := map[string]func(string) error{
+		"ai":           func(p string) error { _, err := NewAIConfigManager(p); return err },
+		"audit":        func(p string) error { _, err := NewAuditConfigManager(p); return err },
+		"auth":         func(p string) error { _, err := NewAuthConfigManager(p); return err },
+		"confirmation": func(p string) error { _, err := NewConfirmationConfigManager(p); return err },
+		"database":     func(p string) error { _, err := NewDatabaseConfigManager(p); return err },
+		"http":         func(p string) error { _, err := NewHTTPConfigManager(p); return err },
+		"network":      func(p string) error { _, err := NewNetworkConfigManager(p); return err },
+		"policy":       func(p string) error { _, err := NewPolicyConfigManager(p); return err },
+		"prompt":       func(p string) error { _, err := NewPromptConfigManager(p); return err },
+		"redaction":    func(p string) error { _, err := NewRedactionConfigManager(p); return err },
+		"security":     func(p string) error { _, err := NewSecurityManager(p); return err },
+		"server":       func(p string) error { _, err := NewServerConfigManager(p); return err },
+		"websocket":    func(p string) error { _, err := NewWebSocketConfigManager(p); return err },
+	}
+	for name, load := range loaders {
+		t.Run(name, func(t *testing.T) {
+			if err := load(configPath); err != nil {
+				t.Errorf("加载默认配置失败: %v", err)
+			}
+		})
+	}
+}
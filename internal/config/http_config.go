@@ -0,0 +1,68 @@
+package config
+
+import (
+	"fmt"
+	"os"
+
+	"gopkg.in/yaml.v3"
+)
+
+// DefaultHTTPHost Streamable HTTP默认只监听本机回环地址
+const DefaultHTTPHost = "127.0.0.1"
+
+// HTTPSettings Streamable HTTP监听设置，CORS和TLS与WebSocket的含义相同
+type HTTPSettings struct {
+	Host string       `yaml:"host"`
+	Port int          `yaml:"port"`
+	CORS CORSSettings `yaml:"cors"`
+	TLS  TLSSettings  `yaml:"tls"`
+}
+
+// HTTPConfig Streamable HTTP配置结构
+type HTTPConfig struct {
+	HTTP HTTPSettings `yaml:"http"`
+}
+
+// HTTPConfigManager Streamable HTTP配置管理器
+type HTTPConfigManager struct {
+	config *HTTPConfig
+}
+
+// NewHTTPConfigManager 创建Streamable HTTP配置管理器
+func NewHTTPConfigManager(configPath string) (*HTTPConfigManager, error) {
+	// 读取配置文件
+	data, err := os.ReadFile(configPath)
+	if err != nil {
+		return nil, fmt.Errorf("读取配置文件失败: %v", err)
+	}
+
+	var config HTTPConfig
+	if err := yaml.Unmarshal(data, &config); err != nil {
+		return nil, fmt.Errorf("解析配置文件失败: %v", err)
+	}
+
+	settings := &config.HTTP
+	if settings.Host == "" {
+		settings.Host = DefaultHTTPHost
+	}
+	if err := normalizeTLSSettings(&settings.TLS); err != nil {
+		return nil, err
+	}
+
+	return &HTTPConfigManager{
+		config: &config,
+	}, nil
+}
+
+// GetSettings 获取Streamable HTTP监听设置
+func (m *HTTPConfigManager) GetSettings() *HTTPSettings {
+	return &m.config.HTTP
+}
+
+// GetAllowedOrigins 获取允许的来源，未启用CORS时返回nil（只接受来自本机回环地址的页面）
+func (m *HTTPConfigManager) GetAllowedOrigins() []string {
+	if !m.config.HTTP.CORS.Enabled {
+		return nil
+	}
+	return m.config.HTTP.CORS.AllowedOrigins
+}
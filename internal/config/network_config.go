@@ -0,0 +1,153 @@
+package config
+
+import (
+	"fmt"
+	"net/netip"
+	"os"
+	"path"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// DefaultMaxRedirects 未配置时允许的最大重定向次数
+const DefaultMaxRedirects = 5
+
+// NetworkHTTPSettings tools.network.http 设置
+type NetworkHTTPSettings struct {
+	// MaxRedirects 最大重定向次数，0表示不跟随重定向
+	MaxRedirects *int `yaml:"max_redirects"`
+}
+
+// EgressRateLimit 按目标主机的请求速率限制
+type EgressRateLimit struct {
+	// RequestsPerMinute 每个主机每分钟的请求数，0表示不限制
+	RequestsPerMinute int `yaml:"requests_per_minute"`
+	// Burst 允许的突发请求数，默认与每分钟请求数相同
+	Burst int `yaml:"burst"`
+}
+
+// EgressSettings 网络工具的出站访问策略
+type EgressSettings struct {
+	// AllowedHosts 允许访问的主机名glob模式，为空时允许所有未被禁止的主机
+	AllowedHosts []string `yaml:"allowed_hosts"`
+	// DeniedHosts 禁止访问的主机名glob模式，优先级最高
+	DeniedHosts []string `yaml:"denied_hosts"`
+	// AllowedCIDRs 允许访问的IP段，可以放行block_private拦截的内部地址
+	AllowedCIDRs []string `yaml:"allowed_cidrs"`
+	// DeniedCIDRs 禁止访问的IP段，优先级高于AllowedCIDRs
+	DeniedCIDRs []string `yaml:"denied_cidrs"`
+	// BlockPrivate 禁止访问回环、私有、链路本地（含云元数据地址）等非公网地址，默认启用
+	BlockPrivate *bool           `yaml:"block_private"`
+	RateLimit    EgressRateLimit `yaml:"rate_limit"`
+}
+
+// NetworkToolSettings tools.network 设置
+type NetworkToolSettings struct {
+	HTTP   NetworkHTTPSettings `yaml:"http"`
+	Egress EgressSettings      `yaml:"egress"`
+}
+
+// NetworkConfig 网络工具配置结构
+type NetworkConfig struct {
+	Tools struct {
+		Network NetworkToolSettings `yaml:"network"`
+	} `yaml:"tools"`
+}
+
+// NetworkConfigManager 网络工具配置管理器
+type NetworkConfigManager struct {
+	config          *NetworkConfig
+	allowedPrefixes []netip.Prefix
+	deniedPrefixes  []netip.Prefix
+}
+
+// NewNetworkConfigManager 创建网络工具配置管理器
+func NewNetworkConfigManager(configPath string) (*NetworkConfigManager, error) {
+	// 读取配置文件
+	data, err := os.ReadFile(configPath)
+	if err != nil {
+		return nil, fmt.Errorf("读取配置文件失败: %v", err)
+	}
+
+	var config NetworkConfig
+	if err := yaml.Unmarshal(data, &config); err != nil {
+		return nil, fmt.Errorf("解析配置文件失败: %v", err)
+	}
+
+	m := &NetworkConfigManager{
+		config: &config,
+	}
+
+	egress := &config.Tools.Network.Egress
+	for _, hosts := range []*[]string{&egress.AllowedHosts, &egress.DeniedHosts} {
+		for i, pattern := range *hosts {
+			pattern = strings.ToLower(strings.TrimSuffix(pattern, "."))
+			if _, err := path.Match(pattern, ""); err != nil {
+				return nil, fmt.Errorf("无效的主机模式 %q: %v", pattern, err)
+			}
+			(*hosts)[i] = pattern
+		}
+	}
+	if m.allowedPrefixes, err = parsePrefixes(egress.AllowedCIDRs); err != nil {
+		return nil, err
+	}
+	if m.deniedPrefixes, err = parsePrefixes(egress.DeniedCIDRs); err != nil {
+		return nil, err
+	}
+	if egress.RateLimit.RequestsPerMinute < 0 || egress.RateLimit.Burst < 0 {
+		return nil, fmt.Errorf("rate_limit配置不能为负数")
+	}
+	if egress.RateLimit.Burst == 0 {
+		egress.RateLimit.Burst = egress.RateLimit.RequestsPerMinute
+	}
+
+	return m, nil
+}
+
+// parsePrefixes 解析IP段，单个IP视为只包含它自己的网段
+func parsePrefixes(cidrs []string) ([]netip.Prefix, error) {
+	prefixes := make([]netip.Prefix, 0, len(cidrs))
+	for _, cidr := range cidrs {
+		if addr, err := netip.ParseAddr(cidr); err == nil {
+			addr = addr.Unmap()
+			prefixes = append(prefixes, netip.PrefixFrom(addr, addr.BitLen()))
+			continue
+		}
+		prefix, err := netip.ParsePrefix(cidr)
+		if err != nil {
+			return nil, fmt.Errorf("无效的IP段 %q: %v", cidr, err)
+		}
+		prefixes = append(prefixes, prefix.Masked())
+	}
+	return prefixes, nil
+}
+
+// GetMaxRedirects 获取最大重定向次数
+func (m *NetworkConfigManager) GetMaxRedirects() int {
+	if maxRedirects := m.config.Tools.Network.HTTP.MaxRedirects; maxRedirects != nil && *maxRedirects >= 0 {
+		return *maxRedirects
+	}
+	return DefaultMaxRedirects
+}
+
+// GetEgressSettings 获取出站访问策略设置
+func (m *NetworkConfigManager) GetEgressSettings() *EgressSettings {
+	return &m.config.Tools.Network.Egress
+}
+
+// BlockPrivate 是否禁止访问非公网地址
+func (m *NetworkConfigManager) BlockPrivate() bool {
+	blockPrivate := m.config.Tools.Network.Egress.BlockPrivate
+	return blockPrivate == nil || *blockPrivate
+}
+
+// GetAllowedPrefixes 获取解析后的允许IP段
+func (m *NetworkConfigManager) GetAllowedPrefixes() []netip.Prefix {
+	return m.allowedPrefixes
+}
+
+// GetDeniedPrefixes 获取解析后的禁止IP段
+func (m *NetworkConfigManager) GetDeniedPrefixes() []netip.Prefix {
+	return m.deniedPrefixes
+}
@@ -0,0 +1,258 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// 策略规则的效果
+const (
+	PolicyEffectAllow = "allow"
+	PolicyEffectDeny  = "deny"
+)
+
+// ArgumentCondition 对单个工具参数的匹配条件，配置的各项需要同时满足
+// 参数为数组时每个元素都需要满足条件；Not为true时对整体结果取反
+type ArgumentCondition struct {
+	// In 参数值等于其中之一
+	In []string `yaml:"in"`
+	// Pattern 参数值匹配的正则表达式
+	Pattern string `yaml:"pattern"`
+	// Hosts 参数值为URL或主机名时，主机名匹配的glob模式，如 "*.internal"
+	Hosts []string `yaml:"hosts"`
+	// Extensions 参数值为路径时，文件扩展名属于其中之一
+	Extensions []string `yaml:"extensions"`
+	// Paths 参数值为路径时，位于其中某个目录内（解析符号链接后判断）
+	Paths []string `yaml:"paths"`
+	// MaxLength 参数值的最大长度
+	MaxLength ByteSize `yaml:"max_length"`
+	// Not 对匹配结果取反
+	Not bool `yaml:"not"`
+
+	pattern *regexp.Regexp
+}
+
+// Regexp 获取编译后的Pattern，未配置时返回nil
+func (c *ArgumentCondition) Regexp() *regexp.Regexp {
+	return c.pattern
+}
+
+// PolicyRule 一条策略规则，工具、客户端、认证主体和参数条件都满足时规则命中
+type PolicyRule struct {
+	Name string `yaml:"name"`
+	// Tools 规则适用的工具名glob模式，为空时适用于所有工具
+	Tools []string `yaml:"tools"`
+	// Clients 规则适用的客户端标识glob模式，为空时适用于所有客户端
+	Clients []string `yaml:"clients"`
+	// Principals 规则适用的认证主体glob模式，为空时适用于所有调用方（包括未认证的）
+	Principals []string `yaml:"principals"`
+	// Scopes 认证主体必须拥有的全部权限范围
+	Scopes []string `yaml:"scopes"`
+	// Effect 命中后的效果: allow 或 deny
+	Effect string `yaml:"effect"`
+	// Arguments 参数名到匹配条件的映射
+	Arguments map[string]*ArgumentCondition `yaml:"arguments"`
+	// Message 拒绝时附加给调用方的说明
+	Message string `yaml:"message"`
+}
+
+// PolicySettings 工具调用策略设置
+type PolicySettings struct {
+	Enabled *bool `yaml:"enabled"`
+	// Default 没有规则命中时的效果，默认allow
+	Default string       `yaml:"default"`
+	Rules   []PolicyRule `yaml:"rules"`
+}
+
+// ToolSettings tools.system 下单个工具的设置
+type ToolSettings struct {
+	Enabled           *bool    `yaml:"enabled"`
+	MaxFileSize       ByteSize `yaml:"max_file_size"`
+	AllowedExtensions []string `yaml:"allowed_extensions"`
+	AllowedPaths      []string `yaml:"allowed_paths"`
+	AllowedCommands   []string `yaml:"allowed_commands"`
+	// Timeout 工具执行超时秒数
+	Timeout int `yaml:"timeout"`
+}
+
+// SystemToolSettings tools.system 配置
+type SystemToolSettings struct {
+	FileRead       ToolSettings `yaml:"file_read"`
+	FileWrite      ToolSettings `yaml:"file_write"`
+	CommandExecute ToolSettings `yaml:"command_execute"`
+	DirectoryList  ToolSettings `yaml:"directory_list"`
+}
+
+// PolicyConfig 策略配置结构
+type PolicyConfig struct {
+	Policy PolicySettings `yaml:"policy"`
+	Tools  struct {
+		System SystemToolSettings `yaml:"system"`
+	} `yaml:"tools"`
+}
+
+// PolicyConfigManager 策略配置管理器
+type PolicyConfigManager struct {
+	config   *PolicyConfig
+	rules    []PolicyRule
+	timeouts map[string]time.Duration
+}
+
+// NewPolicyConfigManager 创建策略配置管理器
+func NewPolicyConfigManager(configPath string) (*PolicyConfigManager, error) {
+	// 读取配置文件
+	data, err := os.ReadFile(configPath)
+	if err != nil {
+		return nil, fmt.Errorf("读取配置文件失败: %v", err)
+	}
+
+	var config PolicyConfig
+	if err := yaml.Unmarshal(data, &config); err != nil {
+		return nil, fmt.Errorf("解析配置文件失败: %v", err)
+	}
+
+	switch config.Policy.Default {
+	case "":
+		config.Policy.Default = PolicyEffectAllow
+	case PolicyEffectAllow, PolicyEffectDeny:
+	default:
+		return nil, fmt.Errorf("无效的默认策略: %s", config.Policy.Default)
+	}
+
+	m := &PolicyConfigManager{
+		config:   &config,
+		timeouts: make(map[string]time.Duration),
+	}
+
+	// tools.system 中的限制作为基线规则排在前面，显式规则不能放宽它们
+	system := config.Tools.System
+	for name, settings := range map[string]ToolSettings{
+		"file_read":       system.FileRead,
+		"file_write":      system.FileWrite,
+		"command_execute": system.CommandExecute,
+		"directory_list":  system.DirectoryList,
+	} {
+		m.rules = append(m.rules, toolSettingsRules(name, settings)...)
+		if settings.Timeout > 0 {
+			m.timeouts[name] = time.Duration(settings.Timeout) * time.Second
+		}
+	}
+	// 由map生成的规则按名称排序，保证评估顺序稳定
+	sort.Slice(m.rules, func(i, j int) bool { return m.rules[i].Name < m.rules[j].Name })
+	m.rules = append(m.rules, config.Policy.Rules...)
+
+	for i := range m.rules {
+		if err := validateRule(&m.rules[i], i); err != nil {
+			return nil, err
+		}
+	}
+
+	return m, nil
+}
+
+// toolSettingsRules 将tools.system中单个工具的设置转换为拒绝规则
+func toolSettingsRules(tool string, settings ToolSettings) []PolicyRule {
+	prefix := "tools.system." + tool
+	deny := func(suffix, argument string, condition *ArgumentCondition) PolicyRule {
+		rule := PolicyRule{
+			Name:   prefix + "." + suffix,
+			Tools:  []string{tool},
+			Effect: PolicyEffectDeny,
+		}
+		if condition != nil {
+			rule.Arguments = map[string]*ArgumentCondition{argument: condition}
+		}
+		return rule
+	}
+
+	var rules []PolicyRule
+	if settings.Enabled != nil && !*settings.Enabled {
+		rules = append(rules, deny("enabled", "", nil))
+	}
+	if len(settings.AllowedExtensions) > 0 {
+		rules = append(rules, deny("allowed_extensions", "path",
+			&ArgumentCondition{Extensions: settings.AllowedExtensions, Not: true}))
+	}
+	if len(settings.AllowedPaths) > 0 {
+		rules = append(rules, deny("allowed_paths", "path",
+			&ArgumentCondition{Paths: settings.AllowedPaths, Not: true}))
+	}
+	if len(settings.AllowedCommands) > 0 {
+		rules = append(rules, deny("allowed_commands", "command",
+			&ArgumentCondition{In: settings.AllowedCommands, Not: true}))
+	}
+	// 只有file_write能在调用前从参数得知写入大小，file_read的大小由安全配置限制
+	if settings.MaxFileSize > 0 && tool == "file_write" {
+		rules = append(rules, deny("max_file_size", "content",
+			&ArgumentCondition{MaxLength: settings.MaxFileSize, Not: true}))
+	}
+	return rules
+}
+
+// validateRule 校验规则并编译正则表达式
+func validateRule(rule *PolicyRule, index int) error {
+	if rule.Name == "" {
+		rule.Name = fmt.Sprintf("rule#%d", index+1)
+	}
+	switch rule.Effect {
+	case PolicyEffectAllow, PolicyEffectDeny:
+	default:
+		return fmt.Errorf("策略规则 %s 的effect无效: %q", rule.Name, rule.Effect)
+	}
+
+	for _, patterns := range [][]string{rule.Tools, rule.Clients, rule.Principals} {
+		for _, pattern := range patterns {
+			if _, err := path.Match(pattern, ""); err != nil {
+				return fmt.Errorf("策略规则 %s 的模式 %q 无效: %v", rule.Name, pattern, err)
+			}
+		}
+	}
+
+	for argument, condition := range rule.Arguments {
+		if condition == nil {
+			return fmt.Errorf("策略规则 %s 的参数 %s 缺少匹配条件", rule.Name, argument)
+		}
+		if condition.Pattern != "" {
+			re, err := regexp.Compile(condition.Pattern)
+			if err != nil {
+				return fmt.Errorf("策略规则 %s 的参数 %s 正则表达式无效: %v", rule.Name, argument, err)
+			}
+			condition.pattern = re
+		}
+		for i, host := range condition.Hosts {
+			condition.Hosts[i] = strings.ToLower(host)
+			if _, err := path.Match(host, ""); err != nil {
+				return fmt.Errorf("策略规则 %s 的主机模式 %q 无效: %v", rule.Name, host, err)
+			}
+		}
+		condition.Extensions = normalizeExtensions(condition.Extensions)
+	}
+	return nil
+}
+
+// IsEnabled 是否启用策略检查，未配置时启用
+func (m *PolicyConfigManager) IsEnabled() bool {
+	return m.config.Policy.Enabled == nil || *m.config.Policy.Enabled
+}
+
+// GetDefaultEffect 获取没有规则命中时的效果
+func (m *PolicyConfigManager) GetDefaultEffect() string {
+	return m.config.Policy.Default
+}
+
+// GetRules 获取按评估顺序排列的规则，tools.system 生成的规则在前
+func (m *PolicyConfigManager) GetRules() []PolicyRule {
+	return m.rules
+}
+
+// GetToolTimeout 获取工具的执行超时，未配置时返回0
+func (m *PolicyConfigManager) GetToolTimeout(tool string) time.Duration {
+	return m.timeouts[tool]
+}
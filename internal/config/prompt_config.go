@@ -0,0 +1,234 @@
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"sort"
+	"text/template"
+
+	"gopkg.in/yaml.v3"
+)
+
+// PromptArgumentConfig 提示词参数配置
+type PromptArgumentConfig struct {
+	Name        string `yaml:"name"`
+	Description string `yaml:"description"`
+	Required    bool   `yaml:"required"`
+}
+
+// PromptConfig 单个提示词模板配置，Template使用Go text/template语法
+type PromptConfig struct {
+	Description string                 `yaml:"description"`
+	Arguments   []PromptArgumentConfig `yaml:"arguments"`
+	Template    string                 `yaml:"template"`
+}
+
+// PromptsConfig 提示词配置结构
+type PromptsConfig struct {
+	Prompts map[string]PromptConfig `yaml:"prompts"`
+}
+
+// 内置提示词名称
+const (
+	PromptGenerateSQL            = "generate_sql"
+	PromptMatchTable             = "match_table"
+	PromptAnalyzeSummary         = "analyze_summary"
+	PromptAnalyzeInsights        = "analyze_insights"
+	PromptAnalyzeRecommendations = "analyze_recommendations"
+	PromptAnalyzeDetailed        = "analyze_detailed"
+	PromptAnalyzeGeneral         = "analyze_general"
+	PromptFileManagerPlan        = "file_manager_plan"
+)
+
+// dataArgument 数据分析类提示词共用的参数
+var dataArgument = []PromptArgumentConfig{
+	{Name: "data", Description: "需要分析的数据（JSON或文本）", Required: true},
+}
+
+// defaultPrompts 内置提示词，配置文件中的同名提示词会覆盖这些默认值
+var defaultPrompts = map[string]PromptConfig{
+	PromptGenerateSQL: {
+		Description: "根据自然语言描述生成SQL查询语句",
+		Arguments: []PromptArgumentConfig{
+			{Name: "description", Description: "查询需求描述", Required: true},
+			{Name: "table_name", Description: "目标表名", Required: true},
+			{Name: "dialect", Description: "数据库方言（MySQL、PostgreSQL、SQLite、SQL Server）", Required: false},
+			{Name: "quoted_table", Description: "按方言引用后的表名", Required: false},
+			{Name: "columns", Description: "表结构，每行一列", Required: false},
+			{Name: "limit_example", Description: "按方言限制结果数量的示例语句", Required: false},
+		},
+		Template: `请根据以下描述生成{{if .dialect}}{{.dialect}} {{end}}SQL查询语句：
+
+描述：{{.description}}
+表名：{{if .quoted_table}}{{.quoted_table}}{{else}}{{.table_name}}{{end}}
+{{- if .columns}}
+表结构：
+{{.columns}}
+{{- end}}
+
+要求：
+1. 只返回SQL语句，不要其他解释
+2. {{if .dialect}}使用{{.dialect}}语法{{else}}使用标准SQL语法{{end}}{{if .columns}}，只使用表结构中列出的列{{end}}
+3. 确保查询安全，避免SQL注入
+4. 如果需要限制结果数量，默认限制100行{{if .limit_example}}，写法如：{{.limit_example}}{{else}}，使用LIMIT 100{{end}}
+
+SQL：`,
+	},
+	PromptMatchTable: {
+		Description: "从可用表中选择最符合查询需求的表",
+		Arguments: []PromptArgumentConfig{
+			{Name: "query", Description: "用户查询需求", Required: true},
+			{Name: "tables", Description: "逗号分隔的可用表名", Required: true},
+		},
+		Template: `根据用户的查询需求，从可用的数据库表中选择最合适的表。
+
+用户查询：{{.query}}
+
+可用表名：{{.tables}}
+
+请只返回最合适的一个表名，不要包含其他解释。如果无法确定，返回第一个表名。`,
+	},
+	PromptAnalyzeSummary: {
+		Description: "用中文分析数据并提供摘要",
+		Arguments:   dataArgument,
+		Template:    "请严格用中文分析以下数据并提供摘要。必须用中文回答，不要使用英文。请直接返回分析结果：\n\n数据：{{.data}}\n\n要求：用中文分析并提供摘要",
+	},
+	PromptAnalyzeInsights: {
+		Description: "用中文分析数据并提供洞察和发现",
+		Arguments:   dataArgument,
+		Template:    "请严格用中文分析以下数据并提供洞察和发现。必须用中文回答，不要使用英文。请直接返回分析结果：\n\n数据：{{.data}}\n\n要求：用中文分析并提供洞察",
+	},
+	PromptAnalyzeRecommendations: {
+		Description: "用中文分析数据并提供建议和推荐",
+		Arguments:   dataArgument,
+		Template:    "请严格用中文分析以下数据并提供建议和推荐。必须用中文回答，不要使用英文。请直接返回分析结果：\n\n数据：{{.data}}\n\n要求：用中文分析并提供建议",
+	},
+	PromptAnalyzeDetailed: {
+		Description: "用中文对数据进行详细分析",
+		Arguments:   dataArgument,
+		Template:    "请严格用中文对以下数据进行详细分析。必须用中文回答，不要使用英文。请直接返回分析结果：\n\n数据：{{.data}}\n\n要求：用中文进行详细分析，包括数据统计、趋势分析和业务洞察",
+	},
+	PromptAnalyzeGeneral: {
+		Description: "用中文进行通用数据分析",
+		Arguments:   dataArgument,
+		Template:    "请严格用中文分析以下数据。必须用中文回答，不要使用英文。请直接返回分析结果：\n\n数据：{{.data}}\n\n要求：用中文分析数据",
+	},
+	PromptFileManagerPlan: {
+		Description: "分析文件管理指令并生成操作计划",
+		Arguments: []PromptArgumentConfig{
+			{Name: "instruction", Description: "文件操作指令", Required: true},
+			{Name: "target_path", Description: "目标路径", Required: false},
+			{Name: "operation_mode", Description: "操作模式 (plan_only, execute)", Required: false},
+		},
+		Template: `你是一个智能文件管理助手。用户的指令是："{{.instruction}}"
+
+当前目标路径：{{.target_path}}
+
+请分析这个指令并：
+1. 理解用户想要进行的文件操作类型
+2. 确定具体需要执行的操作步骤
+3. 如果需要，生成相应的文件操作命令
+
+操作模式：{{.operation_mode}}
+- 如果是 plan_only，只输出分析和计划，不执行实际操作
+- 如果是 execute，除了分析还要说明具体执行的操作
+
+请用JSON格式回复，包含以下字段：
+{
+  "analysis": "对指令的分析",
+  "operation_type": "操作类型(read/write/create/delete/list/search等)",
+  "action_plan": ["具体的操作步骤"],
+  "commands": ["如果需要执行，具体的命令或操作"],
+  "warnings": ["任何安全警告或注意事项"]
+}`,
+	},
+}
+
+// PromptConfigManager 提示词配置管理器
+type PromptConfigManager struct {
+	prompts   map[string]PromptConfig
+	templates map[string]*template.Template
+}
+
+// NewPromptConfigManager 创建提示词配置管理器，合并内置提示词和配置文件中的提示词
+func NewPromptConfigManager(configPath string) (*PromptConfigManager, error) {
+	// 读取配置文件
+	data, err := os.ReadFile(configPath)
+	if err != nil {
+		return nil, fmt.Errorf("读取配置文件失败: %v", err)
+	}
+
+	var config PromptsConfig
+	if err := yaml.Unmarshal(data, &config); err != nil {
+		return nil, fmt.Errorf("解析配置文件失败: %v", err)
+	}
+
+	prompts := make(map[string]PromptConfig, len(defaultPrompts)+len(config.Prompts))
+	for name, prompt := range defaultPrompts {
+		prompts[name] = prompt
+	}
+	for name, prompt := range config.Prompts {
+		// 配置中未声明参数时沿用内置提示词的参数定义
+		if base, exists := prompts[name]; exists && len(prompt.Arguments) == 0 {
+			prompt.Arguments = base.Arguments
+		}
+		if base, exists := prompts[name]; exists && prompt.Description == "" {
+			prompt.Description = base.Description
+		}
+		prompts[name] = prompt
+	}
+
+	templates := make(map[string]*template.Template, len(prompts))
+	for name, prompt := range prompts {
+		if prompt.Template == "" {
+			return nil, fmt.Errorf("提示词 %s 缺少template", name)
+		}
+		tmpl, err := template.New(name).Option("missingkey=zero").Parse(prompt.Template)
+		if err != nil {
+			return nil, fmt.Errorf("解析提示词模板 %s 失败: %v", name, err)
+		}
+		templates[name] = tmpl
+	}
+
+	return &PromptConfigManager{
+		prompts:   prompts,
+		templates: templates,
+	}, nil
+}
+
+// GetPromptNames 获取所有提示词名称（已排序）
+func (m *PromptConfigManager) GetPromptNames() []string {
+	names := make([]string, 0, len(m.prompts))
+	for name := range m.prompts {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// GetPrompt 获取指定提示词配置
+func (m *PromptConfigManager) GetPrompt(name string) (PromptConfig, bool) {
+	prompt, exists := m.prompts[name]
+	return prompt, exists
+}
+
+// Render 使用参数渲染提示词，缺少必填参数时返回错误
+func (m *PromptConfigManager) Render(name string, arguments map[string]string) (string, error) {
+	prompt, exists := m.prompts[name]
+	if !exists {
+		return "", fmt.Errorf("提示词不存在: %s", name)
+	}
+
+	for _, arg := range prompt.Arguments {
+		if arg.Required && arguments[arg.Name] == "" {
+			return "", fmt.Errorf("提示词 %s 缺少必填参数: %s", name, arg.Name)
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := m.templates[name].Execute(&buf, arguments); err != nil {
+		return "", fmt.Errorf("渲染提示词 %s 失败: %v", name, err)
+	}
+	return buf.String(), nil
+}
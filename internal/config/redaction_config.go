@@ -0,0 +1,98 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+
+	"gopkg.in/yaml.v3"
+)
+
+// RedactionPattern 自定义脱敏规则
+type RedactionPattern struct {
+	Name string `yaml:"name"`
+	// Pattern 匹配敏感内容的正则表达式
+	Pattern string `yaml:"pattern"`
+	// Replacement 替换文本，可引用分组（如 "${1}[REDACTED]"），默认 "[REDACTED]"
+	Replacement string `yaml:"replacement"`
+
+	pattern *regexp.Regexp
+}
+
+// Regexp 获取编译后的Pattern
+func (p *RedactionPattern) Regexp() *regexp.Regexp {
+	return p.pattern
+}
+
+// RedactionSettings 敏感信息脱敏设置
+type RedactionSettings struct {
+	// Enabled 默认启用
+	Enabled *bool `yaml:"enabled"`
+	// BuiltinPatterns 是否使用内置规则（Authorization头、DSN密码、已知前缀的API密钥等），默认启用
+	BuiltinPatterns *bool `yaml:"builtin_patterns"`
+	// Patterns 在内置规则之后应用的自定义规则
+	Patterns []RedactionPattern `yaml:"patterns"`
+}
+
+// RedactionConfig 脱敏配置结构
+type RedactionConfig struct {
+	Redaction RedactionSettings `yaml:"redaction"`
+}
+
+// RedactionConfigManager 脱敏配置管理器
+type RedactionConfigManager struct {
+	config *RedactionConfig
+}
+
+// NewRedactionConfigManager 创建脱敏配置管理器
+func NewRedactionConfigManager(configPath string) (*RedactionConfigManager, error) {
+	// 读取配置文件
+	data, err := os.ReadFile(configPath)
+	if err != nil {
+		return nil, fmt.Errorf("读取配置文件失败: %v", err)
+	}
+
+	var config RedactionConfig
+	if err := yaml.Unmarshal(data, &config); err != nil {
+		return nil, fmt.Errorf("解析配置文件失败: %v", err)
+	}
+
+	for i := range config.Redaction.Patterns {
+		pattern := &config.Redaction.Patterns[i]
+		if pattern.Name == "" {
+			pattern.Name = fmt.Sprintf("pattern#%d", i+1)
+		}
+		if pattern.Pattern == "" {
+			return nil, fmt.Errorf("脱敏规则 %s 缺少pattern", pattern.Name)
+		}
+		compiled, err := regexp.Compile(pattern.Pattern)
+		if err != nil {
+			return nil, fmt.Errorf("脱敏规则 %s 的正则表达式无效: %v", pattern.Name, err)
+		}
+		pattern.pattern = compiled
+		if pattern.Replacement == "" {
+			pattern.Replacement = "[REDACTED]"
+		}
+	}
+
+	return &RedactionConfigManager{
+		config: &config,
+	}, nil
+}
+
+// IsEnabled 是否启用脱敏
+func (m *RedactionConfigManager) IsEnabled() bool {
+	enabled := m.config.Redaction.Enabled
+	return enabled == nil || *enabled
+}
+
+// UseBuiltinPatterns 是否使用内置脱敏规则
+func (m *RedactionConfigManager) UseBuiltinPatterns() bool {
+	builtin := m.config.Redaction.BuiltinPatterns
+	return builtin == nil || *builtin
+}
+
+// GetPatterns 获取自定义脱敏规则
+func (m *RedactionConfigManager) GetPatterns() []RedactionPattern {
+	return m.config.Redaction.Patterns
+}
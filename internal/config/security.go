@@ -3,11 +3,42 @@ package config
 import (
 	"fmt"
 	"os"
+	"path/filepath"
 	"strings"
+	"time"
 
 	"gopkg.in/yaml.v3"
 )
 
+// 未配置时使用的默认限制
+const (
+	DefaultMaxFileSize       = ByteSize(100 << 20)
+	DefaultMaxDirectoryItems = 1000
+	DefaultMaxCommandOutput  = ByteSize(10 << 20)
+	DefaultMaxHTTPResponse   = ByteSize(10 << 20)
+	DefaultCommandTimeout    = 30 * time.Second
+	DefaultSandboxMemory     = ByteSize(512 << 20)
+	DefaultSandboxOpenFiles  = 256
+)
+
+// defaultAllowedCommands 未配置命令列表时允许执行的命令，只包含只读且会自行退出的命令
+var defaultAllowedCommands = []string{"ls", "cat", "echo", "pwd", "whoami", "date", "ps", "head", "tail", "wc"}
+
+// defaultSandboxEnv 未配置时传给命令的环境变量
+var defaultSandboxEnv = []string{"PATH", "LANG", "LC_ALL", "LC_CTYPE", "TZ"}
+
+// defaultDeniedArguments 未配置时各命令禁止使用的参数，这些参数会执行其他程序或修改文件
+var defaultDeniedArguments = map[string][]string{
+	"find": {"-exec", "-execdir", "-ok", "-okdir", "-delete", "-fprint", "-fprint0", "-fprintf", "-fls"},
+}
+
+// shellCommands 可以解释或启动任意命令的程序，无论白名单如何配置都不允许执行
+var shellCommands = []string{
+	"sh", "bash", "dash", "zsh", "ksh", "csh", "tcsh", "fish", "busybox",
+	"env", "xargs", "nohup", "setsid", "timeout", "nice", "ionice", "stdbuf", "chroot", "sudo", "su", "doas",
+	"python", "python3", "perl", "ruby", "node", "php", "lua", "awk", "gawk",
+}
+
 // SecurityConfig 安全配置结构
 type SecurityConfig struct {
 	Security SecuritySettings `yaml:"security"`
@@ -15,38 +46,84 @@ type SecurityConfig struct {
 
 // SecuritySettings 安全设置
 type SecuritySettings struct {
-	AllowedPaths    []string        `yaml:"allowed_paths"`
-	BlockedPaths    []string        `yaml:"blocked_paths"`
-	PathRules       PathRules       `yaml:"path_rules"`
-	CommandSecurity CommandSecurity `yaml:"command_security"`
-	ResourceLimits  ResourceLimits  `yaml:"resource_limits"`
+	Paths     PathSecurity    `yaml:"paths"`
+	Commands  CommandSecurity `yaml:"commands"`
+	Resources ResourceLimits  `yaml:"resources"`
+}
+
+// PathSecurity 路径访问控制
+type PathSecurity struct {
+	// Allowed 允许访问的目录，为空时不限制；相对路径基于服务器工作目录
+	Allowed []string `yaml:"allowed"`
+	// Blocked 禁止访问的路径，优先级高于Allowed，支持glob模式；不含 "/" 的条目（如 ".git"）匹配路径中的任意一段
+	Blocked []string  `yaml:"blocked"`
+	Rules   PathRules `yaml:"rules"`
 }
 
 // PathRules 路径规则
 type PathRules struct {
-	AllowRelativePaths bool     `yaml:"allow_relative_paths"`
-	AllowAbsolutePaths bool     `yaml:"allow_absolute_paths"`
+	AllowRelativePaths *bool    `yaml:"allow_relative_paths"`
+	AllowAbsolutePaths *bool    `yaml:"allow_absolute_paths"`
 	MaxPathDepth       int      `yaml:"max_path_depth"`
+	MaxPathLength      int      `yaml:"max_path_length"`
 	AllowedExtensions  []string `yaml:"allowed_extensions"`
 	BlockedExtensions  []string `yaml:"blocked_extensions"`
 }
 
 // CommandSecurity 命令安全设置
 type CommandSecurity struct {
-	AllowedCommands []string `yaml:"allowed_commands"`
-	BlockedCommands []string `yaml:"blocked_commands"`
+	AllowedCommands []string          `yaml:"allowed"`
+	BlockedCommands []string          `yaml:"blocked"`
+	Validation      CommandValidation `yaml:"validation"`
+	// DeniedArguments 命令名到禁止参数的映射，参数本身或 "参数=值" 形式都会被拒绝
+	DeniedArguments map[string][]string `yaml:"denied_arguments"`
+	Sandbox         CommandSandbox      `yaml:"sandbox"`
+}
+
+// CommandSandbox 命令执行沙箱设置
+type CommandSandbox struct {
+	// Enabled 默认启用，关闭后命令直接以服务器进程的权限和环境运行
+	Enabled *bool `yaml:"enabled"`
+	// CPUTime CPU时间上限秒数（RLIMIT_CPU），默认与超时时间相同
+	CPUTime int `yaml:"cpu_time"`
+	// MaxMemory 地址空间上限（RLIMIT_AS）
+	MaxMemory ByteSize `yaml:"max_memory"`
+	// MaxOpenFiles 打开文件数上限（RLIMIT_NOFILE）
+	MaxOpenFiles int `yaml:"max_open_files"`
+	// MaxProcesses 进程数上限（RLIMIT_NPROC），按运行用户的全部进程统计，0表示不限制
+	MaxProcesses int `yaml:"max_processes"`
+	// Env 从服务器环境继承的环境变量名，其余变量不会传给命令
+	Env []string `yaml:"env"`
+	// SetEnv 固定设置的环境变量
+	SetEnv map[string]string `yaml:"set_env"`
+	// WorkingDir 未指定working_dir时使用的工作目录，默认第一个允许路径
+	WorkingDir string `yaml:"working_dir"`
+}
+
+// CommandValidation 命令校验方式
+type CommandValidation struct {
+	// Enabled 为false时不再按白名单校验，禁止列表仍然生效
+	Enabled *bool `yaml:"enabled"`
+	// WhitelistMode 为true时只允许Allowed中的命令，否则只拒绝Blocked中的命令
+	WhitelistMode bool `yaml:"whitelist_mode"`
+	// Timeout 命令执行超时秒数
+	Timeout int `yaml:"timeout"`
 }
 
 // ResourceLimits 资源限制
 type ResourceLimits struct {
-	MaxFileSize       int64 `yaml:"max_file_size"`
-	MaxDirectoryItems int   `yaml:"max_directory_items"`
-	MaxCommandOutput  int64 `yaml:"max_command_output"`
+	MaxFileSize       ByteSize `yaml:"max_file_size"`
+	MaxDirectoryItems int      `yaml:"max_directory_items"`
+	MaxCommandOutput  ByteSize `yaml:"max_command_output"`
+	MaxHTTPResponse   ByteSize `yaml:"max_http_response"`
 }
 
 // SecurityManager 安全管理器
 type SecurityManager struct {
-	config *SecurityConfig
+	config       *SecurityConfig
+	allowedRoots []string // 规范化后的允许目录
+	blockedRoots []string // 规范化后的禁止路径（不含glob模式的条目）
+	blockedGlobs []string // 含glob模式或按路径段匹配的禁止路径
 }
 
 // NewSecurityManager 创建新的安全管理器
@@ -62,55 +139,489 @@ func NewSecurityManager(configPath string) (*SecurityManager, error) {
 		return nil, fmt.Errorf("解析配置文件失败: %v", err)
 	}
 
-	return &SecurityManager{
+	config.Security.applyDefaults()
+	settings := config.Security
+
+	sm := &SecurityManager{
 		config: &config,
-	}, nil
+	}
+
+	for _, allowed := range settings.Paths.Allowed {
+		resolved, err := ResolvePath(allowed)
+		if err != nil {
+			return nil, fmt.Errorf("解析允许路径 %s 失败: %v", allowed, err)
+		}
+		sm.allowedRoots = append(sm.allowedRoots, resolved)
+	}
+
+	for _, blocked := range settings.Paths.Blocked {
+		if isGlobPattern(blocked) || isSegmentPattern(blocked) {
+			if _, err := filepath.Match(blocked, ""); err != nil {
+				return nil, fmt.Errorf("无效的禁止路径模式 %s: %v", blocked, err)
+			}
+			sm.blockedGlobs = append(sm.blockedGlobs, blocked)
+			continue
+		}
+		resolved, err := ResolvePath(blocked)
+		if err != nil {
+			return nil, fmt.Errorf("解析禁止路径 %s 失败: %v", blocked, err)
+		}
+		// 同时记录解析前后的路径，避免链接目录（如macOS的/tmp）只匹配其中一种写法
+		absolute, _ := filepath.Abs(blocked)
+		sm.blockedRoots = append(sm.blockedRoots, resolved, absolute)
+	}
+
+	return sm, nil
+}
+
+// applyDefaults 为未配置的项填充默认值
+func (s *SecuritySettings) applyDefaults() {
+	if s.Resources.MaxFileSize <= 0 {
+		s.Resources.MaxFileSize = DefaultMaxFileSize
+	}
+	if s.Resources.MaxDirectoryItems <= 0 {
+		s.Resources.MaxDirectoryItems = DefaultMaxDirectoryItems
+	}
+	if s.Resources.MaxCommandOutput <= 0 {
+		s.Resources.MaxCommandOutput = DefaultMaxCommandOutput
+	}
+	if s.Resources.MaxHTTPResponse <= 0 {
+		s.Resources.MaxHTTPResponse = DefaultMaxHTTPResponse
+	}
+	// 完全没有配置命令列表时使用默认白名单，避免放开所有命令
+	if len(s.Commands.AllowedCommands) == 0 && len(s.Commands.BlockedCommands) == 0 {
+		s.Commands.AllowedCommands = defaultAllowedCommands
+		s.Commands.Validation.WhitelistMode = true
+	}
+	if s.Commands.DeniedArguments == nil {
+		s.Commands.DeniedArguments = defaultDeniedArguments
+	}
+	sandbox := &s.Commands.Sandbox
+	if sandbox.MaxMemory <= 0 {
+		sandbox.MaxMemory = DefaultSandboxMemory
+	}
+	if sandbox.MaxOpenFiles <= 0 {
+		sandbox.MaxOpenFiles = DefaultSandboxOpenFiles
+	}
+	if sandbox.Env == nil {
+		sandbox.Env = defaultSandboxEnv
+	}
+	s.Paths.Rules.AllowedExtensions = normalizeExtensions(s.Paths.Rules.AllowedExtensions)
+	s.Paths.Rules.BlockedExtensions = normalizeExtensions(s.Paths.Rules.BlockedExtensions)
+}
+
+// normalizeExtensions 扩展名统一为小写并带前导点
+func normalizeExtensions(extensions []string) []string {
+	normalized := make([]string, 0, len(extensions))
+	for _, ext := range extensions {
+		ext = strings.ToLower(strings.TrimSpace(ext))
+		if ext == "" {
+			continue
+		}
+		if !strings.HasPrefix(ext, ".") {
+			ext = "." + ext
+		}
+		normalized = append(normalized, ext)
+	}
+	return normalized
 }
 
 // IsPathAllowed 检查路径是否允许访问
+// 路径先转换为绝对路径并解析符号链接，再依次检查禁止路径、允许目录、深度和扩展名
 func (sm *SecurityManager) IsPathAllowed(path string) error {
-	// 简化实现：允许当前目录和子目录
-	if strings.Contains(path, "..") {
-		return fmt.Errorf("不安全的文件路径")
+	rules := sm.config.Security.Paths.Rules
+
+	if path == "" {
+		return fmt.Errorf("路径不能为空")
+	}
+	if strings.ContainsRune(path, 0) {
+		return fmt.Errorf("路径包含非法字符")
+	}
+	if rules.MaxPathLength > 0 && len(path) > rules.MaxPathLength {
+		return fmt.Errorf("路径长度超过限制 %d", rules.MaxPathLength)
+	}
+	if filepath.IsAbs(path) {
+		if rules.AllowAbsolutePaths != nil && !*rules.AllowAbsolutePaths {
+			return fmt.Errorf("不允许使用绝对路径")
+		}
+	} else if rules.AllowRelativePaths != nil && !*rules.AllowRelativePaths {
+		return fmt.Errorf("不允许使用相对路径")
+	}
+
+	absolute, err := filepath.Abs(path)
+	if err != nil {
+		return fmt.Errorf("解析路径失败: %v", err)
+	}
+	resolved, err := ResolvePath(path)
+	if err != nil {
+		return fmt.Errorf("解析路径失败: %v", err)
+	}
+
+	// 解析前后的路径都不能命中禁止列表，防止通过符号链接或..绕过
+	for _, candidate := range []string{absolute, resolved} {
+		if sm.isBlocked(candidate) {
+			return fmt.Errorf("路径 %s 被禁止访问", path)
+		}
+	}
+
+	if len(sm.allowedRoots) > 0 && !sm.isInAllowedRoots(resolved) {
+		return fmt.Errorf("路径 %s 不在允许访问的目录内", path)
+	}
+
+	if rules.MaxPathDepth > 0 && pathDepth(resolved) > rules.MaxPathDepth {
+		return fmt.Errorf("路径深度超过限制 %d", rules.MaxPathDepth)
+	}
+
+	// 目录不检查扩展名
+	if info, err := os.Stat(resolved); err == nil && info.IsDir() {
+		return nil
+	}
+	return sm.checkExtension(path, resolved)
+}
+
+// isBlocked 判断规范化后的路径是否命中禁止列表
+func (sm *SecurityManager) isBlocked(path string) bool {
+	for _, blocked := range sm.blockedRoots {
+		if PathWithin(blocked, path) {
+			return true
+		}
+	}
+	for _, pattern := range sm.blockedGlobs {
+		if matchPathPattern(pattern, path) {
+			return true
+		}
+	}
+	return false
+}
+
+// isInAllowedRoots 判断路径是否位于某个允许目录内
+func (sm *SecurityManager) isInAllowedRoots(path string) bool {
+	for _, root := range sm.allowedRoots {
+		if PathWithin(root, path) {
+			return true
+		}
+	}
+	return false
+}
+
+// checkExtension 检查扩展名，链接本身和链接目标的扩展名都需要满足规则
+func (sm *SecurityManager) checkExtension(path, resolved string) error {
+	rules := sm.config.Security.Paths.Rules
+	extensions := []string{
+		strings.ToLower(filepath.Ext(path)),
+		strings.ToLower(filepath.Ext(resolved)),
+	}
+
+	for _, ext := range extensions {
+		if containsString(rules.BlockedExtensions, ext) {
+			return fmt.Errorf("不允许访问 %s 类型的文件", ext)
+		}
+		if len(rules.AllowedExtensions) > 0 && !containsString(rules.AllowedExtensions, ext) {
+			return fmt.Errorf("不允许访问 %s 类型的文件", displayExtension(ext))
+		}
 	}
 	return nil
 }
 
+// displayExtension 用于错误信息的扩展名，没有扩展名时显示说明文字
+func displayExtension(ext string) string {
+	if ext == "" {
+		return "无扩展名"
+	}
+	return ext
+}
+
 // CheckFileSize 检查文件大小
 func (sm *SecurityManager) CheckFileSize(size int64) error {
-	// 简化实现：允许最大100MB
-	if size > 100*1024*1024 {
-		return fmt.Errorf("文件大小超过限制")
+	limit := sm.config.Security.Resources.MaxFileSize
+	if size > int64(limit) {
+		return fmt.Errorf("文件大小超过限制 %s", limit)
 	}
 	return nil
 }
 
 // IsCommandAllowed 检查命令是否允许执行
+// 命令按程序名匹配，带路径的命令（如/bin/rm）同样受禁止列表约束
 func (sm *SecurityManager) IsCommandAllowed(command string) error {
-	// 简化实现：允许基本命令
-	allowedCommands := []string{"ls", "cat", "echo", "pwd", "whoami", "date", "ps", "top", "mkdir", "rm", "cp", "mv"}
-	for _, allowed := range allowedCommands {
-		if command == allowed {
-			return nil
+	commands := sm.config.Security.Commands
+
+	name := strings.TrimSpace(command)
+	if name == "" {
+		return fmt.Errorf("命令不能为空")
+	}
+	if strings.ContainsAny(name, " \t\n;|&$`<>") {
+		return fmt.Errorf("命令 %s 包含非法字符", command)
+	}
+	base := filepath.Base(name)
+
+	// 禁止列表和shell判断不区分大小写，避免在不区分大小写的文件系统上用 "RM"、"Bash" 绕过
+	if containsFold(commands.BlockedCommands, name) || containsFold(commands.BlockedCommands, base) {
+		return fmt.Errorf("命令 %s 被禁止执行", command)
+	}
+	// 命令不经过shell执行，也不允许借助解释器或包装程序执行任意命令
+	if isShellCommand(base) {
+		return fmt.Errorf("命令 %s 可以执行任意命令，不允许使用", command)
+	}
+	validationEnabled := commands.Validation.Enabled == nil || *commands.Validation.Enabled
+	if validationEnabled && commands.Validation.WhitelistMode {
+		// 白名单只接受不带路径的命令名，避免用同名程序替换
+		if name != base || !containsString(commands.AllowedCommands, name) {
+			return fmt.Errorf("命令 %s 不被允许执行", command)
 		}
 	}
-	return fmt.Errorf("命令 %s 不被允许执行", command)
+	return nil
+}
+
+// CheckCommandArguments 检查命令参数
+// 禁止使用denied_arguments中配置的参数；绝对路径或含 ".." 的参数按路径规则检查，
+// 其余参数基于工作目录解析，对应的文件或链接存在时同样检查，防止经由符号链接访问允许目录之外的路径。
+// 选项中夹带的路径（"--file=/etc/passwd"、"-f/etc/passwd"）一并检查
+func (sm *SecurityManager) CheckCommandArguments(command string, args []string, workingDir string) error {
+	denied := sm.config.Security.Commands.DeniedArguments[filepath.Base(command)]
+	for _, arg := range args {
+		if strings.ContainsRune(arg, 0) {
+			return fmt.Errorf("参数包含非法字符")
+		}
+		flag, value, hasValue := strings.Cut(arg, "=")
+		if containsString(denied, arg) || containsString(denied, flag) {
+			return fmt.Errorf("命令 %s 不允许使用参数 %s", command, flag)
+		}
+
+		candidates := []string{arg}
+		if strings.HasPrefix(arg, "-") {
+			if hasValue {
+				candidates = append(candidates, value)
+			}
+			if i := strings.IndexAny(arg, "/."); i > 0 {
+				candidates = append(candidates, arg[i:])
+			}
+		}
+		for _, path := range candidates {
+			if !filepath.IsAbs(path) {
+				explicit := hasParentReference(path)
+				path = filepath.Join(workingDir, path)
+				if _, err := os.Lstat(path); !explicit && err != nil {
+					continue
+				}
+			}
+			if err := sm.IsPathAllowed(path); err != nil {
+				return fmt.Errorf("参数 %s 检查失败: %v", arg, err)
+			}
+		}
+	}
+	return nil
+}
+
+// CommandWorkingDir 确定命令的工作目录并返回解析后的绝对路径
+// 未指定时使用沙箱配置的工作目录、第一个允许路径或服务器工作目录；工作目录必须是允许访问的已存在目录
+func (sm *SecurityManager) CommandWorkingDir(dir string) (string, error) {
+	if dir == "" {
+		dir = sm.config.Security.Commands.Sandbox.WorkingDir
+	}
+	if dir == "" && len(sm.config.Security.Paths.Allowed) > 0 {
+		dir = sm.config.Security.Paths.Allowed[0]
+	}
+	if dir == "" {
+		dir = "."
+	}
+
+	if err := sm.IsPathAllowed(dir); err != nil {
+		return "", err
+	}
+	resolved, err := ResolvePath(dir)
+	if err != nil {
+		return "", fmt.Errorf("解析工作目录失败: %v", err)
+	}
+	// 没有配置允许路径时，工作目录限制在服务器工作目录内
+	if len(sm.allowedRoots) == 0 {
+		cwd, err := ResolvePath(".")
+		if err != nil {
+			return "", fmt.Errorf("解析服务器工作目录失败: %v", err)
+		}
+		if !PathWithin(cwd, resolved) {
+			return "", fmt.Errorf("工作目录 %s 不在服务器工作目录内", dir)
+		}
+	}
+	info, err := os.Stat(resolved)
+	if err != nil {
+		return "", fmt.Errorf("工作目录不可用: %v", err)
+	}
+	if !info.IsDir() {
+		return "", fmt.Errorf("工作目录 %s 不是目录", dir)
+	}
+	return resolved, nil
+}
+
+// CommandSandbox 获取命令执行沙箱设置
+func (sm *SecurityManager) CommandSandbox() *CommandSandbox {
+	return &sm.config.Security.Commands.Sandbox
+}
+
+// SandboxEnabled 是否在沙箱中执行命令
+func (sm *SecurityManager) SandboxEnabled() bool {
+	enabled := sm.config.Security.Commands.Sandbox.Enabled
+	return enabled == nil || *enabled
+}
+
+// MaxCommandOutput 获取命令输出大小上限
+func (sm *SecurityManager) MaxCommandOutput() int64 {
+	return int64(sm.config.Security.Resources.MaxCommandOutput)
+}
+
+// CommandTimeout 获取命令执行超时时间
+func (sm *SecurityManager) CommandTimeout() time.Duration {
+	if timeout := sm.config.Security.Commands.Validation.Timeout; timeout > 0 {
+		return time.Duration(timeout) * time.Second
+	}
+	return DefaultCommandTimeout
 }
 
 // CheckCommandOutput 检查命令输出大小
 func (sm *SecurityManager) CheckCommandOutput(size int64) error {
-	// 简化实现：允许最大10MB输出
-	if size > 10*1024*1024 {
-		return fmt.Errorf("命令输出大小超过限制")
+	limit := sm.config.Security.Resources.MaxCommandOutput
+	if size > int64(limit) {
+		return fmt.Errorf("命令输出大小超过限制 %s", limit)
+	}
+	return nil
+}
+
+// CheckHTTPResponse 检查HTTP响应体大小
+func (sm *SecurityManager) CheckHTTPResponse(size int64) error {
+	limit := sm.config.Security.Resources.MaxHTTPResponse
+	if size > int64(limit) {
+		return fmt.Errorf("HTTP响应大小超过限制 %s", limit)
 	}
 	return nil
 }
 
+// MaxHTTPResponse 获取HTTP响应体的大小上限，用于限制读取量
+func (sm *SecurityManager) MaxHTTPResponse() int64 {
+	return int64(sm.config.Security.Resources.MaxHTTPResponse)
+}
+
 // CheckDirectoryItems 检查目录项数
 func (sm *SecurityManager) CheckDirectoryItems(count int) error {
-	// 简化实现：允许最大1000个目录项
-	if count > 1000 {
-		return fmt.Errorf("目录项数超过限制")
+	limit := sm.config.Security.Resources.MaxDirectoryItems
+	if count > limit {
+		return fmt.Errorf("目录项数超过限制 %d", limit)
 	}
 	return nil
 }
+
+// ResolvePath 将路径转换为绝对路径并解析符号链接
+// 路径尚不存在时（如即将创建的文件）解析最近的已存在上级目录，再拼接剩余部分
+func ResolvePath(path string) (string, error) {
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return "", err
+	}
+
+	existing := absPath
+	var rest []string
+	for {
+		resolved, err := filepath.EvalSymlinks(existing)
+		if err == nil {
+			return filepath.Join(append([]string{resolved}, rest...)...), nil
+		}
+		if !os.IsNotExist(err) {
+			return "", err
+		}
+		parent := filepath.Dir(existing)
+		if parent == existing {
+			return absPath, nil
+		}
+		rest = append([]string{filepath.Base(existing)}, rest...)
+		existing = parent
+	}
+}
+
+// PathWithin 判断path是否等于root或位于root之下，两者都应是规范化的绝对路径
+func PathWithin(root, path string) bool {
+	rel, err := filepath.Rel(root, path)
+	if err != nil {
+		return false
+	}
+	return rel == "." || (rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)))
+}
+
+// isGlobPattern 判断路径配置是否包含glob通配符
+func isGlobPattern(pattern string) bool {
+	return strings.ContainsAny(pattern, "*?[")
+}
+
+// isSegmentPattern 判断禁止项是否不含路径分隔符，这类条目（如 ".git"）按路径段匹配
+func isSegmentPattern(pattern string) bool {
+	return !strings.ContainsAny(pattern, "/"+string(filepath.Separator)) && pattern != "." && pattern != ".."
+}
+
+// matchPathPattern 用glob模式匹配路径，不区分大小写
+// 不含路径分隔符的模式（如 "*.pem"、".git"）匹配路径中的任意一段，
+// 其余模式匹配路径本身或它的任意上级目录
+func matchPathPattern(pattern, path string) bool {
+	pattern, path = strings.ToLower(pattern), strings.ToLower(path)
+
+	if !strings.ContainsRune(pattern, filepath.Separator) {
+		for _, part := range strings.Split(path, string(filepath.Separator)) {
+			if matched, _ := filepath.Match(pattern, part); matched {
+				return true
+			}
+		}
+		return false
+	}
+
+	for current := path; ; current = filepath.Dir(current) {
+		if matched, _ := filepath.Match(pattern, current); matched {
+			return true
+		}
+		if parent := filepath.Dir(current); parent == current {
+			return false
+		}
+	}
+}
+
+// pathDepth 绝对路径的层级数
+func pathDepth(path string) int {
+	depth := 0
+	for _, part := range strings.Split(filepath.Clean(path), string(filepath.Separator)) {
+		if part != "" {
+			depth++
+		}
+	}
+	return depth
+}
+
+// hasParentReference 判断路径中是否包含 ".." 段
+func hasParentReference(path string) bool {
+	for _, part := range strings.Split(filepath.ToSlash(path), "/") {
+		if part == ".." {
+			return true
+		}
+	}
+	return false
+}
+
+// isShellCommand 判断程序名是否属于shell或解释器，不区分大小写，带版本号的名称（如 python3.12）同样匹配
+func isShellCommand(name string) bool {
+	name = strings.ToLower(name)
+	return containsString(shellCommands, name) || containsString(shellCommands, strings.TrimRight(name, "0123456789."))
+}
+
+// containsFold 判断列表中是否包含指定字符串，不区分大小写
+func containsFold(list []string, value string) bool {
+	for _, item := range list {
+		if strings.EqualFold(item, value) {
+			return true
+		}
+	}
+	return false
+}
+
+// containsString 判断列表中是否包含指定字符串
+func containsString(list []string, value string) bool {
+	for _, item := range list {
+		if item == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,248 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// newTestSecurityManager 在临时目录中创建如下结构，并以 allowed 为唯一允许目录加载安全配置：
+//
+//	allowed/notes.txt
+//	allowed/private/key.txt          禁止路径
+//	allowed/.git/config              禁止的glob ".git"
+//	allowed/certs/server.pem         禁止的glob "*.pem"
+//	allowed/escape -> ../secret      指向允许目录之外的符号链接
+//	allowed/key-link.txt -> ../secret/key.txt
+//	secret/key.txt
+func newTestSecurityManager(t *testing.T, commands string) (*SecurityManager, string) {
+	t.Helper()
+
+	root := t.TempDir()
+	allowed := filepath.Join(root, "allowed")
+	files := []string{
+		"allowed/notes.txt",
+		"allowed/private/key.txt",
+		"allowed/.git/config",
+		"allowed/certs/server.pem",
+		"secret/key.txt",
+	}
+	for _, file := range files {
+		path := filepath.Join(root, file)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte("x"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.Symlink(filepath.Join(root, "secret"), filepath.Join(allowed, "escape")); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(filepath.Join(root, "secret", "key.txt"), filepath.Join(allowed, "key-link.txt")); err != nil {
+		t.Fatal(err)
+	}
+
+	configYAML := fmt.Sprintf(`
+security:
+  paths:
+    allowed: [%q]
+    blocked: [%q, ".git", "*.pem"]
+    rules:
+      blocked_extensions: [".sh", ".exe"]
+  commands:
+%s
+`, allowed, filepath.Join(allowed, "private"), commands)
+
+	configPath := filepath.Join(root, "config.yaml")
+	if err := os.WriteFile(configPath, []byte(configYAML), 0644); err != nil {
+		t.Fatal(err)
+	}
+	sm, err := NewSecurityManager(configPath)
+	if err != nil {
+		t.Fatalf("NewSecurityManager: %v", err)
+	}
+	return sm, allowed
+}
+
+const whitelistCommands = `    allowed: ["ls", "cat", "grep", "find", "env", "sh"]
+    blocked: ["rm"]
+    validation:
+      whitelist_mode: true`
+
+func TestIsPathAllowed(t *testing.T) {
+	sm, allowed := newTestSecurityManager(t, whitelistCommands)
+	root := filepath.Dir(allowed)
+
+	tests := []struct {
+		name    string
+		path    string
+		allowed bool
+	}{
+		{name: "plain file", path: filepath.Join(allowed, "notes.txt"), allowed: true},
+		{name: "allowed root", path: allowed, allowed: true},
+		{name: "allowed root trailing slash", path: allowed + "/", allowed: true},
+		{name: "new file", path: filepath.Join(allowed, "new", "file.txt"), allowed: true},
+
+		// .. 穿越
+		{name: "dotdot out of root", path: allowed + "/../secret/key.txt"},
+		{name: "dotdot back into root", path: allowed + "/private/../notes.txt", allowed: true},
+		{name: "dotdot into blocked", path: allowed + "/certs/../private/key.txt"},
+		{name: "dotdot to filesystem root", path: allowed + "/../../../../etc/passwd"},
+
+		// 符号链接逃逸
+		{name: "symlinked directory", path: filepath.Join(allowed, "escape", "key.txt")},
+		{name: "symlinked file", path: filepath.Join(allowed, "key-link.txt")},
+		{name: "new file under symlink", path: filepath.Join(allowed, "escape", "new.txt")},
+
+		// 禁止路径和glob
+		{name: "blocked directory", path: filepath.Join(allowed, "private")},
+		{name: "blocked file", path: filepath.Join(allowed, "private", "key.txt")},
+		{name: "blocked glob segment", path: filepath.Join(allowed, ".git", "config")},
+		{name: "blocked glob extension", path: filepath.Join(allowed, "certs", "server.pem")},
+		{name: "blocked extension", path: filepath.Join(allowed, "run.sh")},
+
+		// 大小写和结尾斜杠
+		{name: "blocked directory trailing slash", path: filepath.Join(allowed, "private") + "/"},
+		{name: "blocked directory dot suffix", path: filepath.Join(allowed, "private") + "/."},
+		{name: "blocked directory double slash", path: filepath.Join(allowed, "private") + "//key.txt"},
+		{name: "blocked glob upper case", path: filepath.Join(allowed, "certs", "SERVER.PEM")},
+		{name: "blocked glob mixed case segment", path: filepath.Join(allowed, ".Git", "config")},
+		{name: "blocked extension upper case", path: filepath.Join(allowed, "RUN.SH")},
+
+		// 其它
+		{name: "outside allowed roots", path: filepath.Join(root, "secret", "key.txt")},
+		{name: "empty path", path: ""},
+		{name: "nul byte", path: filepath.Join(allowed, "notes.txt\x00.sh")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := sm.IsPathAllowed(tt.path)
+			if tt.allowed && err != nil {
+				t.Errorf("IsPathAllowed(%q) = %v, want allowed", tt.path, err)
+			}
+			if !tt.allowed && err == nil {
+				t.Errorf("IsPathAllowed(%q) allowed, want error", tt.path)
+			}
+		})
+	}
+}
+
+func TestIsCommandAllowed(t *testing.T) {
+	tests := []struct {
+		name     string
+		commands string
+		command  string
+		allowed  bool
+	}{
+		{name: "whitelisted", commands: whitelistCommands, command: "ls", allowed: true},
+		{name: "not whitelisted", commands: whitelistCommands, command: "touch"},
+		{name: "whitelisted with path", commands: whitelistCommands, command: "/bin/ls"},
+		{name: "whitelist upper case", commands: whitelistCommands, command: "LS"},
+		{name: "blocked", commands: whitelistCommands, command: "rm"},
+		{name: "blocked with path", commands: whitelistCommands, command: "/bin/rm"},
+
+		// 即使出现在白名单中，shell和包装程序也不允许执行
+		{name: "whitelisted shell", commands: whitelistCommands, command: "sh"},
+		{name: "whitelisted env", commands: whitelistCommands, command: "env"},
+
+		// 命令名中夹带参数或shell语法
+		{name: "embedded argument", commands: whitelistCommands, command: "ls -la"},
+		{name: "command chaining", commands: whitelistCommands, command: "ls;rm"},
+		{name: "pipe", commands: whitelistCommands, command: "cat|sh"},
+		{name: "and list", commands: whitelistCommands, command: "ls&&rm"},
+		{name: "substitution", commands: whitelistCommands, command: "$(rm)"},
+		{name: "backtick", commands: whitelistCommands, command: "`rm`"},
+		{name: "redirect", commands: whitelistCommands, command: "cat>x"},
+		{name: "newline", commands: whitelistCommands, command: "ls\nrm"},
+		{name: "empty", commands: whitelistCommands, command: "  "},
+	}
+
+	// 黑名单模式下白名单不生效，只能依靠禁止列表和shell判断
+	blacklist := `    blocked: ["rm"]
+    validation:
+      whitelist_mode: false`
+	for _, command := range []string{"bash", "/bin/sh", "BASH", "Sh", "python3.12", "perl", "env", "xargs", "busybox", "timeout", "nice", "nohup", "sudo", "RM", "/usr/bin/Rm"} {
+		tests = append(tests, struct {
+			name     string
+			commands string
+			command  string
+			allowed  bool
+		}{name: "blacklist " + command, commands: blacklist, command: command})
+	}
+	tests = append(tests, struct {
+		name     string
+		commands string
+		command  string
+		allowed  bool
+	}{name: "blacklist ordinary", commands: blacklist, command: "touch", allowed: true})
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sm, _ := newTestSecurityManager(t, tt.commands)
+			err := sm.IsCommandAllowed(tt.command)
+			if tt.allowed && err != nil {
+				t.Errorf("IsCommandAllowed(%q) = %v, want allowed", tt.command, err)
+			}
+			if !tt.allowed && err == nil {
+				t.Errorf("IsCommandAllowed(%q) allowed, want error", tt.command)
+			}
+		})
+	}
+}
+
+func TestCheckCommandArguments(t *testing.T) {
+	sm, allowed := newTestSecurityManager(t, whitelistCommands)
+	root := filepath.Dir(allowed)
+
+	tests := []struct {
+		name    string
+		command string
+		args    []string
+		allowed bool
+	}{
+		{name: "relative file", command: "cat", args: []string{"notes.txt"}, allowed: true},
+		{name: "plain words", command: "grep", args: []string{"-rn", "TODO", "."}, allowed: true},
+		{name: "find name pattern", command: "find", args: []string{".", "-name", "*.go"}, allowed: true},
+		{name: "absolute inside root", command: "cat", args: []string{filepath.Join(allowed, "notes.txt")}, allowed: true},
+
+		{name: "absolute outside root", command: "cat", args: []string{filepath.Join(root, "secret", "key.txt")}},
+		{name: "system file", command: "cat", args: []string{"/etc/passwd"}},
+		{name: "dotdot", command: "cat", args: []string{"../secret/key.txt"}},
+		{name: "dotdot to non-existent", command: "ls", args: []string{"../../nowhere"}},
+		{name: "blocked relative", command: "cat", args: []string{"private/key.txt"}},
+		{name: "blocked glob relative", command: "cat", args: []string{".git/config"}},
+		{name: "symlink escape", command: "cat", args: []string{"escape/key.txt"}},
+		{name: "symlinked file", command: "cat", args: []string{"key-link.txt"}},
+
+		// 选项中夹带的路径
+		{name: "long option value", command: "grep", args: []string{"--file=/etc/passwd", "x"}},
+		{name: "long option dotdot", command: "grep", args: []string{"--file=../secret/key.txt", "x"}},
+		{name: "short option attached", command: "grep", args: []string{"-f/etc/passwd", "x"}},
+		{name: "short option attached dotdot", command: "grep", args: []string{"-f../secret/key.txt", "x"}},
+		{name: "combined short options", command: "grep", args: []string{"-rf/etc/passwd", "x"}},
+
+		// denied_arguments
+		{name: "find exec", command: "find", args: []string{".", "-exec", "rm", "{}", ";"}},
+		{name: "find delete", command: "find", args: []string{".", "-delete"}},
+		{name: "find fprint with value", command: "find", args: []string{".", "-fprint=out.txt"}},
+		{name: "find with path", command: "/usr/bin/find", args: []string{".", "-execdir", "sh"}},
+
+		{name: "nul byte", command: "cat", args: []string{"notes.txt\x00"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := sm.CheckCommandArguments(tt.command, tt.args, allowed)
+			if tt.allowed && err != nil {
+				t.Errorf("CheckCommandArguments(%s %s) = %v, want allowed", tt.command, strings.Join(tt.args, " "), err)
+			}
+			if !tt.allowed && err == nil {
+				t.Errorf("CheckCommandArguments(%s %s) allowed, want error", tt.command, strings.Join(tt.args, " "))
+			}
+		})
+	}
+}
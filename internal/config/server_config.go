@@ -0,0 +1,73 @@
+package config
+
+import (
+	"fmt"
+	"os"
+
+	"gopkg.in/yaml.v3"
+)
+
+// 默认每个连接允许同时执行的请求数
+const DefaultMaxInFlightRequests = 16
+
+// 默认每个连接最多排队等待的请求数
+const DefaultMaxQueuedRequests = 64
+
+// ServerSettings 服务器基础设置
+type ServerSettings struct {
+	Name                string `yaml:"name"`
+	Version             string `yaml:"version"`
+	Mode                string `yaml:"mode"`
+	Description         string `yaml:"description"`
+	MaxInFlightRequests int    `yaml:"max_in_flight_requests"`
+	MaxQueuedRequests   int    `yaml:"max_queued_requests"`
+}
+
+// ServerConfig 服务器配置结构
+type ServerConfig struct {
+	Server ServerSettings `yaml:"server"`
+}
+
+// ServerConfigManager 服务器配置管理器
+type ServerConfigManager struct {
+	config *ServerConfig
+}
+
+// NewServerConfigManager 创建服务器配置管理器
+func NewServerConfigManager(configPath string) (*ServerConfigManager, error) {
+	// 读取配置文件
+	data, err := os.ReadFile(configPath)
+	if err != nil {
+		return nil, fmt.Errorf("读取配置文件失败: %v", err)
+	}
+
+	var config ServerConfig
+	if err := yaml.Unmarshal(data, &config); err != nil {
+		return nil, fmt.Errorf("解析配置文件失败: %v", err)
+	}
+
+	return &ServerConfigManager{
+		config: &config,
+	}, nil
+}
+
+// GetServerSettings 获取服务器基础设置
+func (m *ServerConfigManager) GetServerSettings() *ServerSettings {
+	return &m.config.Server
+}
+
+// GetMaxInFlightRequests 获取每个连接的最大并发请求数，未配置时使用默认值
+func (m *ServerConfigManager) GetMaxInFlightRequests() int {
+	if m.config.Server.MaxInFlightRequests <= 0 {
+		return DefaultMaxInFlightRequests
+	}
+	return m.config.Server.MaxInFlightRequests
+}
+
+// GetMaxQueuedRequests 获取每个连接最多排队等待的请求数，未配置时使用默认值
+func (m *ServerConfigManager) GetMaxQueuedRequests() int {
+	if m.config.Server.MaxQueuedRequests <= 0 {
+		return DefaultMaxQueuedRequests
+	}
+	return m.config.Server.MaxQueuedRequests
+}
@@ -0,0 +1,122 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// 客户端证书校验方式
+const (
+	ClientAuthNone     = "none"
+	ClientAuthOptional = "optional"
+	ClientAuthRequire  = "require"
+)
+
+// CORSSettings 跨域设置，用于校验WebSocket升级请求的Origin
+type CORSSettings struct {
+	// Enabled 为false时只接受来自localhost/127.0.0.1/::1页面的请求
+	Enabled bool `yaml:"enabled"`
+	// AllowedOrigins 允许的来源，如 "https://app.example.com"、"https://*.example.com"，"*" 表示全部
+	AllowedOrigins []string `yaml:"allowed_origins"`
+}
+
+// TLSSettings TLS和双向TLS设置
+type TLSSettings struct {
+	Enabled  bool   `yaml:"enabled"`
+	CertFile string `yaml:"cert_file"`
+	KeyFile  string `yaml:"key_file"`
+	// ClientCAFile 校验客户端证书的CA，配置后才能使用mTLS
+	ClientCAFile string `yaml:"client_ca_file"`
+	// ClientAuth 客户端证书校验方式: none, optional, require
+	ClientAuth string `yaml:"client_auth"`
+	// ClientPrincipals 证书标识（CN、DNS或邮箱SAN）到认证主体的映射，为空时使用证书CN
+	ClientPrincipals map[string]string `yaml:"client_principals"`
+}
+
+// WebSocketSettings WebSocket监听设置
+type WebSocketSettings struct {
+	Host string       `yaml:"host"`
+	Port int          `yaml:"port"`
+	Path string       `yaml:"path"`
+	CORS CORSSettings `yaml:"cors"`
+	TLS  TLSSettings  `yaml:"tls"`
+}
+
+// WebSocketConfig WebSocket配置结构
+type WebSocketConfig struct {
+	WebSocket WebSocketSettings `yaml:"websocket"`
+}
+
+// WebSocketConfigManager WebSocket配置管理器
+type WebSocketConfigManager struct {
+	config *WebSocketConfig
+}
+
+// NewWebSocketConfigManager 创建WebSocket配置管理器
+func NewWebSocketConfigManager(configPath string) (*WebSocketConfigManager, error) {
+	// 读取配置文件
+	data, err := os.ReadFile(configPath)
+	if err != nil {
+		return nil, fmt.Errorf("读取配置文件失败: %v", err)
+	}
+
+	var config WebSocketConfig
+	if err := yaml.Unmarshal(data, &config); err != nil {
+		return nil, fmt.Errorf("解析配置文件失败: %v", err)
+	}
+
+	settings := &config.WebSocket
+	if settings.Path == "" {
+		settings.Path = "/"
+	}
+	if !strings.HasPrefix(settings.Path, "/") {
+		settings.Path = "/" + settings.Path
+	}
+
+	if err := normalizeTLSSettings(&settings.TLS); err != nil {
+		return nil, err
+	}
+
+	return &WebSocketConfigManager{
+		config: &config,
+	}, nil
+}
+
+// GetSettings 获取WebSocket监听设置
+func (m *WebSocketConfigManager) GetSettings() *WebSocketSettings {
+	return &m.config.WebSocket
+}
+
+// GetAllowedOrigins 获取允许的来源，未启用CORS时返回nil（只接受来自本机回环地址的页面）
+func (m *WebSocketConfigManager) GetAllowedOrigins() []string {
+	if !m.config.WebSocket.CORS.Enabled {
+		return nil
+	}
+	return m.config.WebSocket.CORS.AllowedOrigins
+}
+
+// normalizeTLSSettings 补全客户端证书校验方式的默认值并校验TLS设置，各网络传输层共用
+func normalizeTLSSettings(tls *TLSSettings) error {
+	switch tls.ClientAuth {
+	case "":
+		tls.ClientAuth = ClientAuthNone
+		if tls.ClientCAFile != "" {
+			tls.ClientAuth = ClientAuthRequire
+		}
+	case ClientAuthNone, ClientAuthOptional, ClientAuthRequire:
+	default:
+		return fmt.Errorf("无效的client_auth配置: %s", tls.ClientAuth)
+	}
+	if tls.Enabled {
+		if tls.CertFile == "" || tls.KeyFile == "" {
+			return fmt.Errorf("启用TLS需要配置cert_file和key_file")
+		}
+		if tls.ClientAuth != ClientAuthNone && tls.ClientCAFile == "" {
+			return fmt.Errorf("校验客户端证书需要配置client_ca_file")
+		}
+	}
+	return nil
+}
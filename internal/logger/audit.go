@@ -0,0 +1,226 @@
+package logger
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// AuditRecord 一次工具调用的审计记录，以JSON Lines格式追加写入
+type AuditRecord struct {
+	Time       time.Time              `json:"time"`
+	Session    string                 `json:"session,omitempty"`
+	RemoteAddr string                 `json:"remote_addr,omitempty"`
+	Client     string                 `json:"client,omitempty"`
+	Principal  string                 `json:"principal,omitempty"`
+	AuthMethod string                 `json:"auth_method,omitempty"`
+	Tool       string                 `json:"tool"`
+	ParentTool string                 `json:"parent_tool,omitempty"` // 由其它工具发起的嵌套调用，记录发起调用的工具
+	Arguments  map[string]interface{} `json:"arguments,omitempty"`
+	DurationMS float64                `json:"duration_ms"`
+	ResultSize int                    `json:"result_size"`
+	IsError    bool                   `json:"is_error,omitempty"`
+	Error      string                 `json:"error,omitempty"`
+	SideEffect map[string]interface{} `json:"side_effect,omitempty"`
+}
+
+// AuditLog 只追加的审计日志，超过大小上限时轮转为 path.1、path.2 ...
+type AuditLog struct {
+	mu         sync.Mutex
+	path       string
+	maxSize    int64
+	maxBackups int
+	file       *os.File
+	size       int64
+}
+
+// NewAuditLog 打开审计日志，maxSize为0时不轮转
+func NewAuditLog(path string, maxSize int64, maxBackups int) (*AuditLog, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, fmt.Errorf("创建审计日志目录失败: %v", err)
+	}
+
+	a := &AuditLog{
+		path:       path,
+		maxSize:    maxSize,
+		maxBackups: maxBackups,
+	}
+	if err := a.open(); err != nil {
+		return nil, err
+	}
+	return a, nil
+}
+
+// open 以追加方式打开当前日志文件
+func (a *AuditLog) open() error {
+	file, err := os.OpenFile(a.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		return fmt.Errorf("打开审计日志失败: %v", err)
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return fmt.Errorf("读取审计日志信息失败: %v", err)
+	}
+	a.file = file
+	a.size = info.Size()
+	return nil
+}
+
+// Write 追加一条审计记录，每条记录单独一行
+func (a *AuditLog) Write(record AuditRecord) error {
+	data, err := json.Marshal(record)
+	if err != nil {
+		return fmt.Errorf("序列化审计记录失败: %v", err)
+	}
+	data = append(data, '\n')
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if a.file == nil {
+		return fmt.Errorf("审计日志已关闭")
+	}
+	if a.maxSize > 0 && a.size > 0 && a.size+int64(len(data)) > a.maxSize {
+		if err := a.rotate(); err != nil {
+			return err
+		}
+	}
+
+	n, err := a.file.Write(data)
+	a.size += int64(n)
+	if err != nil {
+		return fmt.Errorf("写入审计日志失败: %v", err)
+	}
+	return nil
+}
+
+// rotate 轮转日志：path.N-1 -> path.N ... path -> path.1，超出maxBackups的最旧文件被删除
+func (a *AuditLog) rotate() error {
+	if err := a.file.Close(); err != nil {
+		return fmt.Errorf("关闭审计日志失败: %v", err)
+	}
+	a.file = nil
+
+	if a.maxBackups > 0 {
+		os.Remove(backupPath(a.path, a.maxBackups))
+		for i := a.maxBackups - 1; i >= 1; i-- {
+			os.Rename(backupPath(a.path, i), backupPath(a.path, i+1))
+		}
+		if err := os.Rename(a.path, backupPath(a.path, 1)); err != nil {
+			return fmt.Errorf("轮转审计日志失败: %v", err)
+		}
+	} else if err := os.Remove(a.path); err != nil {
+		return fmt.Errorf("轮转审计日志失败: %v", err)
+	}
+
+	return a.open()
+}
+
+// Close 关闭审计日志
+func (a *AuditLog) Close() error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if a.file == nil {
+		return nil
+	}
+	err := a.file.Close()
+	a.file = nil
+	return err
+}
+
+// backupPath 第n个轮转文件的路径
+func backupPath(path string, n int) string {
+	return fmt.Sprintf("%s.%d", path, n)
+}
+
+// AuditFilter 查询审计记录的条件，零值字段不参与过滤
+type AuditFilter struct {
+	Tool       string
+	Principal  string
+	Session    string
+	Since      time.Time
+	Until      time.Time
+	ErrorsOnly bool
+}
+
+// Match 判断记录是否满足条件
+func (f AuditFilter) Match(record AuditRecord) bool {
+	if f.Tool != "" && record.Tool != f.Tool {
+		return false
+	}
+	if f.Principal != "" && record.Principal != f.Principal {
+		return false
+	}
+	if f.Session != "" && record.Session != f.Session {
+		return false
+	}
+	if !f.Since.IsZero() && record.Time.Before(f.Since) {
+		return false
+	}
+	if !f.Until.IsZero() && record.Time.After(f.Until) {
+		return false
+	}
+	if f.ErrorsOnly && !record.IsError {
+		return false
+	}
+	return true
+}
+
+// ReadAuditRecords 按时间顺序读取审计日志（先读轮转文件，再读当前文件），
+// 对满足条件的记录调用visit，visit返回false时停止；无法解析的行被跳过
+func ReadAuditRecords(path string, filter AuditFilter, visit func(AuditRecord) bool) error {
+	var files []string
+	for i := 1; ; i++ {
+		backup := backupPath(path, i)
+		if _, err := os.Stat(backup); err != nil {
+			break
+		}
+		files = append([]string{backup}, files...)
+	}
+	files = append(files, path)
+
+	for _, file := range files {
+		more, err := readAuditFile(file, filter, visit)
+		if err != nil {
+			return err
+		}
+		if !more {
+			return nil
+		}
+	}
+	return nil
+}
+
+// readAuditFile 读取单个审计日志文件，返回是否继续读取
+func readAuditFile(path string, filter AuditFilter, visit func(AuditRecord) bool) (bool, error) {
+	file, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return true, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("打开审计日志失败: %v", err)
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		var record AuditRecord
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			continue
+		}
+		if filter.Match(record) && !visit(record) {
+			return false, nil
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return false, fmt.Errorf("读取审计日志失败: %v", err)
+	}
+	return true, nil
+}
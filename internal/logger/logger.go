@@ -122,9 +122,9 @@ func (l *Logger) writeToFile(level LogLevel, message string) {
 	file.Sync()
 }
 
-// Log 记录日志（同时输出到控制台和文件）
+// Log 记录日志（同时输出到控制台和文件），内容先经过脱敏
 func (l *Logger) Log(level LogLevel, format string, args ...interface{}) {
-	message := fmt.Sprintf(format, args...)
+	message := Redact(fmt.Sprintf(format, args...))
 	
 	// 输出到控制台
 	log.Printf("[%s] %s", level.String(), message)
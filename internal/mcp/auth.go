@@ -0,0 +1,241 @@
+package mcp
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// 认证方式
+const (
+	AuthMethodToken = "token"
+	AuthMethodJWT   = "jwt"
+)
+
+// ProtectedResourceMetadataPath OAuth 2.0 受保护资源元数据的路径 (RFC 9728)
+const ProtectedResourceMetadataPath = "/.well-known/oauth-protected-resource"
+
+// Principal 认证通过的调用方，附加在会话上供工具策略使用
+type Principal struct {
+	Subject string   `json:"subject"`
+	Method  string   `json:"method"`
+	Scopes  []string `json:"scopes,omitempty"`
+	// ExpiresAt 凭据失效时间（已计入允许的时钟偏差），静态令牌为零值
+	ExpiresAt time.Time              `json:"expiresAt,omitzero"`
+	Claims    map[string]interface{} `json:"-"`
+}
+
+// HasScope 判断调用方是否拥有指定权限范围
+func (p *Principal) HasScope(scope string) bool {
+	if p == nil {
+		return false
+	}
+	for _, s := range p.Scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// Authenticator 校验连接请求中的凭据
+type Authenticator interface {
+	// Authenticate 认证成功返回调用方；凭据缺失或无效时返回 *AuthError
+	Authenticate(r *http.Request) (*Principal, error)
+}
+
+// AuthError 认证失败，Code为RFC 6750定义的错误码，缺少凭据时为空
+type AuthError struct {
+	Status      int
+	Code        string
+	Description string
+}
+
+func (e *AuthError) Error() string {
+	if e.Code == "" {
+		return e.Description
+	}
+	return fmt.Sprintf("%s: %s", e.Code, e.Description)
+}
+
+// errUnrecognizedToken 令牌不属于当前认证器，交给下一个认证器处理
+var errUnrecognizedToken = errors.New("unrecognized token")
+
+// errMissingToken 请求没有携带Bearer令牌
+var errMissingToken = &AuthError{Status: http.StatusUnauthorized, Description: "缺少访问令牌"}
+
+// invalidToken 构建令牌无效的认证错误
+func invalidToken(format string, args ...interface{}) *AuthError {
+	return &AuthError{Status: http.StatusUnauthorized, Code: "invalid_token", Description: fmt.Sprintf(format, args...)}
+}
+
+// bearerToken 从Authorization头中取出Bearer令牌
+func bearerToken(r *http.Request) (string, bool) {
+	header := r.Header.Get("Authorization")
+	scheme, token, found := strings.Cut(header, " ")
+	if !found || !strings.EqualFold(scheme, "Bearer") {
+		return "", false
+	}
+	token = strings.TrimSpace(token)
+	return token, token != ""
+}
+
+// StaticToken 配置文件中的静态API令牌
+type StaticToken struct {
+	Name   string
+	Token  string
+	Scopes []string
+}
+
+// TokenAuthenticator 校验静态API令牌
+type TokenAuthenticator struct {
+	tokens []StaticToken
+	hashes [][sha256.Size]byte
+}
+
+// NewTokenAuthenticator 创建静态令牌认证器，空令牌会被忽略
+func NewTokenAuthenticator(tokens []StaticToken) *TokenAuthenticator {
+	a := &TokenAuthenticator{}
+	for _, token := range tokens {
+		if token.Token == "" {
+			continue
+		}
+		a.tokens = append(a.tokens, token)
+		a.hashes = append(a.hashes, sha256.Sum256([]byte(token.Token)))
+	}
+	return a
+}
+
+// Authenticate 实现Authenticator，比较哈希值避免按长度和内容泄露时间差
+func (a *TokenAuthenticator) Authenticate(r *http.Request) (*Principal, error) {
+	token, ok := bearerToken(r)
+	if !ok {
+		return nil, errMissingToken
+	}
+
+	hash := sha256.Sum256([]byte(token))
+	for i, expected := range a.hashes {
+		if subtle.ConstantTimeCompare(hash[:], expected[:]) == 1 {
+			return &Principal{
+				Subject: a.tokens[i].Name,
+				Method:  AuthMethodToken,
+				Scopes:  a.tokens[i].Scopes,
+			}, nil
+		}
+	}
+	return nil, errUnrecognizedToken
+}
+
+// ChainAuthenticator 依次尝试多个认证器，第一个识别令牌的认证器决定结果
+type ChainAuthenticator []Authenticator
+
+// Authenticate 实现Authenticator
+func (c ChainAuthenticator) Authenticate(r *http.Request) (*Principal, error) {
+	for _, authenticator := range c {
+		principal, err := authenticator.Authenticate(r)
+		if errors.Is(err, errUnrecognizedToken) {
+			continue
+		}
+		return principal, err
+	}
+	if _, ok := bearerToken(r); !ok {
+		return nil, errMissingToken
+	}
+	return nil, invalidToken("访问令牌无效")
+}
+
+// ProtectedResourceMetadata OAuth 2.0 受保护资源元数据 (RFC 9728)
+// MCP客户端据此找到授权服务器
+type ProtectedResourceMetadata struct {
+	Resource               string   `json:"resource"`
+	AuthorizationServers   []string `json:"authorization_servers,omitempty"`
+	ScopesSupported        []string `json:"scopes_supported,omitempty"`
+	BearerMethodsSupported []string `json:"bearer_methods_supported,omitempty"`
+}
+
+// authGate 网络传输层共用的认证入口
+type authGate struct {
+	authenticator    Authenticator
+	metadata         *ProtectedResourceMetadata
+	clientPrincipals map[string]string
+}
+
+// verify 校验请求中的凭据，不写出响应
+// 已校验的客户端证书优先于Bearer令牌；未设置认证器且没有客户端证书时允许匿名访问，返回的调用方为nil
+func (g *authGate) verify(r *http.Request) (*Principal, error) {
+	principal, err := certificatePrincipal(r, g.clientPrincipals)
+	if principal != nil || err != nil {
+		return principal, err
+	}
+	if g.authenticator == nil {
+		return nil, nil
+	}
+	return g.authenticator.Authenticate(r)
+}
+
+// identify 获取请求的调用方，凭据缺失或无效时返回nil而不拒绝请求，用于允许匿名访问的端点
+func (g *authGate) identify(r *http.Request) *Principal {
+	principal, err := g.verify(r)
+	if err != nil {
+		return nil
+	}
+	return principal
+}
+
+// authenticate 认证请求，失败时写出401/403响应并返回false
+func (g *authGate) authenticate(w http.ResponseWriter, r *http.Request) (*Principal, bool) {
+	principal, err := g.verify(r)
+	if err == nil {
+		return principal, true
+	}
+
+	var authErr *AuthError
+	if !errors.As(err, &authErr) {
+		authErr = invalidToken("访问令牌无效")
+	}
+
+	challenge := "Bearer"
+	params := []string{}
+	if g.metadata != nil {
+		params = append(params, fmt.Sprintf("resource_metadata=%q", metadataURL(r)))
+	}
+	// error_description只允许ASCII字符，说明文字放在响应体中
+	if authErr.Code != "" {
+		params = append(params, fmt.Sprintf("error=%q", authErr.Code))
+	}
+	if len(params) > 0 {
+		challenge += " " + strings.Join(params, ", ")
+	}
+	w.Header().Set("WWW-Authenticate", challenge)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(authErr.Status)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"error":             authErr.Code,
+		"error_description": authErr.Description,
+	})
+	return nil, false
+}
+
+// handleMetadata 返回受保护资源元数据
+func (g *authGate) handleMetadata(w http.ResponseWriter, r *http.Request) {
+	if g.metadata == nil {
+		http.NotFound(w, r)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(g.metadata)
+}
+
+// metadataURL 根据请求地址构建元数据URL
+func metadataURL(r *http.Request) string {
+	scheme := "http"
+	if r.TLS != nil {
+		scheme = "https"
+	}
+	return fmt.Sprintf("%s://%s%s", scheme, r.Host, ProtectedResourceMetadataPath)
+}
@@ -0,0 +1,77 @@
+package mcp
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"sync"
+)
+
+// isBatchFrame 判断一帧数据是否为JSON-RPC批量请求（JSON数组）
+func isBatchFrame(data []byte) bool {
+	trimmed := bytes.TrimLeft(data, " \t\r\n")
+	return len(trimmed) > 0 && trimmed[0] == '['
+}
+
+// parseBatch 解析批量请求，无法解析的元素以对应的错误响应返回
+func parseBatch(data []byte) ([]*Message, []*Message, error) {
+	var elements []json.RawMessage
+	if err := json.Unmarshal(data, &elements); err != nil {
+		return nil, nil, err
+	}
+
+	var messages []*Message
+	var invalid []*Message
+	for _, element := range elements {
+		var msg Message
+		if err := json.Unmarshal(element, &msg); err != nil {
+			invalid = append(invalid, NewErrorResponse(nil, InvalidRequestCode, "invalid request", err.Error()))
+			continue
+		}
+		messages = append(messages, &msg)
+	}
+	return messages, invalid, nil
+}
+
+// isSequentialMethod 会改变会话状态的方法需要按顺序执行
+func isSequentialMethod(msg *Message) bool {
+	return !msg.IsRequest() || msg.Method == "initialize" || msg.Method == "shutdown"
+}
+
+// HandleBatch 处理批量消息，返回需要回写的响应数组
+// 改变会话状态的消息按顺序执行，其余请求并发执行；响应顺序与请求顺序一致。
+// 每个并发请求都单独在上下文中的请求限制器排队，批量请求不能绕过连接的并发上限，
+// 队列已满时该元素直接得到错误响应。全部为通知时返回空切片，此时不应回写任何内容。
+func (s *BaseServer) HandleBatch(ctx context.Context, messages []*Message) []*Message {
+	responses := make([]*Message, len(messages))
+
+	var wg sync.WaitGroup
+	for i, msg := range messages {
+		if isSequentialMethod(msg) {
+			// 等待之前的并发请求完成，保证顺序语义
+			wg.Wait()
+			responses[i] = s.HandleMessage(ctx, msg)
+			continue
+		}
+
+		run, errResponse := s.prepareRequest(ctx, msg)
+		if errResponse != nil {
+			responses[i] = errResponse
+			continue
+		}
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			responses[i] = run()
+		}(i)
+	}
+	wg.Wait()
+
+	result := make([]*Message, 0, len(responses))
+	for _, response := range responses {
+		if response != nil {
+			result = append(result, response)
+		}
+	}
+	return result
+}
@@ -26,7 +26,7 @@ type Client interface {
 	// 资源操作
 	ReadResource(uri string) (*ResourceReadResult, error)
 	WriteResource(uri string, content []Content) error
-	ListResources(uri string) ([]string, error)
+	ListResources(cursor string) (*ResourceListResult, error)
 
 	// 事件处理
 	OnMessage(handler func(*Message))
@@ -281,15 +281,45 @@ func (c *StdioClient) WriteResource(uri string, content []Content) error {
 	return fmt.Errorf("写入资源功能尚未实现")
 }
 
-// ListResources 列出资源
-func (c *StdioClient) ListResources(uri string) ([]string, error) {
+// ListResources 列出资源，cursor为空时从第一页开始
+func (c *StdioClient) ListResources(cursor string) (*ResourceListResult, error) {
 	if !c.IsConnected() {
 		return nil, fmt.Errorf("客户端未连接")
 	}
 
-	// 这里需要实现resources/list方法
-	// 暂时返回未实现错误
-	return nil, fmt.Errorf("列出资源功能尚未实现")
+	paramsBytes, _ := json.Marshal(PaginatedParams{Cursor: cursor})
+	msg := Message{
+		JSONRPC: JSONRPCVersion,
+		ID:      fmt.Sprintf("list-%d", time.Now().Unix()),
+		Method:  "resources/list",
+		Params:  paramsBytes,
+	}
+
+	// 发送请求
+	if err := c.conn.SendMessage(&msg); err != nil {
+		return nil, err
+	}
+
+	// 等待响应
+	response := c.conn.WaitForResponse(msg.ID, 5*time.Second)
+	if response == nil {
+		return nil, fmt.Errorf("列出资源超时")
+	}
+
+	if response.Error != nil {
+		return nil, fmt.Errorf("列出资源失败: %s", response.Error.Message)
+	}
+
+	// 解析响应
+	var result ResourceListResult
+	if response.Result != nil {
+		resultBytes, _ := json.Marshal(response.Result)
+		if err := json.Unmarshal(resultBytes, &result); err != nil {
+			return nil, fmt.Errorf("解析资源列表失败: %v", err)
+		}
+	}
+
+	return &result, nil
 }
 
 // OnMessage 设置消息处理器
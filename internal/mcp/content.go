@@ -0,0 +1,145 @@
+package mcp
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+)
+
+// 内容类型
+const (
+	ContentTypeText         = "text"
+	ContentTypeImage        = "image"
+	ContentTypeAudio        = "audio"
+	ContentTypeResource     = "resource"
+	ContentTypeResourceLink = "resource_link"
+)
+
+// 内容受众
+const (
+	RoleUser      = "user"
+	RoleAssistant = "assistant"
+)
+
+// NewTextContent 创建文本内容
+func NewTextContent(text string) Content {
+	return Content{Type: ContentTypeText, Text: text}
+}
+
+// NewImageContent 创建图片内容，data为原始字节
+func NewImageContent(data []byte, mimeType string) Content {
+	return Content{
+		Type:     ContentTypeImage,
+		Data:     base64.StdEncoding.EncodeToString(data),
+		MimeType: mimeType,
+	}
+}
+
+// NewAudioContent 创建音频内容，data为原始字节
+func NewAudioContent(data []byte, mimeType string) Content {
+	return Content{
+		Type:     ContentTypeAudio,
+		Data:     base64.StdEncoding.EncodeToString(data),
+		MimeType: mimeType,
+	}
+}
+
+// NewEmbeddedResource 创建内嵌资源内容
+func NewEmbeddedResource(contents ResourceContents) Content {
+	return Content{
+		Type:     ContentTypeResource,
+		Resource: &contents,
+	}
+}
+
+// NewResourceLink 创建资源链接，客户端可以通过resources/read按需读取
+func NewResourceLink(resource Resource) Content {
+	return Content{
+		Type:        ContentTypeResourceLink,
+		URI:         resource.URI,
+		Name:        resource.Name,
+		Description: resource.Description,
+		MimeType:    resource.MimeType,
+		Annotations: resource.Annotations,
+	}
+}
+
+// WithAnnotations 为内容附加注解
+func (c Content) WithAnnotations(audience []string, priority float64) Content {
+	c.Annotations = &Annotations{
+		Audience: audience,
+		Priority: &priority,
+	}
+	return c
+}
+
+// MarshalJSON 按内容类型只输出该类型定义的字段
+// text类型即使内容为空也必须包含text字段
+func (c Content) MarshalJSON() ([]byte, error) {
+	switch c.Type {
+	case ContentTypeText, "":
+		return json.Marshal(struct {
+			Type        string       `json:"type"`
+			Text        string       `json:"text"`
+			Annotations *Annotations `json:"annotations,omitempty"`
+		}{ContentTypeText, c.Text, c.Annotations})
+	case ContentTypeImage, ContentTypeAudio:
+		return json.Marshal(struct {
+			Type        string       `json:"type"`
+			Data        string       `json:"data"`
+			MimeType    string       `json:"mimeType"`
+			Annotations *Annotations `json:"annotations,omitempty"`
+		}{c.Type, c.Data, c.MimeType, c.Annotations})
+	case ContentTypeResource:
+		if c.Resource == nil {
+			return nil, fmt.Errorf("resource content requires resource")
+		}
+		return json.Marshal(struct {
+			Type        string            `json:"type"`
+			Resource    *ResourceContents `json:"resource"`
+			Annotations *Annotations      `json:"annotations,omitempty"`
+		}{c.Type, c.Resource, c.Annotations})
+	case ContentTypeResourceLink:
+		return json.Marshal(struct {
+			Type        string       `json:"type"`
+			URI         string       `json:"uri"`
+			Name        string       `json:"name"`
+			Description string       `json:"description,omitempty"`
+			MimeType    string       `json:"mimeType,omitempty"`
+			Annotations *Annotations `json:"annotations,omitempty"`
+		}{c.Type, c.URI, c.Name, c.Description, c.MimeType, c.Annotations})
+	default:
+		type plain Content
+		return json.Marshal(plain(c))
+	}
+}
+
+// adaptContent 将会话协商版本不支持的内容类型降级为文本
+// 需要降级时返回新的切片和true，不修改工具返回的原始切片
+func adaptContent(contents []Content, session *Session) ([]Content, bool) {
+	audio := session.Supports(FeatureAudioContent)
+	links := session.Supports(FeatureResourceLinks)
+
+	var adapted []Content
+	for i, content := range contents {
+		var text string
+		switch {
+		case content.Type == ContentTypeAudio && !audio:
+			text = fmt.Sprintf("[音频内容: %s，当前协议版本不支持]", content.MimeType)
+		case content.Type == ContentTypeResourceLink && !links:
+			text = fmt.Sprintf("资源: %s (%s)", content.Name, content.URI)
+		default:
+			continue
+		}
+		if adapted == nil {
+			adapted = append([]Content(nil), contents...)
+		}
+		replacement := NewTextContent(text)
+		replacement.Annotations = content.Annotations
+		adapted[i] = replacement
+	}
+	if adapted == nil {
+		return contents, false
+	}
+	return adapted, true
+}
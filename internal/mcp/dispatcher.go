@@ -0,0 +1,328 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+)
+
+// HandleMessage 处理单个JSON-RPC消息，返回需要回写给客户端的消息
+// 通知和响应类消息不需要回复，此时返回nil。各传输层只负责消息的收发，
+// 所有方法的语义都在这里统一实现。
+func (s *BaseServer) HandleMessage(ctx context.Context, msg *Message) *Message {
+	// 验证消息
+	if err := msg.Validate(); err != nil {
+		return NewErrorResponse(msg.ID, InvalidRequestCode, err.Error(), nil)
+	}
+
+	// 确保工具实现可以通过上下文获取会话
+	ctx = WithSession(ctx, s.sessionFor(ctx))
+
+	// 根据消息类型处理
+	switch {
+	case msg.IsRequest():
+		run, errResponse := s.queueRequest(ctx, msg)
+		if errResponse != nil {
+			return errResponse
+		}
+		return run()
+	case msg.IsResponse():
+		// 客户端对服务器请求（如 sampling/createMessage）的响应
+		if !s.sessionFor(ctx).pending.resolve(msg) {
+			log.Printf("收到未知请求的响应: %v", msg.ID)
+		}
+		return nil
+	case msg.IsNotification():
+		s.handleNotification(ctx, msg)
+		return nil
+	default:
+		return NewErrorResponse(msg.ID, InvalidRequestCode, "invalid message", nil)
+	}
+}
+
+// prepareRequest 校验请求并调用queueRequest，供需要先登记、后在其它goroutine中执行请求的调用方使用
+func (s *BaseServer) prepareRequest(ctx context.Context, msg *Message) (func() *Message, *Message) {
+	if err := msg.Validate(); err != nil {
+		return nil, NewErrorResponse(msg.ID, InvalidRequestCode, err.Error(), nil)
+	}
+	return s.queueRequest(WithSession(ctx, s.sessionFor(ctx)), msg)
+}
+
+// queueRequest 登记请求并在上下文中的请求限制器排队，返回等待名额后执行请求的函数
+// 请求从登记起即可被 notifications/cancelled 取消，包括仍在排队的请求；
+// ID重复或队列已满时不登记，直接返回错误响应。改变会话状态的请求不占用并发名额。
+func (s *BaseServer) queueRequest(ctx context.Context, msg *Message) (func() *Message, *Message) {
+	// 为每个请求创建可取消的上下文，供 notifications/cancelled 使用
+	session, _ := SessionFromContext(ctx)
+	reqCtx, done, err := session.inFlight.track(ctx, msg.ID)
+	if err != nil {
+		return nil, NewErrorResponse(msg.ID, InvalidRequestCode, err.Error(), nil)
+	}
+
+	var limiter *requestLimiter
+	if !isSequentialMethod(msg) {
+		limiter = requestLimiterFrom(ctx)
+	}
+	ticket, err := limiter.enqueue()
+	if err != nil {
+		done()
+		return nil, NewErrorResponse(msg.ID, ServerErrorCode, err.Error(), limiter.limits())
+	}
+
+	return func() *Message {
+		defer done()
+		if err := ticket.wait(reqCtx); err != nil {
+			log.Printf("请求在排队时已取消: %v", msg.ID)
+			return nil
+		}
+		defer ticket.release()
+
+		response := s.handleRequest(reqCtx, msg)
+		if reqCtx.Err() == context.Canceled && ctx.Err() == nil {
+			// 已被客户端取消的请求不再发送响应
+			log.Printf("请求已取消: %v", msg.ID)
+			return nil
+		}
+		return response
+	}, nil
+}
+
+// handleRequest 按方法名分发请求
+func (s *BaseServer) handleRequest(ctx context.Context, msg *Message) *Message {
+	switch msg.Method {
+	case "initialize":
+		return s.handleInitialize(ctx, msg)
+	case "ping":
+		return NewResponse(msg.ID, map[string]interface{}{})
+	case "tools/list":
+		return s.handleToolsList(ctx, msg)
+	case "tools/call":
+		return s.handleToolCall(ctx, msg)
+	case "resources/list":
+		return s.handleResourcesList(ctx, msg)
+	case "resources/templates/list":
+		return s.handleResourceTemplatesList(ctx, msg)
+	case "resources/read":
+		return s.handleResourceRead(ctx, msg)
+	case "resources/subscribe":
+		return s.handleResourceSubscribe(ctx, msg, true)
+	case "resources/unsubscribe":
+		return s.handleResourceSubscribe(ctx, msg, false)
+	case "prompts/list":
+		return s.handlePromptsList(ctx, msg)
+	case "prompts/get":
+		return s.handlePromptGet(ctx, msg)
+	case "shutdown":
+		return s.handleShutdown(ctx, msg)
+	default:
+		return NewErrorResponse(msg.ID, MethodNotFoundCode, "method not found: "+msg.Method, nil)
+	}
+}
+
+// handleNotification 处理通知
+func (s *BaseServer) handleNotification(ctx context.Context, msg *Message) {
+	switch msg.Method {
+	case "notifications/cancelled":
+		var params CancelledParams
+		if err := json.Unmarshal(msg.Params, &params); err != nil || params.RequestID == nil {
+			log.Printf("无效的取消通知: %s", string(msg.Params))
+			return
+		}
+		if s.sessionFor(ctx).inFlight.cancel(params.RequestID) {
+			log.Printf("取消请求 %v: %s", params.RequestID, params.Reason)
+		}
+	case "notifications/roots/list_changed":
+		// 不在这里请求 roots/list：通知按顺序同步处理，等待响应会阻塞读取
+		s.sessionFor(ctx).invalidateRoots()
+		log.Printf("客户端根目录已变化")
+	default:
+		log.Printf("收到通知: %s", msg.Method)
+	}
+}
+
+// logSendError 记录写回消息失败
+func logSendError(err error) {
+	log.Printf("发送消息失败: %v", err)
+}
+
+// isInitialized 检查请求所属会话是否已完成初始化
+func (s *BaseServer) isInitialized(ctx context.Context) bool {
+	return s.sessionFor(ctx).IsInitialized()
+}
+
+// notInitialized 返回未初始化错误
+func notInitialized(id interface{}) *Message {
+	return NewErrorResponse(id, NotInitializedCode, "not initialized", nil)
+}
+
+// handleInitialize 处理初始化请求
+func (s *BaseServer) handleInitialize(ctx context.Context, msg *Message) *Message {
+	var params InitializeParams
+	if err := json.Unmarshal(msg.Params, &params); err != nil {
+		return NewErrorResponse(msg.ID, InvalidParamsCode, "invalid initialize params", nil)
+	}
+
+	// 只接受支持的协议版本，错误中附带支持的版本列表供客户端选择
+	version, err := negotiateProtocolVersion(params.ProtocolVersion)
+	if err != nil {
+		return NewErrorResponse(msg.ID, InvalidParamsCode, err.Error(), map[string]interface{}{
+			"supported": SupportedProtocolVersions,
+			"requested": params.ProtocolVersion,
+		})
+	}
+
+	// 保存协商结果和客户端信息
+	if !s.sessionFor(ctx).initialize(version, params) {
+		return NewErrorResponse(msg.ID, InvalidRequestCode, "already initialized", nil)
+	}
+
+	result := InitializeResult{
+		ProtocolVersion: version,
+		Capabilities:    s.GetCapabilities(),
+		ServerInfo:      s.GetServerInfo(),
+	}
+
+	return NewResponse(msg.ID, result)
+}
+
+// handleToolsList 处理工具列表请求
+func (s *BaseServer) handleToolsList(ctx context.Context, msg *Message) *Message {
+	if !s.isInitialized(ctx) {
+		return notInitialized(msg.ID)
+	}
+
+	tools := s.GetTools()
+	if !s.sessionFor(ctx).Supports(FeatureStructuredOutput) {
+		tools = stripStructuredOutput(tools)
+	}
+
+	return NewResponse(msg.ID, map[string]interface{}{
+		"tools": tools,
+	})
+}
+
+// handleToolCall 处理工具调用请求
+func (s *BaseServer) handleToolCall(ctx context.Context, msg *Message) *Message {
+	if !s.isInitialized(ctx) {
+		return notInitialized(msg.ID)
+	}
+
+	var params ToolCallParams
+	if err := json.Unmarshal(msg.Params, &params); err != nil {
+		return NewErrorResponse(msg.ID, InvalidParamsCode, "invalid tool call params", nil)
+	}
+	if params.Name == "" {
+		return NewErrorResponse(msg.ID, InvalidParamsCode, "tool name is required", nil)
+	}
+
+	// 查找工具
+	if _, exists := s.GetTool(params.Name); !exists {
+		return NewErrorResponse(msg.ID, MethodNotFoundCode, "tool not found: "+params.Name, nil)
+	}
+
+	s.mu.RLock()
+	executor := s.toolExecutor
+	s.mu.RUnlock()
+
+	if executor == nil {
+		return NewErrorResponse(msg.ID, InternalErrorCode, "tool executor not available", nil)
+	}
+
+	// 请求携带progressToken时，工具可以通过ReportProgress汇报进度
+	if params.Meta != nil {
+		ctx = withProgressToken(ctx, params.Meta.ProgressToken)
+	}
+
+	// 调用实际的工具实现，执行失败以isError结果返回，便于模型看到错误并调整
+	result, err := executor.ExecuteTool(ctx, params.Name, params.Arguments)
+	if err != nil {
+		if ctx.Err() == context.Canceled {
+			return NewErrorResponse(msg.ID, InternalErrorCode, "request cancelled", nil)
+		}
+		result = NewToolErrorResult(fmt.Sprintf("工具执行失败: %v", err))
+	}
+	if result == nil {
+		result = NewTextResult("")
+	}
+	session := s.sessionFor(ctx)
+	if result.StructuredContent != nil && !session.Supports(FeatureStructuredOutput) {
+		// 旧版本客户端只接收文本内容
+		stripped := *result
+		stripped.StructuredContent = nil
+		result = &stripped
+	}
+	if adapted, changed := adaptContent(result.Content, session); changed {
+		downgraded := *result
+		downgraded.Content = adapted
+		result = &downgraded
+	}
+
+	return NewResponse(msg.ID, result)
+}
+
+// handlePromptsList 处理提示词列表请求
+func (s *BaseServer) handlePromptsList(ctx context.Context, msg *Message) *Message {
+	if !s.isInitialized(ctx) {
+		return notInitialized(msg.ID)
+	}
+
+	params, err := parsePaginatedParams(msg.Params)
+	if err != nil {
+		return NewErrorResponse(msg.ID, InvalidParamsCode, "invalid prompts/list params", nil)
+	}
+
+	prompts := s.GetPrompts()
+	start, end, next, err := paginate(len(prompts), params.Cursor)
+	if err != nil {
+		return NewErrorResponse(msg.ID, InvalidParamsCode, err.Error(), nil)
+	}
+
+	return NewResponse(msg.ID, PromptListResult{
+		Prompts:    prompts[start:end],
+		NextCursor: next,
+	})
+}
+
+// handlePromptGet 处理获取提示词请求
+func (s *BaseServer) handlePromptGet(ctx context.Context, msg *Message) *Message {
+	if !s.isInitialized(ctx) {
+		return notInitialized(msg.ID)
+	}
+
+	var params GetPromptParams
+	if err := json.Unmarshal(msg.Params, &params); err != nil || params.Name == "" {
+		return NewErrorResponse(msg.ID, InvalidParamsCode, "invalid prompts/get params", nil)
+	}
+
+	s.mu.RLock()
+	prompt, exists := s.prompts[params.Name]
+	provider := s.promptProvider
+	s.mu.RUnlock()
+
+	if !exists {
+		return NewErrorResponse(msg.ID, InvalidParamsCode, "prompt not found: "+params.Name, nil)
+	}
+	for _, arg := range prompt.Arguments {
+		if arg.Required && params.Arguments[arg.Name] == "" {
+			return NewErrorResponse(msg.ID, InvalidParamsCode, "missing required argument: "+arg.Name, nil)
+		}
+	}
+	if provider == nil {
+		return NewErrorResponse(msg.ID, InternalErrorCode, "prompt provider not available", nil)
+	}
+
+	result, err := provider.GetPrompt(ctx, params.Name, params.Arguments)
+	if err != nil {
+		return NewErrorResponse(msg.ID, InternalErrorCode, fmt.Sprintf("获取提示词失败: %v", err), nil)
+	}
+
+	return NewResponse(msg.ID, result)
+}
+
+// handleShutdown 处理关闭请求
+func (s *BaseServer) handleShutdown(ctx context.Context, msg *Message) *Message {
+	s.sessionFor(ctx).reset()
+
+	return NewResponse(msg.ID, map[string]interface{}{})
+}
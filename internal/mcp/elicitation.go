@@ -0,0 +1,41 @@
+package mcp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// 用户对elicitation请求的处理结果
+const (
+	ElicitActionAccept  = "accept"
+	ElicitActionDecline = "decline"
+	ElicitActionCancel  = "cancel"
+)
+
+// ErrElicitationNotSupported 当前会话的客户端没有声明elicitation能力
+var ErrElicitationNotSupported = errors.New("客户端未声明elicitation能力")
+
+// Elicit 通过 elicitation/create 请求客户端向用户询问信息
+// 客户端未声明elicitation能力时返回ErrElicitationNotSupported
+func Elicit(ctx context.Context, message string, requestedSchema map[string]interface{}) (*ElicitResult, error) {
+	if !FeatureEnabled(ctx, FeatureElicitation) {
+		return nil, ErrElicitationNotSupported
+	}
+
+	var result ElicitResult
+	params := ElicitRequestParams{
+		Message:         message,
+		RequestedSchema: requestedSchema,
+	}
+	if err := SendRequest(ctx, "elicitation/create", params, &result); err != nil {
+		return nil, err
+	}
+
+	switch result.Action {
+	case ElicitActionAccept, ElicitActionDecline, ElicitActionCancel:
+		return &result, nil
+	default:
+		return nil, fmt.Errorf("无效的elicitation响应: %s", result.Action)
+	}
+}
@@ -0,0 +1,36 @@
+package mcp
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+)
+
+// writeHealth 写出健康检查响应，各网络传输层共用
+// details 中的字段会合并到响应中，例如当前连接数或会话数
+func (s *BaseServer) writeHealth(w http.ResponseWriter, service string, details map[string]interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+
+	response := map[string]interface{}{
+		"status":    "healthy",
+		"service":   service,
+		"timestamp": time.Now().Format(time.RFC3339),
+		"tools":     len(s.GetTools()),
+	}
+	for key, value := range details {
+		response[key] = value
+	}
+
+	json.NewEncoder(w).Encode(response)
+}
+
+// healthSessions 健康检查中的会话部分：所有调用方都能看到会话数量，
+// 认证后的调用方还能看到每个会话的详情（协商的协议版本、客户端信息、能力和进行中的请求）
+func (s *BaseServer) healthSessions(details map[string]interface{}, principal *Principal) map[string]interface{} {
+	details["sessions"] = s.sessionStats()
+	if principal != nil {
+		details["sessionDetails"] = s.sessionInfos()
+	}
+	return details
+}
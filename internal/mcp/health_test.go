@@ -0,0 +1,113 @@
+package mcp
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+const testHealthToken = "health-secret"
+
+// healthResponse 健康检查响应中与会话有关的部分
+type healthResponse struct {
+	Sessions       map[string]int `json:"sessions"`
+	SessionDetails []SessionInfo  `json:"sessionDetails"`
+}
+
+// newHealthSession 创建一个已完成初始化的会话
+func newHealthSession(remoteAddr string, principal *Principal) *Session {
+	session := NewSession(newSessionID())
+	session.remoteAddr = remoteAddr
+	session.principal = principal
+	session.initialized = true
+	session.protocolVersion = "2025-06-18"
+	session.clientInfo = &ClientInfo{Name: "inspector", Version: "1.0"}
+	session.clientCapabilities = map[string]interface{}{"roots": map[string]interface{}{}}
+	return session
+}
+
+func TestHealthSessions(t *testing.T) {
+	authenticator := NewTokenAuthenticator([]StaticToken{{Name: "monitor", Token: testHealthToken}})
+
+	httpServer := NewHTTPServer(0)
+	httpServer.SetAuthenticator(authenticator)
+	httpServer.createSession("192.0.2.11:51235", nil)
+	httpServer.registerSession(newHealthSession("192.0.2.10:51234", &Principal{Subject: "alice", Method: AuthMethodJWT}), nil)
+
+	wsServer := NewWebSocketServer(0)
+	wsServer.SetAuthenticator(authenticator)
+	wsServer.registerSession(NewSession(newSessionID()), nil)
+	wsServer.registerSession(newHealthSession("192.0.2.10:51234", &Principal{Subject: "alice", Method: AuthMethodJWT}), nil)
+
+	servers := []struct {
+		name   string
+		handle http.HandlerFunc
+	}{
+		{name: "http", handle: httpServer.handleHealth},
+		{name: "websocket", handle: wsServer.handleHealth},
+	}
+	tests := []struct {
+		name          string
+		token         string
+		authenticated bool
+	}{
+		{name: "anonymous"},
+		{name: "invalid token", token: "wrong"},
+		{name: "authenticated", token: testHealthToken, authenticated: true},
+	}
+
+	for _, server := range servers {
+		for _, tt := range tests {
+			t.Run(server.name+"/"+tt.name, func(t *testing.T) {
+				request := httptest.NewRequest(http.MethodGet, "/health", nil)
+				if tt.token != "" {
+					request.Header.Set("Authorization", "Bearer "+tt.token)
+				}
+				recorder := httptest.NewRecorder()
+				server.handle(recorder, request)
+
+				// 匿名或凭据无效的健康检查不会被拒绝
+				if recorder.Code != http.StatusOK {
+					t.Fatalf("status = %d, want 200", recorder.Code)
+				}
+				var response healthResponse
+				if err := json.Unmarshal(recorder.Body.Bytes(), &response); err != nil {
+					t.Fatal(err)
+				}
+				if response.Sessions["total"] != 2 || response.Sessions["initialized"] != 1 {
+					t.Errorf("sessions = %v, want total 2, initialized 1", response.Sessions)
+				}
+
+				if !tt.authenticated {
+					body := recorder.Body.String()
+					for _, secret := range []string{"alice", "192.0.2.10", "principal", "remoteAddr", "inspector"} {
+						if strings.Contains(body, secret) {
+							t.Errorf("health response contains %q: %s", secret, body)
+						}
+					}
+					return
+				}
+
+				if len(response.SessionDetails) != 2 {
+					t.Fatalf("sessionDetails = %+v, want 2 sessions", response.SessionDetails)
+				}
+				var found bool
+				for _, info := range response.SessionDetails {
+					if !info.Initialized {
+						continue
+					}
+					found = true
+					if info.ProtocolVersion != "2025-06-18" || info.ClientInfo == nil || info.ClientInfo.Name != "inspector" ||
+						info.ClientCapabilities["roots"] == nil || info.Principal == nil || info.Principal.Subject != "alice" {
+						t.Errorf("session detail = %+v", info)
+					}
+				}
+				if !found {
+					t.Errorf("sessionDetails = %+v, want the initialized session", response.SessionDetails)
+				}
+			})
+		}
+	}
+}
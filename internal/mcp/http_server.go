@@ -0,0 +1,695 @@
+package mcp
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// HTTPEndpointPath Streamable HTTP传输的MCP端点
+	HTTPEndpointPath = "/mcp"
+
+	// SessionIDHeader 会话ID请求/响应头
+	SessionIDHeader = "Mcp-Session-Id"
+
+	// ProtocolVersionHeader 初始化之后的请求携带的协议版本头
+	ProtocolVersionHeader = "MCP-Protocol-Version"
+
+	// lastEventIDHeader SSE断线重连时携带的最后事件ID
+	lastEventIDHeader = "Last-Event-ID"
+
+	// maxHTTPBodySize 单个POST请求体的最大字节数
+	maxHTTPBodySize = 10 << 20
+
+	// sseEventBufferSize 每个会话保留的SSE事件数，用于断线重连时补发
+	sseEventBufferSize = 256
+
+	// sseKeepAliveInterval SSE保活注释的发送间隔
+	sseKeepAliveInterval = 30 * time.Second
+
+	// httpSessionIdleTimeout 会话在无请求、无事件流时的最长保留时间
+	httpSessionIdleTimeout = 30 * time.Minute
+)
+
+// HTTPServer Streamable HTTP MCP服务器
+// 所有JSON-RPC消息通过单一端点POST提交，响应按客户端的Accept头
+// 以application/json或text/event-stream返回；GET打开服务器推送的事件流，
+// DELETE结束会话。所有方法都先校验Origin并经过与WebSocket相同的认证。
+type HTTPServer struct {
+	*BaseServer
+	host        string
+	port        int
+	tlsConfig   *tls.Config
+	checkOrigin func(r *http.Request) bool
+	auth        authGate
+	server      *http.Server
+	sessions    map[string]*httpSession
+	sessionMu   sync.RWMutex
+	done        chan struct{}
+	stopOnce    sync.Once
+}
+
+// sseEvent 一条已编号的SSE事件
+type sseEvent struct {
+	id        uint64
+	data      []byte
+	delivered bool
+}
+
+// httpSession Streamable HTTP会话，保存事件缓冲区和当前的GET事件流
+type httpSession struct {
+	*Session
+	limiter *requestLimiter
+
+	mu       sync.Mutex
+	nextID   uint64
+	events   []*sseEvent
+	stream   chan *sseEvent
+	lastSeen time.Time
+}
+
+// NewHTTPServer 创建新的Streamable HTTP服务器，默认只监听本机回环地址
+func NewHTTPServer(port int) *HTTPServer {
+	return &HTTPServer{
+		BaseServer:  NewBaseServer(),
+		host:        "127.0.0.1",
+		port:        port,
+		checkOrigin: newOriginChecker(nil),
+		sessions:    make(map[string]*httpSession),
+		done:        make(chan struct{}),
+	}
+}
+
+// Start 启动HTTP服务器，监听成功后在后台处理请求
+func (s *HTTPServer) Start() error {
+	mux := http.NewServeMux()
+	mux.HandleFunc(HTTPEndpointPath, s.handleMCP)
+	mux.HandleFunc("/health", s.handleHealth)
+	mux.HandleFunc(ProtectedResourceMetadataPath, s.auth.handleMetadata)
+
+	s.server = &http.Server{
+		Addr:      net.JoinHostPort(s.host, strconv.Itoa(s.port)),
+		Handler:   mux,
+		TLSConfig: s.tlsConfig,
+	}
+
+	listener, err := net.Listen("tcp", s.server.Addr)
+	if err != nil {
+		return fmt.Errorf("监听 %s 失败: %v", s.server.Addr, err)
+	}
+
+	scheme := "http"
+	if s.tlsConfig != nil {
+		listener = tls.NewListener(listener, s.tlsConfig)
+		scheme = "https"
+	}
+	log.Printf("Streamable HTTP MCP服务器启动在 %s", s.server.Addr)
+	log.Printf("MCP端点: %s://%s%s", scheme, s.server.Addr, HTTPEndpointPath)
+	log.Printf("健康检查: %s://%s/health", scheme, s.server.Addr)
+
+	go func() {
+		if err := s.server.Serve(listener); err != nil && err != http.ErrServerClosed {
+			log.Printf("HTTP服务器错误: %v", err)
+		}
+	}()
+	go s.expireSessions()
+
+	return nil
+}
+
+// Stop 停止HTTP服务器并结束所有会话
+func (s *HTTPServer) Stop() error {
+	log.Println("正在停止Streamable HTTP MCP服务器...")
+
+	// 通知所有SSE事件流退出，否则Shutdown会一直等待
+	s.stopOnce.Do(func() { close(s.done) })
+
+	s.sessionMu.Lock()
+	for id, session := range s.sessions {
+		s.unregisterSession(session.Session)
+		delete(s.sessions, id)
+	}
+	s.sessionMu.Unlock()
+
+	if s.server != nil {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		return s.server.Shutdown(ctx)
+	}
+
+	return nil
+}
+
+// SetListenAddress 设置监听的主机地址，为空时监听所有网卡
+// 必须在Start之前调用
+func (s *HTTPServer) SetListenAddress(host string) {
+	s.host = host
+}
+
+// SetAllowedOrigins 设置允许的浏览器来源，为空时只接受本机回环地址的页面
+// 必须在Start之前调用
+func (s *HTTPServer) SetAllowedOrigins(origins []string) {
+	s.checkOrigin = newOriginChecker(origins)
+}
+
+// SetTLSConfig 设置TLS配置，设置后以https提供服务
+// 必须在Start之前调用
+func (s *HTTPServer) SetTLSConfig(config *tls.Config) {
+	s.tlsConfig = config
+}
+
+// SetClientPrincipals 设置客户端证书标识到认证主体的映射
+// 必须在Start之前调用
+func (s *HTTPServer) SetClientPrincipals(principals map[string]string) {
+	s.auth.clientPrincipals = principals
+}
+
+// SetAuthenticator 设置请求认证器，为nil时不认证
+// 必须在Start之前调用
+func (s *HTTPServer) SetAuthenticator(authenticator Authenticator) {
+	s.auth.authenticator = authenticator
+}
+
+// SetResourceMetadata 设置受保护资源元数据，认证失败时在WWW-Authenticate中返回其地址
+func (s *HTTPServer) SetResourceMetadata(metadata *ProtectedResourceMetadata) {
+	s.auth.metadata = metadata
+}
+
+// SetToolExecutor 设置工具执行器
+func (s *HTTPServer) SetToolExecutor(executor ToolExecutor) {
+	s.BaseServer.SetToolExecutor(executor)
+}
+
+// handleHealth 处理健康检查请求，匿名调用方只能看到会话数量，认证后还会列出会话详情
+func (s *HTTPServer) handleHealth(w http.ResponseWriter, r *http.Request) {
+	s.writeHealth(w, "mcp-ai-http", s.healthSessions(map[string]interface{}{}, s.auth.identify(r)))
+}
+
+// handleMCP 校验Origin并认证后按HTTP方法分发MCP端点请求
+func (s *HTTPServer) handleMCP(w http.ResponseWriter, r *http.Request) {
+	if !s.checkOrigin(r) {
+		log.Printf("拒绝来源 %q 的HTTP请求: %s", r.Header.Get("Origin"), r.RemoteAddr)
+		http.Error(w, "origin not allowed", http.StatusForbidden)
+		return
+	}
+
+	principal, ok := s.auth.authenticate(w, r)
+	if !ok {
+		log.Printf("HTTP认证失败: %s", r.RemoteAddr)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodPost:
+		s.handlePost(w, r, principal)
+	case http.MethodGet:
+		s.handleGet(w, r, principal)
+	case http.MethodDelete:
+		s.handleDelete(w, r, principal)
+	default:
+		w.Header().Set("Allow", "GET, POST, DELETE")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// handlePost 处理客户端提交的JSON-RPC消息
+func (s *HTTPServer) handlePost(w http.ResponseWriter, r *http.Request, principal *Principal) {
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxHTTPBodySize))
+	if err != nil {
+		writeJSONError(w, http.StatusRequestEntityTooLarge, NewErrorResponse(nil, InvalidRequestCode, "request body too large", nil))
+		return
+	}
+
+	batch := isBatchFrame(body)
+	var messages, invalid []*Message
+	if batch {
+		messages, invalid, err = parseBatch(body)
+	} else {
+		var msg Message
+		if err = json.Unmarshal(body, &msg); err == nil {
+			messages = []*Message{&msg}
+		}
+	}
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, NewErrorResponse(nil, ParseErrorCode, "Parse error", err.Error()))
+		return
+	}
+	if len(messages) == 0 && len(invalid) == 0 {
+		writeJSONError(w, http.StatusBadRequest, NewErrorResponse(nil, InvalidRequestCode, "invalid request", "empty batch"))
+		return
+	}
+
+	// initialize请求创建新会话，其余请求必须携带已有的会话ID
+	var session *httpSession
+	if containsMethod(messages, "initialize") {
+		session = s.createSession(r.RemoteAddr, principal)
+	} else {
+		var status int
+		session, status = s.lookupSession(r, principal)
+		if session == nil {
+			http.Error(w, http.StatusText(status), status)
+			return
+		}
+	}
+	w.Header().Set(SessionIDHeader, session.ID())
+
+	// 只有通知和响应时直接处理，返回202且不带响应体
+	if !containsRequest(messages) && len(invalid) == 0 {
+		ctx := WithNotifier(WithSession(r.Context(), session.Session), session.notify)
+		for _, msg := range messages {
+			s.HandleMessage(ctx, msg)
+		}
+		w.WriteHeader(http.StatusAccepted)
+		return
+	}
+
+	if acceptsEventStream(r) {
+		s.respondEventStream(w, r, session, messages, invalid, batch)
+	} else {
+		s.respondJSON(w, r, session, messages, invalid, batch)
+	}
+}
+
+// respondJSON 以单个JSON响应返回结果，处理期间的通知发送到会话的GET事件流
+func (s *HTTPServer) respondJSON(w http.ResponseWriter, r *http.Request, session *httpSession, messages, invalid []*Message, batch bool) {
+	ctx := WithNotifier(WithSession(r.Context(), session.Session), session.notify)
+	payload := s.handleMessages(ctx, session, messages, invalid, batch)
+
+	w.Header().Set("Content-Type", "application/json")
+	if payload == nil {
+		w.WriteHeader(http.StatusAccepted)
+		return
+	}
+	json.NewEncoder(w).Encode(payload)
+}
+
+// respondEventStream 以SSE流返回结果，处理期间的通知先于响应写入同一事件流
+// 客户端断开不视为取消请求，未送达的事件可以通过GET携带Last-Event-ID补发
+func (s *HTTPServer) respondEventStream(w http.ResponseWriter, r *http.Request, session *httpSession, messages, invalid []*Message, batch bool) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		s.respondJSON(w, r, session, messages, invalid, batch)
+		return
+	}
+
+	setEventStreamHeaders(w)
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	var writeMu sync.Mutex
+	connected := true
+	send := func(v interface{}) error {
+		data, err := json.Marshal(v)
+		if err != nil {
+			return err
+		}
+		event := session.record(data)
+
+		writeMu.Lock()
+		defer writeMu.Unlock()
+		if !connected {
+			return nil
+		}
+		if err := writeSSEEvent(w, event); err != nil {
+			connected = false
+			return err
+		}
+		flusher.Flush()
+		session.markDelivered(event)
+		return nil
+	}
+
+	ctx := context.WithoutCancel(r.Context())
+	ctx = WithNotifier(WithSession(ctx, session.Session), func(msg *Message) error {
+		return send(msg)
+	})
+
+	if payload := s.handleMessages(ctx, session, messages, invalid, batch); payload != nil {
+		if err := send(payload); err != nil {
+			log.Printf("SSE写入失败，响应已缓存等待重连: %v", err)
+		}
+	}
+}
+
+// handleMessages 处理一次POST中的消息，返回需要写回的内容
+// 单条消息返回*Message，批量请求返回[]*Message，没有响应时返回nil
+func (s *HTTPServer) handleMessages(ctx context.Context, session *httpSession, messages, invalid []*Message, batch bool) interface{} {
+	// 每个请求在会话的请求限制器中登记后排队，超过并发上限时按到达顺序等待，排队期间可被取消
+	ctx = withRequestLimiter(ctx, session.limiter)
+	if !batch {
+		response := s.HandleMessage(ctx, messages[0])
+		s.discardFailedInitialize(session, messages[0], response)
+		if response == nil {
+			return nil
+		}
+		return response
+	}
+
+	responses := append(invalid, s.HandleBatch(ctx, messages)...)
+	if len(responses) == 0 {
+		return nil
+	}
+	return responses
+}
+
+// discardFailedInitialize 初始化失败时删除刚创建的会话
+func (s *HTTPServer) discardFailedInitialize(session *httpSession, msg, response *Message) {
+	if msg.Method != "initialize" || response == nil || response.Error == nil || session.IsInitialized() {
+		return
+	}
+	s.removeSession(session.ID())
+}
+
+// handleGet 打开服务器推送的SSE事件流，携带Last-Event-ID时先补发未送达的事件
+func (s *HTTPServer) handleGet(w http.ResponseWriter, r *http.Request, principal *Principal) {
+	if !acceptsEventStream(r) {
+		http.Error(w, "Accept must include text/event-stream", http.StatusNotAcceptable)
+		return
+	}
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming unsupported", http.StatusInternalServerError)
+		return
+	}
+
+	session, status := s.lookupSession(r, principal)
+	if session == nil {
+		http.Error(w, http.StatusText(status), status)
+		return
+	}
+
+	var lastEventID uint64
+	if value := r.Header.Get(lastEventIDHeader); value != "" {
+		id, err := strconv.ParseUint(value, 10, 64)
+		if err != nil {
+			http.Error(w, "invalid Last-Event-ID", http.StatusBadRequest)
+			return
+		}
+		lastEventID = id
+	}
+
+	stream, missed := session.attach(lastEventID)
+	defer session.detach(stream)
+
+	w.Header().Set(SessionIDHeader, session.ID())
+	setEventStreamHeaders(w)
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	for _, event := range missed {
+		if err := writeSSEEvent(w, event); err != nil {
+			return
+		}
+		session.markDelivered(event)
+	}
+	flusher.Flush()
+
+	keepAlive := time.NewTicker(sseKeepAliveInterval)
+	defer keepAlive.Stop()
+
+	for {
+		select {
+		case event, ok := <-stream:
+			if !ok {
+				// 被同一会话的新事件流替换
+				return
+			}
+			if err := writeSSEEvent(w, event); err != nil {
+				return
+			}
+			flusher.Flush()
+			session.markDelivered(event)
+		case <-keepAlive.C:
+			if _, err := io.WriteString(w, ": keep-alive\n\n"); err != nil {
+				return
+			}
+			flusher.Flush()
+		case <-r.Context().Done():
+			return
+		case <-s.done:
+			return
+		}
+	}
+}
+
+// handleDelete 客户端主动结束会话
+func (s *HTTPServer) handleDelete(w http.ResponseWriter, r *http.Request, principal *Principal) {
+	session, status := s.lookupSession(r, principal)
+	if session == nil {
+		http.Error(w, http.StatusText(status), status)
+		return
+	}
+
+	s.removeSession(session.ID())
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// createSession 创建并登记新会话，会话归属于发起initialize的调用方
+func (s *HTTPServer) createSession(remoteAddr string, principal *Principal) *httpSession {
+	session := &httpSession{
+		Session:  NewSession(newSessionID()),
+		limiter:  s.newRequestLimiter(),
+		lastSeen: time.Now(),
+	}
+	session.remoteAddr = remoteAddr
+	session.principal = principal
+
+	s.sessionMu.Lock()
+	s.sessions[session.ID()] = session
+	s.sessionMu.Unlock()
+	s.registerSession(session.Session, session.notify)
+
+	log.Printf("新的HTTP会话: %s", session.ID())
+	return session
+}
+
+// lookupSession 根据请求头查找会话，失败时返回对应的HTTP状态码
+// 会话只能由创建它的调用方使用，防止持有会话ID的其他调用方冒用
+func (s *HTTPServer) lookupSession(r *http.Request, principal *Principal) (*httpSession, int) {
+	id := r.Header.Get(SessionIDHeader)
+	if id == "" {
+		return nil, http.StatusBadRequest
+	}
+
+	s.sessionMu.RLock()
+	session, exists := s.sessions[id]
+	s.sessionMu.RUnlock()
+
+	if !exists {
+		return nil, http.StatusNotFound
+	}
+	if !samePrincipal(session.Principal(), principal) {
+		return nil, http.StatusForbidden
+	}
+	// 协议版本头必须与会话协商的版本一致，未携带时按旧版本客户端处理
+	if version := r.Header.Get(ProtocolVersionHeader); version != "" {
+		if !IsSupportedProtocolVersion(version) || (session.IsInitialized() && version != session.ProtocolVersion()) {
+			return nil, http.StatusBadRequest
+		}
+	}
+	session.touch()
+	return session, http.StatusOK
+}
+
+// removeSession 删除会话并取消其正在执行的请求
+func (s *HTTPServer) removeSession(id string) {
+	s.sessionMu.Lock()
+	session, exists := s.sessions[id]
+	delete(s.sessions, id)
+	s.sessionMu.Unlock()
+
+	if exists {
+		s.unregisterSession(session.Session)
+		session.detach(nil)
+		log.Printf("HTTP会话已结束: %s", id)
+	}
+}
+
+// expireSessions 定期清理长时间空闲的会话
+func (s *HTTPServer) expireSessions() {
+	ticker := time.NewTicker(time.Minute)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			var expired []string
+			s.sessionMu.RLock()
+			for id, session := range s.sessions {
+				if session.idleSince(httpSessionIdleTimeout) {
+					expired = append(expired, id)
+				}
+			}
+			s.sessionMu.RUnlock()
+
+			for _, id := range expired {
+				s.removeSession(id)
+			}
+		case <-s.done:
+			return
+		}
+	}
+}
+
+// touch 记录会话最近一次活动时间
+func (h *httpSession) touch() {
+	h.mu.Lock()
+	h.lastSeen = time.Now()
+	h.mu.Unlock()
+}
+
+// idleSince 会话没有事件流且超过timeout没有请求时视为空闲
+func (h *httpSession) idleSince(timeout time.Duration) bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	return h.stream == nil && h.inFlight.count() == 0 && time.Since(h.lastSeen) > timeout
+}
+
+// record 为一条消息分配事件ID并写入缓冲区
+func (h *httpSession) record(data []byte) *sseEvent {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.nextID++
+	event := &sseEvent{id: h.nextID, data: data}
+	h.events = append(h.events, event)
+	if len(h.events) > sseEventBufferSize {
+		h.events = h.events[len(h.events)-sseEventBufferSize:]
+	}
+	return event
+}
+
+// markDelivered 标记事件已成功写给客户端
+func (h *httpSession) markDelivered(event *sseEvent) {
+	h.mu.Lock()
+	event.delivered = true
+	h.mu.Unlock()
+}
+
+// notify 通过GET事件流向客户端推送消息，没有事件流时缓存等待客户端连接
+func (h *httpSession) notify(msg *Message) error {
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	event := h.record(data)
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.stream != nil {
+		select {
+		case h.stream <- event:
+		default:
+			// 事件流阻塞时不等待，事件保留在缓冲区中
+		}
+	}
+	return nil
+}
+
+// attach 为GET请求创建事件流，同一会话只保留最新的事件流
+// 返回lastEventID之后所有尚未送达的事件
+func (h *httpSession) attach(lastEventID uint64) (chan *sseEvent, []*sseEvent) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.stream != nil {
+		close(h.stream)
+	}
+	h.stream = make(chan *sseEvent, 64)
+	h.lastSeen = time.Now()
+
+	var missed []*sseEvent
+	for _, event := range h.events {
+		if event.id > lastEventID && !event.delivered {
+			missed = append(missed, event)
+		}
+	}
+	return h.stream, missed
+}
+
+// detach 关闭事件流，stream为nil时关闭当前的事件流
+func (h *httpSession) detach(stream chan *sseEvent) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.stream == nil || (stream != nil && h.stream != stream) {
+		return
+	}
+	if stream == nil {
+		close(h.stream)
+	}
+	h.stream = nil
+	h.lastSeen = time.Now()
+}
+
+// writeSSEEvent 写出一条SSE事件
+func writeSSEEvent(w io.Writer, event *sseEvent) error {
+	_, err := fmt.Fprintf(w, "id: %d\nevent: message\ndata: %s\n\n", event.id, event.data)
+	return err
+}
+
+// setEventStreamHeaders 设置SSE响应头
+func setEventStreamHeaders(w http.ResponseWriter) {
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+}
+
+// writeJSONError 以指定状态码写出JSON-RPC错误
+func writeJSONError(w http.ResponseWriter, status int, msg *Message) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(msg)
+}
+
+// acceptsEventStream 判断客户端是否接受SSE响应
+func acceptsEventStream(r *http.Request) bool {
+	for _, value := range r.Header.Values("Accept") {
+		for _, part := range strings.Split(value, ",") {
+			mediaType := strings.TrimSpace(strings.Split(part, ";")[0])
+			if mediaType == "text/event-stream" {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// containsMethod 判断消息中是否包含指定方法的请求
+func containsMethod(messages []*Message, method string) bool {
+	for _, msg := range messages {
+		if msg.IsRequest() && msg.Method == method {
+			return true
+		}
+	}
+	return false
+}
+
+// containsRequest 判断消息中是否包含需要响应的请求
+func containsRequest(messages []*Message) bool {
+	for _, msg := range messages {
+		if msg.IsRequest() {
+			return true
+		}
+	}
+	return false
+}
+
+// samePrincipal 判断两次请求是否来自同一调用方，均未认证时视为相同
+func samePrincipal(a, b *Principal) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	return a.Subject == b.Subject && a.Method == b.Method
+}
@@ -0,0 +1,468 @@
+package mcp
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	_ "crypto/sha256"
+	_ "crypto/sha512"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// jwksRefreshInterval 遇到未知kid时重新获取远程JWKS的最小间隔
+const jwksRefreshInterval = time.Minute
+
+// JWTConfig JWT访问令牌的校验设置
+type JWTConfig struct {
+	// Issuer 令牌的iss必须与之相同
+	Issuer string
+	// Audience 令牌的aud必须包含其中之一，通常为本服务器的资源标识
+	Audience []string
+	// JWKSFile 本地JWKS文件，与JWKSURL二选一
+	JWKSFile string
+	// JWKSURL 授权服务器的JWKS地址
+	JWKSURL string
+	// Leeway 校验exp、nbf时允许的时钟偏差
+	Leeway time.Duration
+	// RequiredScopes 令牌必须包含的权限范围
+	RequiredScopes []string
+}
+
+// JWTAuthenticator 按OAuth 2.1资源服务器的要求校验JWT访问令牌
+type JWTAuthenticator struct {
+	config     JWTConfig
+	httpClient *http.Client
+	mu         sync.RWMutex
+	keys       map[string]crypto.PublicKey
+	fetchedAt  time.Time
+}
+
+// NewJWTAuthenticator 创建JWT认证器并加载签名公钥
+func NewJWTAuthenticator(config JWTConfig) (*JWTAuthenticator, error) {
+	if config.JWKSFile == "" && config.JWKSURL == "" {
+		return nil, fmt.Errorf("JWT认证需要配置jwks_file或jwks_url")
+	}
+	if config.Issuer == "" {
+		return nil, fmt.Errorf("JWT认证需要配置issuer")
+	}
+	if len(config.Audience) == 0 {
+		return nil, fmt.Errorf("JWT认证需要配置audience")
+	}
+
+	a := &JWTAuthenticator{
+		config:     config,
+		httpClient: &http.Client{Timeout: 10 * time.Second},
+	}
+	if err := a.loadKeys(); err != nil {
+		return nil, err
+	}
+	return a, nil
+}
+
+// loadKeys 从文件或URL加载JWKS
+func (a *JWTAuthenticator) loadKeys() error {
+	var data []byte
+	var err error
+	if a.config.JWKSFile != "" {
+		data, err = os.ReadFile(a.config.JWKSFile)
+		if err != nil {
+			return fmt.Errorf("读取JWKS文件失败: %v", err)
+		}
+	} else {
+		data, err = a.fetchJWKS()
+		if err != nil {
+			return err
+		}
+	}
+
+	keys, err := parseJWKS(data)
+	if err != nil {
+		return err
+	}
+
+	a.mu.Lock()
+	a.keys = keys
+	a.fetchedAt = time.Now()
+	a.mu.Unlock()
+	return nil
+}
+
+// fetchJWKS 获取远程JWKS
+func (a *JWTAuthenticator) fetchJWKS() ([]byte, error) {
+	resp, err := a.httpClient.Get(a.config.JWKSURL)
+	if err != nil {
+		return nil, fmt.Errorf("获取JWKS失败: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("获取JWKS失败: %s", resp.Status)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return nil, fmt.Errorf("读取JWKS失败: %v", err)
+	}
+	return data, nil
+}
+
+// key 按kid查找公钥，远程JWKS中找不到时按最小间隔重新获取一次，应对密钥轮换
+func (a *JWTAuthenticator) key(kid string) (crypto.PublicKey, bool) {
+	lookup := func() (crypto.PublicKey, bool, time.Time) {
+		a.mu.RLock()
+		defer a.mu.RUnlock()
+		if kid == "" && len(a.keys) == 1 {
+			for _, key := range a.keys {
+				return key, true, a.fetchedAt
+			}
+		}
+		key, ok := a.keys[kid]
+		return key, ok, a.fetchedAt
+	}
+
+	key, ok, fetchedAt := lookup()
+	if ok || a.config.JWKSURL == "" || time.Since(fetchedAt) < jwksRefreshInterval {
+		return key, ok
+	}
+	if err := a.loadKeys(); err != nil {
+		return nil, false
+	}
+	key, ok, _ = lookup()
+	return key, ok
+}
+
+// Authenticate 实现Authenticator，不是JWT格式的令牌交给其他认证器
+func (a *JWTAuthenticator) Authenticate(r *http.Request) (*Principal, error) {
+	token, ok := bearerToken(r)
+	if !ok {
+		return nil, errMissingToken
+	}
+	if strings.Count(token, ".") != 2 {
+		return nil, errUnrecognizedToken
+	}
+	return a.Verify(token)
+}
+
+// jwtHeader JWT头部
+type jwtHeader struct {
+	Alg string `json:"alg"`
+	Kid string `json:"kid"`
+	Typ string `json:"typ"`
+}
+
+// Verify 校验JWT的签名和声明，返回令牌代表的调用方
+func (a *JWTAuthenticator) Verify(token string) (*Principal, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, invalidToken("令牌格式错误")
+	}
+
+	var header jwtHeader
+	if err := decodeSegment(parts[0], &header); err != nil {
+		return nil, invalidToken("令牌头部无效")
+	}
+	key, ok := a.key(header.Kid)
+	if !ok {
+		return nil, invalidToken("未知的签名密钥 %q", header.Kid)
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, invalidToken("令牌签名编码错误")
+	}
+	if err := verifySignature(header.Alg, key, []byte(parts[0]+"."+parts[1]), signature); err != nil {
+		return nil, invalidToken("%v", err)
+	}
+
+	var claims map[string]interface{}
+	if err := decodeSegment(parts[1], &claims); err != nil {
+		return nil, invalidToken("令牌声明无效")
+	}
+	return a.validateClaims(claims)
+}
+
+// validateClaims 校验iss、aud、exp、nbf和权限范围
+func (a *JWTAuthenticator) validateClaims(claims map[string]interface{}) (*Principal, error) {
+	now := time.Now()
+	leeway := a.config.Leeway
+
+	if issuer, _ := claims["iss"].(string); issuer != a.config.Issuer {
+		return nil, invalidToken("令牌签发者不匹配")
+	}
+	if !audienceMatches(claims["aud"], a.config.Audience) {
+		return nil, invalidToken("令牌受众不匹配")
+	}
+
+	exp, ok := numericDate(claims["exp"])
+	if !ok {
+		return nil, invalidToken("令牌缺少过期时间")
+	}
+	if now.After(exp.Add(leeway)) {
+		return nil, invalidToken("令牌已过期")
+	}
+	if nbf, ok := numericDate(claims["nbf"]); ok && now.Add(leeway).Before(nbf) {
+		return nil, invalidToken("令牌尚未生效")
+	}
+
+	subject, _ := claims["sub"].(string)
+	if subject == "" {
+		return nil, invalidToken("令牌缺少sub")
+	}
+
+	principal := &Principal{
+		Subject:   subject,
+		Method:    AuthMethodJWT,
+		Scopes:    tokenScopes(claims),
+		ExpiresAt: exp.Add(leeway),
+		Claims:    claims,
+	}
+	for _, scope := range a.config.RequiredScopes {
+		if !principal.HasScope(scope) {
+			return nil, &AuthError{
+				Status:      http.StatusForbidden,
+				Code:        "insufficient_scope",
+				Description: fmt.Sprintf("令牌缺少权限范围 %s", scope),
+			}
+		}
+	}
+	return principal, nil
+}
+
+// decodeSegment 解码JWT的base64url段
+func decodeSegment(segment string, v interface{}) error {
+	data, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
+
+// audienceMatches aud可以是字符串或字符串数组
+func audienceMatches(aud interface{}, expected []string) bool {
+	var values []string
+	switch v := aud.(type) {
+	case string:
+		values = []string{v}
+	case []interface{}:
+		for _, item := range v {
+			if s, ok := item.(string); ok {
+				values = append(values, s)
+			}
+		}
+	}
+	for _, value := range values {
+		for _, e := range expected {
+			if value == e {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// numericDate 解析JWT的NumericDate声明
+func numericDate(value interface{}) (time.Time, bool) {
+	seconds, ok := value.(float64)
+	if !ok {
+		return time.Time{}, false
+	}
+	return time.Unix(int64(seconds), 0), true
+}
+
+// tokenScopes 读取scope（空格分隔）或scp（数组）声明
+func tokenScopes(claims map[string]interface{}) []string {
+	if scope, ok := claims["scope"].(string); ok {
+		return strings.Fields(scope)
+	}
+	var scopes []string
+	if scp, ok := claims["scp"].([]interface{}); ok {
+		for _, item := range scp {
+			if s, ok := item.(string); ok {
+				scopes = append(scopes, s)
+			}
+		}
+	}
+	return scopes
+}
+
+// verifySignature 按alg校验签名，公钥类型必须与算法一致，不接受none和HMAC
+func verifySignature(alg string, key crypto.PublicKey, signed, signature []byte) error {
+	var hash crypto.Hash
+	switch alg {
+	case "RS256", "PS256", "ES256":
+		hash = crypto.SHA256
+	case "RS384", "PS384", "ES384":
+		hash = crypto.SHA384
+	case "RS512", "PS512", "ES512":
+		hash = crypto.SHA512
+	case "EdDSA":
+	default:
+		return fmt.Errorf("不支持的签名算法 %q", alg)
+	}
+
+	var digest []byte
+	if hash != 0 {
+		h := hash.New()
+		h.Write(signed)
+		digest = h.Sum(nil)
+	}
+
+	switch k := key.(type) {
+	case *rsa.PublicKey:
+		switch alg[:2] {
+		case "RS":
+			if err := rsa.VerifyPKCS1v15(k, hash, digest, signature); err != nil {
+				return fmt.Errorf("签名校验失败")
+			}
+			return nil
+		case "PS":
+			if err := rsa.VerifyPSS(k, hash, digest, signature, nil); err != nil {
+				return fmt.Errorf("签名校验失败")
+			}
+			return nil
+		}
+	case *ecdsa.PublicKey:
+		size := (k.Curve.Params().BitSize + 7) / 8
+		if alg[:2] != "ES" || len(signature) != 2*size || hash.Size()*8 != curveHashBits(k.Curve) {
+			break
+		}
+		r := new(big.Int).SetBytes(signature[:size])
+		s := new(big.Int).SetBytes(signature[size:])
+		if !ecdsa.Verify(k, digest, r, s) {
+			return fmt.Errorf("签名校验失败")
+		}
+		return nil
+	case ed25519.PublicKey:
+		if alg != "EdDSA" {
+			break
+		}
+		if !ed25519.Verify(k, signed, signature) {
+			return fmt.Errorf("签名校验失败")
+		}
+		return nil
+	}
+	return fmt.Errorf("签名算法 %s 与密钥类型不匹配", alg)
+}
+
+// curveHashBits ES算法规定的曲线与哈希长度对应关系
+func curveHashBits(curve elliptic.Curve) int {
+	switch curve {
+	case elliptic.P256():
+		return 256
+	case elliptic.P384():
+		return 384
+	case elliptic.P521():
+		return 512
+	}
+	return 0
+}
+
+// jsonWebKey JWKS中的单个密钥
+type jsonWebKey struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+// parseJWKS 解析JWKS，忽略非签名用途和不支持的密钥
+func parseJWKS(data []byte) (map[string]crypto.PublicKey, error) {
+	var set struct {
+		Keys []jsonWebKey `json:"keys"`
+	}
+	if err := json.Unmarshal(data, &set); err != nil {
+		return nil, fmt.Errorf("解析JWKS失败: %v", err)
+	}
+
+	keys := make(map[string]crypto.PublicKey)
+	for _, jwk := range set.Keys {
+		if jwk.Use != "" && jwk.Use != "sig" {
+			continue
+		}
+		key, err := jwk.publicKey()
+		if err != nil {
+			return nil, fmt.Errorf("解析密钥 %q 失败: %v", jwk.Kid, err)
+		}
+		if key != nil {
+			keys[jwk.Kid] = key
+		}
+	}
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("JWKS中没有可用的签名密钥")
+	}
+	return keys, nil
+}
+
+// publicKey 将JWK转换为公钥，不支持的类型返回nil
+func (k jsonWebKey) publicKey() (crypto.PublicKey, error) {
+	decode := func(value string) ([]byte, error) {
+		return base64.RawURLEncoding.DecodeString(strings.TrimRight(value, "="))
+	}
+
+	switch k.Kty {
+	case "RSA":
+		n, err := decode(k.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := decode(k.E)
+		if err != nil {
+			return nil, err
+		}
+		exponent := new(big.Int).SetBytes(e)
+		if !exponent.IsInt64() || exponent.Int64() < 3 {
+			return nil, fmt.Errorf("无效的RSA指数")
+		}
+		return &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(exponent.Int64())}, nil
+	case "EC":
+		var curve elliptic.Curve
+		switch k.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, nil
+		}
+		x, err := decode(k.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := decode(k.Y)
+		if err != nil {
+			return nil, err
+		}
+		key := &ecdsa.PublicKey{Curve: curve, X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}
+		if _, err := key.ECDH(); err != nil {
+			return nil, fmt.Errorf("无效的EC公钥: %v", err)
+		}
+		return key, nil
+	case "OKP":
+		if k.Crv != "Ed25519" {
+			return nil, nil
+		}
+		x, err := decode(k.X)
+		if err != nil {
+			return nil, err
+		}
+		if len(x) != ed25519.PublicKeySize {
+			return nil, fmt.Errorf("无效的Ed25519公钥")
+		}
+		return ed25519.PublicKey(x), nil
+	}
+	return nil, nil
+}
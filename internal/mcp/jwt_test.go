@@ -0,0 +1,309 @@
+package mcp
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+const (
+	testIssuer   = "https://auth.example.com"
+	testAudience = "https://mcp.example.com"
+	testLeeway   = 30 * time.Second
+)
+
+// testKeys 测试用的签名私钥，公钥写入本地JWKS文件
+type testKeys struct {
+	rsa      *rsa.PrivateKey
+	ed25519  ed25519.PrivateKey
+	jwksFile string
+}
+
+// newTestKeys 生成RSA和Ed25519密钥，并把公钥写成JWKS文件（kid分别为rsa-1和ed-1）
+func newTestKeys(t *testing.T) *testKeys {
+	t.Helper()
+
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, edKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	encode := base64.RawURLEncoding.EncodeToString
+	jwks := map[string]interface{}{
+		"keys": []map[string]string{
+			{
+				"kty": "RSA", "kid": "rsa-1", "use": "sig",
+				"n": encode(rsaKey.N.Bytes()),
+				"e": encode(big.NewInt(int64(rsaKey.E)).Bytes()),
+			},
+			{
+				"kty": "OKP", "kid": "ed-1", "crv": "Ed25519",
+				"x": encode(edKey.Public().(ed25519.PublicKey)),
+			},
+		},
+	}
+	data, err := json.Marshal(jwks)
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "jwks.json")
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	return &testKeys{rsa: rsaKey, ed25519: edKey, jwksFile: path}
+}
+
+// sign 按header中的alg签名，alg为none或未知算法时签名为空
+func (k *testKeys) sign(t *testing.T, header, claims map[string]interface{}) string {
+	t.Helper()
+
+	segment := func(v interface{}) string {
+		data, err := json.Marshal(v)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return base64.RawURLEncoding.EncodeToString(data)
+	}
+	signed := segment(header) + "." + segment(claims)
+
+	var signature []byte
+	switch header["alg"] {
+	case "RS256":
+		digest := sha256.Sum256([]byte(signed))
+		var err error
+		signature, err = rsa.SignPKCS1v15(rand.Reader, k.rsa, crypto.SHA256, digest[:])
+		if err != nil {
+			t.Fatal(err)
+		}
+	case "EdDSA":
+		signature = ed25519.Sign(k.ed25519, []byte(signed))
+	}
+	return signed + "." + base64.RawURLEncoding.EncodeToString(signature)
+}
+
+// validClaims 返回一组可以通过校验的声明
+func validClaims() map[string]interface{} {
+	now := time.Now()
+	return map[string]interface{}{
+		"iss":   testIssuer,
+		"aud":   testAudience,
+		"sub":   "alice",
+		"scope": "tools files",
+		"iat":   now.Unix(),
+		"exp":   now.Add(time.Hour).Unix(),
+	}
+}
+
+func newTestJWTAuthenticator(t *testing.T, keys *testKeys, requiredScopes ...string) *JWTAuthenticator {
+	t.Helper()
+
+	authenticator, err := NewJWTAuthenticator(JWTConfig{
+		Issuer:         testIssuer,
+		Audience:       []string{testAudience},
+		JWKSFile:       keys.jwksFile,
+		Leeway:         testLeeway,
+		RequiredScopes: requiredScopes,
+	})
+	if err != nil {
+		t.Fatalf("NewJWTAuthenticator: %v", err)
+	}
+	return authenticator
+}
+
+func bearerRequest(token string) *http.Request {
+	r := httptest.NewRequest(http.MethodPost, "/mcp", nil)
+	if token != "" {
+		r.Header.Set("Authorization", "Bearer "+token)
+	}
+	return r
+}
+
+func TestJWTAuthenticator(t *testing.T) {
+	keys := newTestKeys(t)
+	authenticator := newTestJWTAuthenticator(t, keys)
+	now := time.Now()
+
+	rsaHeader := map[string]interface{}{"alg": "RS256", "kid": "rsa-1", "typ": "at+jwt"}
+	edHeader := map[string]interface{}{"alg": "EdDSA", "kid": "ed-1"}
+	with := func(changes map[string]interface{}) map[string]interface{} {
+		claims := validClaims()
+		for key, value := range changes {
+			if value == nil {
+				delete(claims, key)
+				continue
+			}
+			claims[key] = value
+		}
+		return claims
+	}
+
+	tests := []struct {
+		name    string
+		token   string
+		subject string
+		code    string
+	}{
+		{name: "valid RS256", token: keys.sign(t, rsaHeader, validClaims()), subject: "alice"},
+		{name: "valid EdDSA", token: keys.sign(t, edHeader, validClaims()), subject: "alice"},
+		{name: "audience array", token: keys.sign(t, rsaHeader, with(map[string]interface{}{"aud": []string{"other", testAudience}})), subject: "alice"},
+		{name: "expired within leeway", token: keys.sign(t, rsaHeader, with(map[string]interface{}{"exp": now.Add(-testLeeway / 2).Unix()})), subject: "alice"},
+
+		{name: "expired beyond leeway", token: keys.sign(t, rsaHeader, with(map[string]interface{}{"exp": now.Add(-2 * testLeeway).Unix()})), code: "invalid_token"},
+		{name: "not yet valid", token: keys.sign(t, rsaHeader, with(map[string]interface{}{"nbf": now.Add(2 * testLeeway).Unix()})), code: "invalid_token"},
+		{name: "missing exp", token: keys.sign(t, rsaHeader, with(map[string]interface{}{"exp": nil})), code: "invalid_token"},
+		{name: "wrong audience", token: keys.sign(t, rsaHeader, with(map[string]interface{}{"aud": "https://other.example.com"})), code: "invalid_token"},
+		{name: "missing audience", token: keys.sign(t, rsaHeader, with(map[string]interface{}{"aud": nil})), code: "invalid_token"},
+		{name: "wrong issuer", token: keys.sign(t, rsaHeader, with(map[string]interface{}{"iss": "https://evil.example.com"})), code: "invalid_token"},
+		{name: "missing subject", token: keys.sign(t, rsaHeader, with(map[string]interface{}{"sub": nil})), code: "invalid_token"},
+		{name: "unknown kid", token: keys.sign(t, map[string]interface{}{"alg": "RS256", "kid": "rsa-2"}, validClaims()), code: "invalid_token"},
+		{name: "alg none", token: keys.sign(t, map[string]interface{}{"alg": "none", "kid": "rsa-1"}, validClaims()), code: "invalid_token"},
+		{name: "alg none without kid", token: keys.sign(t, map[string]interface{}{"alg": "none"}, validClaims()), code: "invalid_token"},
+		{name: "alg HS256", token: keys.sign(t, map[string]interface{}{"alg": "HS256", "kid": "rsa-1"}, validClaims()), code: "invalid_token"},
+		{name: "alg does not match key", token: keys.sign(t, map[string]interface{}{"alg": "EdDSA", "kid": "rsa-1"}, validClaims()), code: "invalid_token"},
+		{name: "malformed", token: "a.b.c", code: "invalid_token"},
+	}
+
+	// 篡改声明后签名不再匹配
+	valid := keys.sign(t, rsaHeader, validClaims())
+	parts := strings.Split(valid, ".")
+	forged := keys.sign(t, rsaHeader, with(map[string]interface{}{"sub": "admin"}))
+	tests = append(tests, struct {
+		name    string
+		token   string
+		subject string
+		code    string
+	}{name: "tampered claims", token: parts[0] + "." + strings.Split(forged, ".")[1] + "." + parts[2], code: "invalid_token"})
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			principal, err := authenticator.Authenticate(bearerRequest(tt.token))
+			if tt.code == "" {
+				if err != nil {
+					t.Fatalf("Authenticate error: %v", err)
+				}
+				if principal.Subject != tt.subject || principal.Method != AuthMethodJWT {
+					t.Errorf("principal = %+v, want subject %s", principal, tt.subject)
+				}
+				return
+			}
+			var authErr *AuthError
+			if !errors.As(err, &authErr) {
+				t.Fatalf("Authenticate error = %v, want *AuthError", err)
+			}
+			if authErr.Code != tt.code || authErr.Status != http.StatusUnauthorized {
+				t.Errorf("Authenticate error = %d %s, want 401 %s", authErr.Status, authErr.Code, tt.code)
+			}
+		})
+	}
+}
+
+func TestJWTAuthenticatorScopes(t *testing.T) {
+	keys := newTestKeys(t)
+	authenticator := newTestJWTAuthenticator(t, keys, "tools")
+	header := map[string]interface{}{"alg": "RS256", "kid": "rsa-1"}
+
+	principal, err := authenticator.Authenticate(bearerRequest(keys.sign(t, header, validClaims())))
+	if err != nil {
+		t.Fatalf("Authenticate error: %v", err)
+	}
+	if !principal.HasScope("files") {
+		t.Errorf("scopes = %v, want files", principal.Scopes)
+	}
+
+	claims := validClaims()
+	claims["scope"] = "files"
+	_, err = authenticator.Authenticate(bearerRequest(keys.sign(t, header, claims)))
+	var authErr *AuthError
+	if !errors.As(err, &authErr) || authErr.Status != http.StatusForbidden || authErr.Code != "insufficient_scope" {
+		t.Errorf("Authenticate error = %v, want 403 insufficient_scope", err)
+	}
+}
+
+func TestAuthGateChallenge(t *testing.T) {
+	keys := newTestKeys(t)
+	chain := ChainAuthenticator{
+		NewTokenAuthenticator([]StaticToken{{Name: "local-dev", Token: "static-token"}}),
+		newTestJWTAuthenticator(t, keys),
+	}
+	gate := authGate{
+		authenticator: chain,
+		metadata:      &ProtectedResourceMetadata{Resource: testAudience},
+	}
+	valid := keys.sign(t, map[string]interface{}{"alg": "RS256", "kid": "rsa-1"}, validClaims())
+
+	tests := []struct {
+		name      string
+		token     string
+		status    int
+		subject   string
+		challenge string
+	}{
+		{name: "missing token", status: http.StatusUnauthorized, challenge: `Bearer resource_metadata="http://example.com/.well-known/oauth-protected-resource"`},
+		{name: "invalid token", token: "not-a-token", status: http.StatusUnauthorized, challenge: `error="invalid_token"`},
+		{name: "alg none", token: keys.sign(t, map[string]interface{}{"alg": "none"}, validClaims()), status: http.StatusUnauthorized, challenge: `error="invalid_token"`},
+		{name: "static token", token: "static-token", status: http.StatusOK, subject: "local-dev"},
+		{name: "jwt", token: valid, status: http.StatusOK, subject: "alice"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recorder := httptest.NewRecorder()
+			principal, ok := gate.authenticate(recorder, bearerRequest(tt.token))
+			if tt.status == http.StatusOK {
+				if !ok || principal == nil || principal.Subject != tt.subject {
+					t.Fatalf("authenticate = %+v, %v, want subject %s", principal, ok, tt.subject)
+				}
+				return
+			}
+			if ok {
+				t.Fatalf("authenticate succeeded, want %d", tt.status)
+			}
+			if recorder.Code != tt.status {
+				t.Errorf("status = %d, want %d", recorder.Code, tt.status)
+			}
+			challenge := recorder.Header().Get("WWW-Authenticate")
+			if !strings.HasPrefix(challenge, "Bearer") || !strings.Contains(challenge, tt.challenge) {
+				t.Errorf("WWW-Authenticate = %q, want it to contain %q", challenge, tt.challenge)
+			}
+		})
+	}
+}
+
+func TestHTTPServerRequiresAuthentication(t *testing.T) {
+	keys := newTestKeys(t)
+	server := NewHTTPServer(0)
+	server.SetAuthenticator(newTestJWTAuthenticator(t, keys))
+
+	for _, method := range []string{http.MethodPost, http.MethodGet, http.MethodDelete} {
+		t.Run(method, func(t *testing.T) {
+			recorder := httptest.NewRecorder()
+			request := httptest.NewRequest(method, HTTPEndpointPath, strings.NewReader(`{"jsonrpc":"2.0","id":1,"method":"ping"}`))
+			server.handleMCP(recorder, request)
+
+			if recorder.Code != http.StatusUnauthorized {
+				t.Errorf("status = %d, want 401", recorder.Code)
+			}
+			if challenge := recorder.Header().Get("WWW-Authenticate"); !strings.HasPrefix(challenge, "Bearer") {
+				t.Errorf("WWW-Authenticate = %q, want Bearer challenge", challenge)
+			}
+		})
+	}
+}
@@ -0,0 +1,137 @@
+package mcp
+
+import (
+	"container/list"
+	"context"
+	"errors"
+	"sync"
+)
+
+// defaultMaxQueued 每个连接默认的最大排队请求数
+const defaultMaxQueued = 64
+
+// errQueueFull 排队请求数已达上限
+var errQueueFull = errors.New("too many queued requests")
+
+// requestLimiter 限制一个连接上同时执行的请求数
+// 超过上限的请求按到达顺序（FIFO）排队，队列满时立即拒绝，因此等待中的请求数量有界。
+// nil limiter不做任何限制。
+type requestLimiter struct {
+	mu      sync.Mutex
+	limit   int
+	depth   int
+	running int
+	waiters *list.List // 排队中的请求，元素为 chan struct{}，名额转交时关闭
+}
+
+// newRequestLimiter 创建请求限制器，limit为同时执行的请求数，depth为最多排队的请求数
+func newRequestLimiter(limit, depth int) *requestLimiter {
+	if limit <= 0 {
+		limit = defaultMaxInFlight
+	}
+	if depth < 0 {
+		depth = 0
+	}
+	return &requestLimiter{
+		limit:   limit,
+		depth:   depth,
+		waiters: list.New(),
+	}
+}
+
+// limiterTicket 一次名额申请，ready关闭时名额已分配
+type limiterTicket struct {
+	limiter *requestLimiter
+	element *list.Element
+	ready   chan struct{}
+}
+
+// enqueue 申请名额，不阻塞：有空闲名额时立即分配，否则排到队尾，队列已满时返回errQueueFull
+func (l *requestLimiter) enqueue() (*limiterTicket, error) {
+	ticket := &limiterTicket{limiter: l, ready: make(chan struct{})}
+	if l == nil {
+		close(ticket.ready)
+		return ticket, nil
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.running < l.limit && l.waiters.Len() == 0 {
+		l.running++
+		close(ticket.ready)
+		return ticket, nil
+	}
+	if l.waiters.Len() >= l.depth {
+		return nil, errQueueFull
+	}
+	ticket.element = l.waiters.PushBack(ticket.ready)
+	return ticket, nil
+}
+
+// wait 等待名额分配，ctx结束时退出队列并返回ctx的错误
+// 返回nil后调用方必须调用release归还名额
+func (t *limiterTicket) wait(ctx context.Context) error {
+	select {
+	case <-t.ready:
+		// 名额和ctx结束同时就绪时不再开始执行
+		if err := ctx.Err(); err != nil {
+			t.release()
+			return err
+		}
+		return nil
+	case <-ctx.Done():
+	}
+
+	l := t.limiter
+	l.mu.Lock()
+	select {
+	case <-t.ready:
+		// 退出队列前名额已经转交过来，转交给下一个请求
+		l.mu.Unlock()
+		t.release()
+	default:
+		l.waiters.Remove(t.element)
+		l.mu.Unlock()
+	}
+	return ctx.Err()
+}
+
+// release 归还名额，有排队的请求时直接转交给队首
+func (t *limiterTicket) release() {
+	l := t.limiter
+	if l == nil {
+		return
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if front := l.waiters.Front(); front != nil {
+		l.waiters.Remove(front)
+		close(front.Value.(chan struct{}))
+		return
+	}
+	l.running--
+}
+
+type requestLimiterKey struct{}
+
+// withRequestLimiter 将连接的请求限制器附加到上下文，HandleMessage据此排队
+func withRequestLimiter(ctx context.Context, limiter *requestLimiter) context.Context {
+	return context.WithValue(ctx, requestLimiterKey{}, limiter)
+}
+
+// requestLimiterFrom 获取上下文中的请求限制器，没有时返回nil（不限制）
+func requestLimiterFrom(ctx context.Context) *requestLimiter {
+	limiter, _ := ctx.Value(requestLimiterKey{}).(*requestLimiter)
+	return limiter
+}
+
+// limits 返回并发上限和队列深度，用于错误信息
+func (l *requestLimiter) limits() map[string]interface{} {
+	return map[string]interface{}{
+		"maxInFlight": l.limit,
+		"maxQueued":   l.depth,
+	}
+}
@@ -0,0 +1,106 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+)
+
+// pendingRequests 服务器发往客户端、等待响应的请求
+// ID使用带前缀的字符串，避免与客户端的数字ID混淆，也不受JSON数字类型转换影响
+type pendingRequests struct {
+	mu      sync.Mutex
+	nextID  int64
+	waiters map[string]chan *Message
+}
+
+// newPendingRequests 创建待响应请求表
+func newPendingRequests() *pendingRequests {
+	return &pendingRequests{
+		waiters: make(map[string]chan *Message),
+	}
+}
+
+// add 分配请求ID并登记等待响应的通道
+func (p *pendingRequests) add() (string, chan *Message) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.nextID++
+	id := fmt.Sprintf("srv-%d", p.nextID)
+	ch := make(chan *Message, 1)
+	p.waiters[id] = ch
+	return id, ch
+}
+
+// remove 删除等待中的请求
+func (p *pendingRequests) remove(id string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	delete(p.waiters, id)
+}
+
+// resolve 将客户端响应交给等待的请求，没有对应请求时返回false
+func (p *pendingRequests) resolve(msg *Message) bool {
+	id, ok := msg.ID.(string)
+	if !ok {
+		return false
+	}
+
+	p.mu.Lock()
+	ch, exists := p.waiters[id]
+	delete(p.waiters, id)
+	p.mu.Unlock()
+
+	if exists {
+		ch <- msg
+	}
+	return exists
+}
+
+// SendRequest 通过当前请求的连接向客户端发送请求并等待响应，结果解码到result
+// 上下文取消时向客户端发送 notifications/cancelled；客户端返回的错误以*Error返回
+func SendRequest(ctx context.Context, method string, params interface{}, result interface{}) error {
+	session, ok := SessionFromContext(ctx)
+	if !ok {
+		return fmt.Errorf("上下文中没有会话，无法向客户端发送 %s", method)
+	}
+	notifier, ok := NotifierFromContext(ctx)
+	if !ok {
+		return fmt.Errorf("当前连接不支持向客户端发送 %s", method)
+	}
+
+	id, ch := session.pending.add()
+	defer session.pending.remove(id)
+
+	if err := notifier(NewRequest(id, method, params)); err != nil {
+		return fmt.Errorf("发送 %s 请求失败: %v", method, err)
+	}
+
+	select {
+	case response := <-ch:
+		if response.Error != nil {
+			return response.Error
+		}
+		if result == nil {
+			return nil
+		}
+		data, err := json.Marshal(response.Result)
+		if err != nil {
+			return fmt.Errorf("解析 %s 响应失败: %v", method, err)
+		}
+		if err := json.Unmarshal(data, result); err != nil {
+			return fmt.Errorf("解析 %s 响应失败: %v", method, err)
+		}
+		return nil
+	case <-ctx.Done():
+		// 通知客户端放弃处理，发送失败不影响返回结果
+		_ = notifier(NewNotification("notifications/cancelled", CancelledParams{
+			RequestID: id,
+			Reason:    ctx.Err().Error(),
+		}))
+		return fmt.Errorf("等待客户端响应 %s 时请求结束: %v", method, ctx.Err())
+	}
+}
@@ -0,0 +1,131 @@
+package mcp
+
+import (
+	"context"
+	"fmt"
+	"sync"
+)
+
+// Notifier 向当前连接发送服务器通知的函数，由传输层提供
+type Notifier func(msg *Message) error
+
+type notifierKey struct{}
+
+type progressTokenKey struct{}
+
+// WithNotifier 将通知发送函数附加到上下文
+func WithNotifier(ctx context.Context, notifier Notifier) context.Context {
+	return context.WithValue(ctx, notifierKey{}, notifier)
+}
+
+// NotifierFromContext 获取上下文中的通知发送函数
+func NotifierFromContext(ctx context.Context) (Notifier, bool) {
+	notifier, ok := ctx.Value(notifierKey{}).(Notifier)
+	return notifier, ok && notifier != nil
+}
+
+// withProgressToken 将请求携带的progressToken附加到上下文
+func withProgressToken(ctx context.Context, token interface{}) context.Context {
+	if token == nil {
+		return ctx
+	}
+	return context.WithValue(ctx, progressTokenKey{}, token)
+}
+
+// ReportProgress 发送 notifications/progress，请求未携带progressToken时不发送
+// total 小于等于0表示总量未知
+func ReportProgress(ctx context.Context, progress, total float64, message string) {
+	token := ctx.Value(progressTokenKey{})
+	if token == nil {
+		return
+	}
+	notifier, ok := NotifierFromContext(ctx)
+	if !ok {
+		return
+	}
+
+	params := ProgressParams{
+		ProgressToken: token,
+		Progress:      progress,
+		Message:       message,
+	}
+	if total > 0 {
+		params.Total = total
+	}
+	// 进度通知发送失败不影响工具执行
+	_ = notifier(NewNotification("notifications/progress", params))
+}
+
+// inFlightRequests 正在执行的请求，用于响应 notifications/cancelled
+type inFlightRequests struct {
+	mu      sync.Mutex
+	cancels map[string]context.CancelFunc
+}
+
+// newInFlightRequests 创建请求跟踪表
+func newInFlightRequests() *inFlightRequests {
+	return &inFlightRequests{
+		cancels: make(map[string]context.CancelFunc),
+	}
+}
+
+// requestKey 将JSON-RPC ID统一转换为字符串键
+func requestKey(id interface{}) string {
+	return fmt.Sprintf("%T:%v", id, id)
+}
+
+// track 为请求创建可取消的上下文，返回的函数需在请求结束时调用
+// 同一ID的请求仍在执行时返回错误，保证响应可以按ID唯一对应
+func (r *inFlightRequests) track(ctx context.Context, id interface{}) (context.Context, func(), error) {
+	key := requestKey(id)
+
+	r.mu.Lock()
+	if _, exists := r.cancels[key]; exists {
+		r.mu.Unlock()
+		return nil, nil, fmt.Errorf("duplicate request id: %v", id)
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	r.cancels[key] = cancel
+	r.mu.Unlock()
+
+	return ctx, func() {
+		r.mu.Lock()
+		delete(r.cancels, key)
+		r.mu.Unlock()
+		cancel()
+	}, nil
+}
+
+// cancel 取消指定ID的请求，请求不存在时返回false
+func (r *inFlightRequests) cancel(id interface{}) bool {
+	r.mu.Lock()
+	cancel, exists := r.cancels[requestKey(id)]
+	r.mu.Unlock()
+
+	if exists {
+		cancel()
+	}
+	return exists
+}
+
+// cancelAll 取消所有正在执行的请求，会话结束时调用
+func (r *inFlightRequests) cancelAll() {
+	r.mu.Lock()
+	cancels := make([]context.CancelFunc, 0, len(r.cancels))
+	for _, cancel := range r.cancels {
+		cancels = append(cancels, cancel)
+	}
+	r.mu.Unlock()
+
+	for _, cancel := range cancels {
+		cancel()
+	}
+}
+
+// count 正在执行的请求数
+func (r *inFlightRequests) count() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return len(r.cancels)
+}
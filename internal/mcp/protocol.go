@@ -0,0 +1,109 @@
+package mcp
+
+import (
+	"context"
+	"fmt"
+)
+
+// SupportedProtocolVersions 服务器支持的协议版本，按从新到旧排列
+var SupportedProtocolVersions = []string{
+	ProtocolVersion20250618,
+	ProtocolVersion20250326,
+	ProtocolVersion20241105,
+}
+
+// IsSupportedProtocolVersion 判断协议版本是否受支持
+func IsSupportedProtocolVersion(version string) bool {
+	for _, supported := range SupportedProtocolVersions {
+		if supported == version {
+			return true
+		}
+	}
+	return false
+}
+
+// negotiateProtocolVersion 协商协议版本，客户端请求的版本受支持时直接使用
+func negotiateProtocolVersion(requested string) (string, error) {
+	if requested == "" {
+		return "", fmt.Errorf("protocolVersion is required")
+	}
+	if !IsSupportedProtocolVersion(requested) {
+		return "", fmt.Errorf("unsupported protocol version: %s", requested)
+	}
+	return requested, nil
+}
+
+// Feature 依赖协议版本或双方能力声明的功能
+type Feature string
+
+const (
+	// FeatureSampling 服务器通过 sampling/createMessage 请求客户端调用模型
+	FeatureSampling Feature = "sampling"
+	// FeatureRoots 服务器通过 roots/list 获取客户端的根目录
+	FeatureRoots Feature = "roots"
+	// FeatureRootsListChanged 客户端在根目录变化时发送通知
+	FeatureRootsListChanged Feature = "roots.listChanged"
+	// FeatureElicitation 服务器通过 elicitation/create 向用户询问信息
+	FeatureElicitation Feature = "elicitation"
+	// FeatureStructuredOutput 工具返回outputSchema和structuredContent
+	FeatureStructuredOutput Feature = "structuredOutput"
+	// FeatureAudioContent 内容中可以包含audio类型
+	FeatureAudioContent Feature = "audioContent"
+	// FeatureResourceLinks 内容中可以包含resource_link类型
+	FeatureResourceLinks Feature = "resourceLinks"
+)
+
+// featureMinVersion 各功能要求的最低协议版本
+var featureMinVersion = map[Feature]string{
+	FeatureSampling:         ProtocolVersion20241105,
+	FeatureRoots:            ProtocolVersion20241105,
+	FeatureRootsListChanged: ProtocolVersion20241105,
+	FeatureElicitation:      ProtocolVersion20250618,
+	FeatureStructuredOutput: ProtocolVersion20250618,
+	FeatureAudioContent:     ProtocolVersion20250326,
+	FeatureResourceLinks:    ProtocolVersion20250618,
+}
+
+// Supports 判断会话是否可以使用指定功能
+// 功能需要满足协商版本的最低要求，客户端侧功能还需要客户端在初始化时声明对应能力
+func (s *Session) Supports(feature Feature) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if !s.initialized {
+		return false
+	}
+	// 协议版本使用日期格式，可以直接按字符串比较
+	if minVersion, ok := featureMinVersion[feature]; !ok || s.protocolVersion < minVersion {
+		return false
+	}
+
+	switch feature {
+	case FeatureSampling:
+		return hasCapability(s.clientCapabilities, "sampling")
+	case FeatureRoots:
+		return hasCapability(s.clientCapabilities, "roots")
+	case FeatureRootsListChanged:
+		roots, _ := s.clientCapabilities["roots"].(map[string]interface{})
+		listChanged, _ := roots["listChanged"].(bool)
+		return listChanged
+	case FeatureElicitation:
+		return hasCapability(s.clientCapabilities, "elicitation")
+	case FeatureStructuredOutput, FeatureAudioContent, FeatureResourceLinks:
+		return true
+	default:
+		return false
+	}
+}
+
+// FeatureEnabled 判断当前请求所属会话是否可以使用指定功能，供工具实现使用
+func FeatureEnabled(ctx context.Context, feature Feature) bool {
+	session, ok := SessionFromContext(ctx)
+	return ok && session.Supports(feature)
+}
+
+// hasCapability 判断能力声明中是否包含指定能力
+func hasCapability(capabilities map[string]interface{}, name string) bool {
+	value, exists := capabilities[name]
+	return exists && value != nil
+}
@@ -0,0 +1,198 @@
+package mcp
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"log"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// resourcePageSize 资源列表每页数量
+const resourcePageSize = 50
+
+// URIScheme 提取URI的scheme部分，例如 "db://demo/users" 返回 "db"
+func URIScheme(uri string) string {
+	if idx := strings.Index(uri, "://"); idx > 0 {
+		return uri[:idx]
+	}
+	return ""
+}
+
+// encodeCursor 将偏移量编码为不透明的分页游标
+func encodeCursor(offset int) string {
+	return base64.StdEncoding.EncodeToString([]byte(strconv.Itoa(offset)))
+}
+
+// decodeCursor 解析分页游标，空游标表示从头开始
+func decodeCursor(cursor string) (int, error) {
+	if cursor == "" {
+		return 0, nil
+	}
+	data, err := base64.StdEncoding.DecodeString(cursor)
+	if err != nil {
+		return 0, fmt.Errorf("invalid cursor")
+	}
+	offset, err := strconv.Atoi(string(data))
+	if err != nil || offset < 0 {
+		return 0, fmt.Errorf("invalid cursor")
+	}
+	return offset, nil
+}
+
+// paginate 计算分页区间和下一页游标
+func paginate(total int, cursor string) (start, end int, next string, err error) {
+	start, err = decodeCursor(cursor)
+	if err != nil {
+		return 0, 0, "", err
+	}
+	if start > total {
+		start = total
+	}
+	end = start + resourcePageSize
+	if end < total {
+		next = encodeCursor(end)
+	} else {
+		end = total
+	}
+	return start, end, next, nil
+}
+
+// parsePaginatedParams 解析分页参数，允许params为空
+func parsePaginatedParams(raw json.RawMessage) (PaginatedParams, error) {
+	var params PaginatedParams
+	if len(raw) == 0 || string(raw) == "null" {
+		return params, nil
+	}
+	err := json.Unmarshal(raw, &params)
+	return params, err
+}
+
+// handleResourcesList 处理资源列表请求，汇总所有资源处理器的资源
+func (s *BaseServer) handleResourcesList(ctx context.Context, msg *Message) *Message {
+	if !s.isInitialized(ctx) {
+		return notInitialized(msg.ID)
+	}
+
+	params, err := parsePaginatedParams(msg.Params)
+	if err != nil {
+		return NewErrorResponse(msg.ID, InvalidParamsCode, "invalid resources/list params", nil)
+	}
+
+	var resources []Resource
+	for _, handler := range s.GetResourceHandlers() {
+		items, err := handler.List(ctx)
+		if err != nil {
+			// 单个处理器失败不影响其他资源的列出
+			log.Printf("列出资源失败: %v", err)
+			continue
+		}
+		resources = append(resources, items...)
+	}
+	sort.SliceStable(resources, func(i, j int) bool {
+		return resources[i].URI < resources[j].URI
+	})
+
+	start, end, next, err := paginate(len(resources), params.Cursor)
+	if err != nil {
+		return NewErrorResponse(msg.ID, InvalidParamsCode, err.Error(), nil)
+	}
+
+	return NewResponse(msg.ID, ResourceListResult{
+		Resources:  append([]Resource{}, resources[start:end]...),
+		NextCursor: next,
+	})
+}
+
+// handleResourceTemplatesList 处理资源模板列表请求
+func (s *BaseServer) handleResourceTemplatesList(ctx context.Context, msg *Message) *Message {
+	if !s.isInitialized(ctx) {
+		return notInitialized(msg.ID)
+	}
+
+	params, err := parsePaginatedParams(msg.Params)
+	if err != nil {
+		return NewErrorResponse(msg.ID, InvalidParamsCode, "invalid resources/templates/list params", nil)
+	}
+
+	var templates []ResourceTemplate
+	for _, handler := range s.GetResourceHandlers() {
+		templates = append(templates, handler.Templates()...)
+	}
+
+	start, end, next, err := paginate(len(templates), params.Cursor)
+	if err != nil {
+		return NewErrorResponse(msg.ID, InvalidParamsCode, err.Error(), nil)
+	}
+
+	return NewResponse(msg.ID, ResourceTemplateListResult{
+		ResourceTemplates: append([]ResourceTemplate{}, templates[start:end]...),
+		NextCursor:        next,
+	})
+}
+
+// handleResourceRead 处理资源读取请求，按URI scheme路由到资源处理器
+func (s *BaseServer) handleResourceRead(ctx context.Context, msg *Message) *Message {
+	if !s.isInitialized(ctx) {
+		return notInitialized(msg.ID)
+	}
+
+	var params ResourceReadParams
+	if err := json.Unmarshal(msg.Params, &params); err != nil || params.URI == "" {
+		return NewErrorResponse(msg.ID, InvalidParamsCode, "invalid resource read params", nil)
+	}
+
+	scheme := URIScheme(params.URI)
+	handler, exists := s.GetResourceHandler(scheme)
+	if !exists {
+		return NewErrorResponse(msg.ID, InvalidParamsCode, "resource not found: "+params.URI, map[string]interface{}{
+			"uri": params.URI,
+		})
+	}
+
+	result, err := handler.Read(ctx, params.URI)
+	if err != nil {
+		return NewErrorResponse(msg.ID, InternalErrorCode, fmt.Sprintf("读取资源失败: %v", err), map[string]interface{}{
+			"uri": params.URI,
+		})
+	}
+
+	return NewResponse(msg.ID, result)
+}
+
+// handleResourceSubscribe 处理资源订阅和取消订阅请求，订阅关系保存在会话中
+func (s *BaseServer) handleResourceSubscribe(ctx context.Context, msg *Message, subscribe bool) *Message {
+	if !s.isInitialized(ctx) {
+		return notInitialized(msg.ID)
+	}
+
+	var params ResourceSubscribeParams
+	if err := json.Unmarshal(msg.Params, &params); err != nil || params.URI == "" {
+		return NewErrorResponse(msg.ID, InvalidParamsCode, "invalid resource subscribe params", nil)
+	}
+
+	session := s.sessionFor(ctx)
+	if !subscribe {
+		session.unsubscribe(params.URI)
+		return NewResponse(msg.ID, map[string]interface{}{})
+	}
+
+	if _, exists := s.GetResourceHandler(URIScheme(params.URI)); !exists {
+		return NewErrorResponse(msg.ID, InvalidParamsCode, "resource not found: "+params.URI, map[string]interface{}{
+			"uri": params.URI,
+		})
+	}
+	session.subscribe(params.URI)
+	return NewResponse(msg.ID, map[string]interface{}{})
+}
+
+// NotifyResourceUpdated 向订阅了该资源的会话发送 notifications/resources/updated
+func (s *BaseServer) NotifyResourceUpdated(uri string) {
+	notification := NewNotification("notifications/resources/updated", ResourceUpdatedParams{URI: uri})
+	s.broadcast(notification, func(session *Session) bool {
+		return session.IsSubscribed(uri)
+	})
+}
@@ -0,0 +1,63 @@
+package mcp
+
+import (
+	"context"
+	"errors"
+)
+
+// ErrRootsNotSupported 当前会话的客户端没有声明roots能力
+var ErrRootsNotSupported = errors.New("客户端未声明roots能力")
+
+// Roots 获取客户端声明的根目录
+// 首次调用时通过 roots/list 向客户端请求，之后使用缓存，直到客户端发送 notifications/roots/list_changed
+func Roots(ctx context.Context) ([]Root, error) {
+	session, ok := SessionFromContext(ctx)
+	if !ok || !session.Supports(FeatureRoots) {
+		return nil, ErrRootsNotSupported
+	}
+
+	if roots, loaded := session.cachedRoots(); loaded {
+		return roots, nil
+	}
+
+	// 并发的工具调用只发送一次 roots/list
+	session.rootsFetch.Lock()
+	defer session.rootsFetch.Unlock()
+
+	if roots, loaded := session.cachedRoots(); loaded {
+		return roots, nil
+	}
+
+	var result ListRootsResult
+	if err := SendRequest(ctx, "roots/list", nil, &result); err != nil {
+		return nil, err
+	}
+	session.setRoots(result.Roots)
+	return result.Roots, nil
+}
+
+// cachedRoots 获取缓存的根目录
+func (s *Session) cachedRoots() ([]Root, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.roots, s.rootsLoaded
+}
+
+// setRoots 缓存客户端返回的根目录
+func (s *Session) setRoots(roots []Root) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.roots = roots
+	s.rootsLoaded = true
+}
+
+// invalidateRoots 客户端根目录变化后清除缓存，下次使用时重新请求
+func (s *Session) invalidateRoots() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.roots = nil
+	s.rootsLoaded = false
+}
@@ -0,0 +1,27 @@
+package mcp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// ErrSamplingNotSupported 当前会话的客户端没有声明sampling能力
+var ErrSamplingNotSupported = errors.New("客户端未声明sampling能力")
+
+// CreateMessage 通过 sampling/createMessage 请求客户端调用其模型生成回复
+// 客户端未声明sampling能力时返回错误，调用方应改用本地提供商
+func CreateMessage(ctx context.Context, params CreateMessageParams) (*CreateMessageResult, error) {
+	if !FeatureEnabled(ctx, FeatureSampling) {
+		return nil, ErrSamplingNotSupported
+	}
+	if len(params.Messages) == 0 {
+		return nil, fmt.Errorf("sampling请求至少需要一条消息")
+	}
+
+	var result CreateMessageResult
+	if err := SendRequest(ctx, "sampling/createMessage", params, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
@@ -0,0 +1,131 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+)
+
+// frameWriter 向连接写入一帧JSON数据（单条消息或消息数组），必须是并发安全的
+type frameWriter func(v interface{}) error
+
+// requestScheduler 单个连接上的请求调度器
+// 请求在独立的goroutine中并发执行，数量受maxInFlight限制；超过上限的请求按到达顺序排队，
+// 队列深度受maxQueued限制，队列满时立即返回错误响应。
+// 通知、initialize和shutdown按到达顺序同步处理，保证会话状态变化有序。
+type requestScheduler struct {
+	server  *BaseServer
+	write   frameWriter
+	limiter *requestLimiter
+	wg      sync.WaitGroup
+}
+
+// newRequestScheduler 创建请求调度器
+func (s *BaseServer) newRequestScheduler(write frameWriter) *requestScheduler {
+	return &requestScheduler{
+		server:  s,
+		write:   write,
+		limiter: s.newRequestLimiter(),
+	}
+}
+
+// Notifier 返回向该连接发送通知的函数
+func (r *requestScheduler) Notifier() Notifier {
+	return func(msg *Message) error {
+		return r.write(msg)
+	}
+}
+
+// DispatchFrame 解析并调度一帧数据，支持单条消息和JSON-RPC批量请求
+// 返回解析出的单条消息，批量请求或解析失败时返回nil
+func (r *requestScheduler) DispatchFrame(ctx context.Context, data []byte) *Message {
+	if isBatchFrame(data) {
+		r.dispatchBatch(ctx, data)
+		return nil
+	}
+
+	var msg Message
+	if err := json.Unmarshal(data, &msg); err != nil {
+		r.send(NewErrorResponse(nil, ParseErrorCode, "Parse error", err.Error()))
+		return nil
+	}
+
+	r.Dispatch(ctx, &msg)
+	return &msg
+}
+
+// dispatchBatch 调度批量请求，结果在一帧中写回
+// 批次中的每个请求各自占用并发名额或排队位置，超出队列的元素得到错误响应
+func (r *requestScheduler) dispatchBatch(ctx context.Context, data []byte) {
+	messages, invalid, err := parseBatch(data)
+	if err != nil {
+		r.send(NewErrorResponse(nil, ParseErrorCode, "Parse error", err.Error()))
+		return
+	}
+	if len(messages) == 0 && len(invalid) == 0 {
+		r.send(NewErrorResponse(nil, InvalidRequestCode, "invalid request", "empty batch"))
+		return
+	}
+
+	ctx = withRequestLimiter(ctx, r.limiter)
+	run := func() {
+		responses := append(invalid, r.server.HandleBatch(ctx, messages)...)
+		// 仅包含通知的批量请求不返回任何内容
+		if len(responses) == 0 {
+			return
+		}
+		if err := r.write(responses); err != nil {
+			logSendError(err)
+		}
+	}
+
+	// 批次在新的goroutine中处理，其中的顺序请求和排队等待都不阻塞读取循环
+	r.wg.Add(1)
+	go func() {
+		defer r.wg.Done()
+		run()
+	}()
+}
+
+// Dispatch 调度一条消息，响应通过write写回
+// 请求在读取循环中同步登记和排队，等待名额和执行在新的goroutine中进行，
+// 读取循环因此不会被阻塞，仍能接收取消通知和客户端对服务器请求的响应
+func (r *requestScheduler) Dispatch(ctx context.Context, msg *Message) {
+	if isSequentialMethod(msg) {
+		r.handle(ctx, msg)
+		return
+	}
+
+	run, errResponse := r.server.prepareRequest(withRequestLimiter(ctx, r.limiter), msg)
+	if errResponse != nil {
+		r.send(errResponse)
+		return
+	}
+
+	r.wg.Add(1)
+	go func() {
+		defer r.wg.Done()
+		if response := run(); response != nil {
+			r.send(response)
+		}
+	}()
+}
+
+// Wait 等待所有正在执行的请求结束
+func (r *requestScheduler) Wait() {
+	r.wg.Wait()
+}
+
+// handle 处理消息并写回响应
+func (r *requestScheduler) handle(ctx context.Context, msg *Message) {
+	if response := r.server.HandleMessage(ctx, msg); response != nil {
+		r.send(response)
+	}
+}
+
+// send 写回消息，失败时只记录日志
+func (r *requestScheduler) send(msg *Message) {
+	if err := r.write(msg); err != nil {
+		logSendError(err)
+	}
+}
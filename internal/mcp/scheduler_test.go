@@ -0,0 +1,292 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestRequestLimiterFIFO(t *testing.T) {
+	limiter := newRequestLimiter(1, 2)
+	ctx := context.Background()
+
+	first, err := limiter.enqueue()
+	if err != nil || first.wait(ctx) != nil {
+		t.Fatalf("first enqueue = %v", err)
+	}
+	second, err := limiter.enqueue()
+	if err != nil {
+		t.Fatal(err)
+	}
+	third, err := limiter.enqueue()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 并发名额和队列都已占满
+	if _, err := limiter.enqueue(); !errors.Is(err, errQueueFull) {
+		t.Fatalf("enqueue on full queue = %v, want errQueueFull", err)
+	}
+
+	// 名额按排队顺序转交
+	first.release()
+	select {
+	case <-second.ready:
+	case <-time.After(time.Second):
+		t.Fatal("second ticket not granted after release")
+	}
+	select {
+	case <-third.ready:
+		t.Fatal("third ticket granted before second released")
+	default:
+	}
+
+	// 腾出的队列位置可以再次使用
+	fourth, err := limiter.enqueue()
+	if err != nil {
+		t.Fatalf("enqueue after dequeue = %v", err)
+	}
+	second.release()
+	third.release()
+	if err := fourth.wait(ctx); err != nil {
+		t.Fatal(err)
+	}
+	fourth.release()
+	if limiter.running != 0 || limiter.waiters.Len() != 0 {
+		t.Errorf("limiter not drained: running=%d waiters=%d", limiter.running, limiter.waiters.Len())
+	}
+}
+
+func TestRequestLimiterCancelWhileQueued(t *testing.T) {
+	limiter := newRequestLimiter(1, 1)
+
+	first, _ := limiter.enqueue()
+	queued, err := limiter.enqueue()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := queued.wait(ctx); !errors.Is(err, context.Canceled) {
+		t.Fatalf("wait = %v, want context.Canceled", err)
+	}
+	if limiter.waiters.Len() != 0 {
+		t.Errorf("cancelled ticket still queued")
+	}
+
+	first.release()
+	if limiter.running != 0 {
+		t.Errorf("running = %d after release, want 0", limiter.running)
+	}
+}
+
+// blockingExecutor 每次调用登记参数n，并阻塞到release关闭
+type blockingExecutor struct {
+	started chan string
+	release chan struct{}
+}
+
+func (e *blockingExecutor) ExecuteTool(ctx context.Context, name string, arguments map[string]interface{}) (*ToolCallResult, error) {
+	e.started <- fmt.Sprint(arguments["n"])
+	select {
+	case <-e.release:
+		return NewTextResult("ok"), nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// schedulerHarness 已初始化的服务器和调度器，收集写回的消息
+type schedulerHarness struct {
+	server    *BaseServer
+	scheduler *requestScheduler
+	executor  *blockingExecutor
+
+	mu       sync.Mutex
+	messages []*Message
+}
+
+func newSchedulerHarness(t *testing.T, maxInFlight, maxQueued int) *schedulerHarness {
+	t.Helper()
+
+	h := &schedulerHarness{
+		server:   NewBaseServer(),
+		executor: &blockingExecutor{started: make(chan string, 16), release: make(chan struct{})},
+	}
+	h.server.SetMaxInFlight(maxInFlight)
+	h.server.SetMaxQueued(maxQueued)
+	h.server.SetToolExecutor(h.executor)
+	if err := h.server.RegisterTool(Tool{Name: "block", InputSchema: map[string]interface{}{"type": "object"}}); err != nil {
+		t.Fatal(err)
+	}
+	h.scheduler = h.server.newRequestScheduler(func(v interface{}) error {
+		h.mu.Lock()
+		defer h.mu.Unlock()
+		switch v := v.(type) {
+		case *Message:
+			h.messages = append(h.messages, v)
+		case []*Message:
+			h.messages = append(h.messages, v...)
+		}
+		return nil
+	})
+
+	h.dispatch(t, `{"jsonrpc":"2.0","id":"init","method":"initialize","params":{"protocolVersion":"2025-06-18","capabilities":{},"clientInfo":{"name":"test","version":"1"}}}`)
+	h.dispatch(t, `{"jsonrpc":"2.0","method":"notifications/initialized"}`)
+	h.take()
+	return h
+}
+
+func (h *schedulerHarness) dispatch(t *testing.T, frame string) {
+	t.Helper()
+	h.scheduler.DispatchFrame(context.Background(), []byte(frame))
+}
+
+func (h *schedulerHarness) call(t *testing.T, id int) {
+	t.Helper()
+	h.dispatch(t, fmt.Sprintf(`{"jsonrpc":"2.0","id":%d,"method":"tools/call","params":{"name":"block","arguments":{"n":%d}}}`, id, id))
+}
+
+// take 取出目前为止写回的消息
+func (h *schedulerHarness) take() []*Message {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	messages := h.messages
+	h.messages = nil
+	return messages
+}
+
+func (h *schedulerHarness) expectStarted(t *testing.T, want string) {
+	t.Helper()
+	select {
+	case got := <-h.executor.started:
+		if got != want {
+			t.Fatalf("started request %s, want %s", got, want)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("request %s did not start", want)
+	}
+}
+
+func (h *schedulerHarness) expectIdle(t *testing.T) {
+	t.Helper()
+	select {
+	case got := <-h.executor.started:
+		t.Fatalf("request %s started while the only slot was taken", got)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func TestRequestSchedulerQueuesInOrder(t *testing.T) {
+	h := newSchedulerHarness(t, 1, 4)
+
+	for id := 1; id <= 4; id++ {
+		h.call(t, id)
+	}
+	h.expectStarted(t, "1")
+	h.expectIdle(t)
+
+	close(h.executor.release)
+	for _, id := range []string{"2", "3", "4"} {
+		h.expectStarted(t, id)
+	}
+	h.scheduler.Wait()
+
+	if messages := h.take(); len(messages) != 4 {
+		t.Errorf("got %d responses, want 4", len(messages))
+	}
+}
+
+func TestRequestSchedulerRejectsWhenQueueFull(t *testing.T) {
+	h := newSchedulerHarness(t, 1, 1)
+
+	h.call(t, 1)
+	h.expectStarted(t, "1")
+	h.call(t, 2)
+	h.call(t, 3)
+
+	messages := h.take()
+	if len(messages) != 1 || fmt.Sprint(messages[0].ID) != "3" || messages[0].Error == nil || messages[0].Error.Code != ServerErrorCode {
+		t.Fatalf("responses = %+v, want queue full error for request 3", messages)
+	}
+
+	close(h.executor.release)
+	h.expectStarted(t, "2")
+	h.scheduler.Wait()
+}
+
+func TestRequestSchedulerCancelQueuedRequest(t *testing.T) {
+	h := newSchedulerHarness(t, 1, 4)
+
+	h.call(t, 1)
+	h.expectStarted(t, "1")
+	h.call(t, 2)
+	h.call(t, 3)
+
+	// 排队中的请求已经登记，可以被取消
+	if count := h.server.session.inFlight.count(); count != 3 {
+		t.Errorf("in-flight count = %d, want 3", count)
+	}
+	h.dispatch(t, `{"jsonrpc":"2.0","method":"notifications/cancelled","params":{"requestId":2,"reason":"test"}}`)
+
+	close(h.executor.release)
+	h.expectStarted(t, "3")
+	h.scheduler.Wait()
+
+	select {
+	case got := <-h.executor.started:
+		t.Fatalf("request %s ran, want cancelled request 2 skipped", got)
+	default:
+	}
+
+	ids := map[string]bool{}
+	for _, msg := range h.take() {
+		data, _ := json.Marshal(msg.ID)
+		ids[string(data)] = true
+	}
+	if !ids["1"] || !ids["3"] || ids["2"] || len(ids) != 2 {
+		t.Errorf("responses for %v, want 1 and 3 only", ids)
+	}
+	if count := h.server.session.inFlight.count(); count != 0 {
+		t.Errorf("in-flight count = %d after completion, want 0", count)
+	}
+}
+
+func TestRequestSchedulerBatchHonorsLimit(t *testing.T) {
+	h := newSchedulerHarness(t, 1, 1)
+
+	// 一帧中的三个调用各自占用名额：一个执行、一个排队、一个因队列已满被拒绝
+	h.dispatch(t, `[
+		{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"block","arguments":{"n":1}}},
+		{"jsonrpc":"2.0","id":2,"method":"tools/call","params":{"name":"block","arguments":{"n":2}}},
+		{"jsonrpc":"2.0","id":3,"method":"tools/call","params":{"name":"block","arguments":{"n":3}}}
+	]`)
+	h.expectStarted(t, "1")
+	h.expectIdle(t)
+
+	close(h.executor.release)
+	h.expectStarted(t, "2")
+	h.scheduler.Wait()
+
+	messages := h.take()
+	if len(messages) != 3 {
+		t.Fatalf("got %d responses, want 3", len(messages))
+	}
+	for i, msg := range messages {
+		if got := fmt.Sprint(msg.ID); got != fmt.Sprint(i+1) {
+			t.Errorf("response %d has id %s, want responses in request order", i, got)
+		}
+	}
+	if messages[0].Error != nil || messages[1].Error != nil {
+		t.Errorf("responses 1 and 2 = %+v, %+v, want results", messages[0].Error, messages[1].Error)
+	}
+	if messages[2].Error == nil || messages[2].Error.Code != ServerErrorCode {
+		t.Errorf("response 3 = %+v, want queue full error", messages[2])
+	}
+}
@@ -1,11 +1,14 @@
 package mcp
 
 import (
+	"bufio"
+	"bytes"
 	"context"
 	"encoding/json"
 	"fmt"
 	"io"
 	"log"
+	"sort"
 	"sync"
 )
 
@@ -22,13 +25,22 @@ type Server interface {
 
 	// 注册资源处理器
 	RegisterResourceHandler(scheme string, handler ResourceHandler) error
+
+	// 注册提示词
+	RegisterPrompt(prompt Prompt) error
 }
 
-// ResourceHandler 资源处理器接口
+// ResourceHandler 资源处理器接口，按URI scheme注册
 type ResourceHandler interface {
 	Read(ctx context.Context, uri string) (*ResourceReadResult, error)
 	Write(ctx context.Context, uri string, content []Content) error
-	List(ctx context.Context, uri string) ([]string, error)
+	List(ctx context.Context) ([]Resource, error)
+	Templates() []ResourceTemplate
+}
+
+// PromptProvider 提示词渲染接口
+type PromptProvider interface {
+	GetPrompt(ctx context.Context, name string, arguments map[string]string) (*GetPromptResult, error)
 }
 
 // ToolExecutor 工具执行器接口
@@ -36,14 +48,22 @@ type ToolExecutor interface {
 	ExecuteTool(ctx context.Context, name string, arguments map[string]interface{}) (*ToolCallResult, error)
 }
 
+// defaultMaxInFlight 每个连接默认的最大并发请求数
+const defaultMaxInFlight = 16
+
 // BaseServer MCP服务器基础实现
 type BaseServer struct {
 	tools            map[string]Tool
 	resourceHandlers map[string]ResourceHandler
 	toolExecutor     ToolExecutor
+	prompts          map[string]Prompt
+	promptProvider   PromptProvider
+	session          *Session
+	sessions         map[string]*Session
+	maxInFlight      int
+	maxQueued        int
 	mu               sync.RWMutex
-	initialized      bool
-	clientInfo       *ClientInfo
+	serverInfo       *ServerInfo
 	capabilities     map[string]interface{}
 }
 
@@ -52,7 +72,28 @@ func NewBaseServer() *BaseServer {
 	return &BaseServer{
 		tools:            make(map[string]Tool),
 		resourceHandlers: make(map[string]ResourceHandler),
-		capabilities:     make(map[string]interface{}),
+		prompts:          make(map[string]Prompt),
+		session:          NewSession(newSessionID()),
+		sessions:         make(map[string]*Session),
+		maxInFlight:      defaultMaxInFlight,
+		maxQueued:        defaultMaxQueued,
+		serverInfo: &ServerInfo{
+			Name:    "mcp-ai-server",
+			Version: "1.0.0",
+		},
+		// 默认能力，所有传输层一致
+		capabilities: map[string]interface{}{
+			"tools": map[string]interface{}{
+				"listChanged": true,
+			},
+			"resources": map[string]interface{}{
+				"subscribe":   true,
+				"listChanged": true,
+			},
+			"prompts": map[string]interface{}{
+				"listChanged": false,
+			},
+		},
 	}
 }
 
@@ -69,6 +110,29 @@ func (s *BaseServer) RegisterTool(tool Tool) error {
 	return nil
 }
 
+// ReplaceTools 用新的工具列表替换已注册的工具，并通知所有会话工具列表已变化
+func (s *BaseServer) ReplaceTools(tools []Tool) error {
+	toolMap := make(map[string]Tool, len(tools))
+	for _, tool := range tools {
+		if tool.Name == "" {
+			return fmt.Errorf("tool name cannot be empty")
+		}
+		toolMap[tool.Name] = tool
+	}
+
+	s.mu.Lock()
+	s.tools = toolMap
+	s.mu.Unlock()
+
+	s.NotifyToolsListChanged()
+	return nil
+}
+
+// NotifyToolsListChanged 向所有会话发送 notifications/tools/list_changed
+func (s *BaseServer) NotifyToolsListChanged() {
+	s.broadcast(NewNotification("notifications/tools/list_changed", map[string]interface{}{}), nil)
+}
+
 // RegisterResourceHandler 注册资源处理器
 func (s *BaseServer) RegisterResourceHandler(scheme string, handler ResourceHandler) error {
 	s.mu.Lock()
@@ -82,6 +146,42 @@ func (s *BaseServer) RegisterResourceHandler(scheme string, handler ResourceHand
 	return nil
 }
 
+// RegisterPrompt 注册提示词
+func (s *BaseServer) RegisterPrompt(prompt Prompt) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if prompt.Name == "" {
+		return fmt.Errorf("prompt name cannot be empty")
+	}
+
+	s.prompts[prompt.Name] = prompt
+	return nil
+}
+
+// GetPrompts 获取所有提示词，按名称排序
+func (s *BaseServer) GetPrompts() []Prompt {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	prompts := make([]Prompt, 0, len(s.prompts))
+	for _, prompt := range s.prompts {
+		prompts = append(prompts, prompt)
+	}
+	sort.Slice(prompts, func(i, j int) bool {
+		return prompts[i].Name < prompts[j].Name
+	})
+	return prompts
+}
+
+// SetPromptProvider 设置提示词渲染器
+func (s *BaseServer) SetPromptProvider(provider PromptProvider) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.promptProvider = provider
+}
+
 // GetTool 获取工具
 func (s *BaseServer) GetTool(name string) (Tool, bool) {
 	s.mu.RLock()
@@ -112,6 +212,24 @@ func (s *BaseServer) GetResourceHandler(scheme string) (ResourceHandler, bool) {
 	return handler, exists
 }
 
+// GetResourceHandlers 获取所有资源处理器，按scheme排序
+func (s *BaseServer) GetResourceHandlers() []ResourceHandler {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	schemes := make([]string, 0, len(s.resourceHandlers))
+	for scheme := range s.resourceHandlers {
+		schemes = append(schemes, scheme)
+	}
+	sort.Strings(schemes)
+
+	handlers := make([]ResourceHandler, 0, len(schemes))
+	for _, scheme := range schemes {
+		handlers = append(handlers, s.resourceHandlers[scheme])
+	}
+	return handlers
+}
+
 // SetCapabilities 设置服务器能力
 func (s *BaseServer) SetCapabilities(capabilities map[string]interface{}) {
 	s.mu.Lock()
@@ -128,6 +246,63 @@ func (s *BaseServer) SetToolExecutor(executor ToolExecutor) {
 	s.toolExecutor = executor
 }
 
+// SetServerInfo 设置服务器信息
+func (s *BaseServer) SetServerInfo(info *ServerInfo) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.serverInfo = info
+}
+
+// GetServerInfo 获取服务器信息
+func (s *BaseServer) GetServerInfo() *ServerInfo {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.serverInfo
+}
+
+// SetMaxInFlight 设置每个连接的最大并发请求数
+func (s *BaseServer) SetMaxInFlight(n int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if n > 0 {
+		s.maxInFlight = n
+	}
+}
+
+// GetMaxInFlight 获取每个连接的最大并发请求数
+func (s *BaseServer) GetMaxInFlight() int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.maxInFlight
+}
+
+// SetMaxQueued 设置每个连接的最大排队请求数，并发名额用尽后的请求在队列中等待，队列满时返回错误
+func (s *BaseServer) SetMaxQueued(n int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if n >= 0 {
+		s.maxQueued = n
+	}
+}
+
+// GetMaxQueued 获取每个连接的最大排队请求数
+func (s *BaseServer) GetMaxQueued() int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.maxQueued
+}
+
+// newRequestLimiter 按当前设置为一个连接创建请求限制器
+func (s *BaseServer) newRequestLimiter() *requestLimiter {
+	return newRequestLimiter(s.GetMaxInFlight(), s.GetMaxQueued())
+}
+
 // GetCapabilities 获取服务器能力
 func (s *BaseServer) GetCapabilities() map[string]interface{} {
 	s.mu.RLock()
@@ -139,10 +314,11 @@ func (s *BaseServer) GetCapabilities() map[string]interface{} {
 // StdioServer 基于标准输入输出的MCP服务器
 type StdioServer struct {
 	*BaseServer
-	reader io.Reader
-	writer io.Writer
-	ctx    context.Context
-	cancel context.CancelFunc
+	reader  io.Reader
+	writer  io.Writer
+	writeMu sync.Mutex
+	ctx     context.Context
+	cancel  context.CancelFunc
 }
 
 // NewStdioServer 创建新的stdio服务器
@@ -166,16 +342,6 @@ func (s *StdioServer) SetToolExecutor(executor ToolExecutor) {
 func (s *StdioServer) Start() error {
 	// 注意：在stdio模式下，日志应该输出到stderr，避免干扰JSON通信
 
-	// 设置默认能力
-	s.SetCapabilities(map[string]interface{}{
-		"tools": map[string]interface{}{
-			"listChanged": true,
-		},
-		"resources": map[string]interface{}{
-			"listChanged": true,
-		},
-	})
-
 	// 启动消息处理循环
 	go s.handleMessages()
 
@@ -189,217 +355,53 @@ func (s *StdioServer) Stop() error {
 	return nil
 }
 
-// handleMessages 处理消息循环
+// handleMessages 处理消息循环，每行一帧（单条消息或批量请求数组）
 func (s *StdioServer) handleMessages() {
-	decoder := json.NewDecoder(s.reader)
+	reader := bufio.NewReader(s.reader)
+	scheduler := s.newRequestScheduler(s.writeFrame)
+	// stdio只有一个客户端，使用服务器的默认会话
+	s.registerSession(s.session, scheduler.Notifier())
+	ctx := WithNotifier(WithSession(s.ctx, s.session), scheduler.Notifier())
 
 	for {
 		select {
 		case <-s.ctx.Done():
 			return
 		default:
-			var msg Message
-			if err := decoder.Decode(&msg); err != nil {
+			line, err := reader.ReadBytes('\n')
+			if len(bytes.TrimSpace(line)) > 0 {
+				// 请求并发执行，响应按ID与请求对应
+				msg := scheduler.DispatchFrame(ctx, line)
+
+				// shutdown 响应发出后停止服务器
+				if msg != nil && msg.IsRequest() && msg.Method == "shutdown" {
+					go s.Stop()
+				}
+			}
+			if err != nil {
 				if err == io.EOF {
 					log.Println("客户端断开连接")
-					return
+				} else {
+					log.Printf("读取消息错误: %v", err)
 				}
-				log.Printf("解析消息错误: %v", err)
-				continue
-			}
-
-			// 处理消息
-			if err := s.handleMessage(&msg); err != nil {
-				log.Printf("处理消息错误: %v", err)
+				return
 			}
 		}
 	}
 }
 
-// handleMessage 处理单个消息
-func (s *StdioServer) handleMessage(msg *Message) error {
-	// 验证消息
-	if err := msg.Validate(); err != nil {
-		return s.sendError(msg.ID, ParseErrorCode, err.Error(), nil)
-	}
-
-	// 根据消息类型处理
-	switch {
-	case msg.IsRequest():
-		return s.handleRequest(msg)
-	case msg.IsResponse():
-		// 服务器通常不处理响应
-		return nil
-	case msg.IsNotification():
-		return s.handleNotification(msg)
-	default:
-		return s.sendError(msg.ID, InvalidRequestCode, "invalid message", nil)
-	}
-}
-
-// handleRequest 处理请求
-func (s *StdioServer) handleRequest(msg *Message) error {
-	switch msg.Method {
-	case "initialize":
-		return s.handleInitialize(msg)
-	case "tools/list":
-		return s.handleToolsList(msg)
-	case "tools/call":
-		return s.handleToolCall(msg)
-	case "resources/read":
-		return s.handleResourceRead(msg)
-	case "shutdown":
-		return s.handleShutdown(msg)
-	default:
-		return s.sendError(msg.ID, MethodNotFoundCode, "method not found: "+msg.Method, nil)
-	}
-}
-
-// handleInitialize 处理初始化请求
-func (s *StdioServer) handleInitialize(msg *Message) error {
-	if s.initialized {
-		return s.sendError(msg.ID, InvalidRequestCode, "already initialized", nil)
-	}
-
-	var params InitializeParams
-	if err := json.Unmarshal(msg.Params, &params); err != nil {
-		return s.sendError(msg.ID, InvalidParamsCode, "invalid initialize params", nil)
-	}
-
-	// 保存客户端信息
-	s.clientInfo = params.ClientInfo
-	s.initialized = true
-
-	// 发送初始化响应
-	result := InitializeResult{
-		ProtocolVersion: ProtocolVersion,
-		Capabilities:    s.GetCapabilities(),
-		ServerInfo: &ServerInfo{
-			Name:    "mcp-ai-server",
-			Version: "1.0.0",
-		},
-	}
-
-	return s.sendResponse(msg.ID, result)
-}
-
-// handleToolsList 处理工具列表请求
-func (s *StdioServer) handleToolsList(msg *Message) error {
-	if !s.initialized {
-		return s.sendError(msg.ID, InvalidRequestCode, "not initialized", nil)
-	}
-
-	tools := s.GetTools()
-	return s.sendResponse(msg.ID, map[string]interface{}{
-		"tools": tools,
-	})
-}
-
-// handleToolCall 处理工具调用请求
-func (s *StdioServer) handleToolCall(msg *Message) error {
-	if !s.initialized {
-		return s.sendError(msg.ID, InvalidRequestCode, "not initialized", nil)
-	}
-
-	var params ToolCallParams
-	if err := json.Unmarshal(msg.Params, &params); err != nil {
-		return s.sendError(msg.ID, InvalidParamsCode, "invalid tool call params", nil)
-	}
-
-	// 查找工具
-	tool, exists := s.GetTool(params.Name)
-	if !exists {
-		return s.sendError(msg.ID, MethodNotFoundCode, "tool not found: "+params.Name, nil)
-	}
-
-	// 调用实际的工具实现
-	if s.toolExecutor != nil {
-		result, err := s.toolExecutor.ExecuteTool(context.Background(), params.Name, params.Arguments)
-		if err != nil {
-			return s.sendError(msg.ID, InternalErrorCode, fmt.Sprintf("工具执行失败: %v", err), nil)
-		}
-		return s.sendResponse(msg.ID, result)
-	}
-
-	// 如果没有工具处理器，返回默认响应
-	result := ToolCallResult{
-		Content: []Content{
-			{
-				Type: "text",
-				Text: fmt.Sprintf("工具 %s 被调用，参数: %v", tool.Name, params.Arguments),
-			},
-		},
-	}
-
-	return s.sendResponse(msg.ID, result)
-}
-
-// handleResourceRead 处理资源读取请求
-func (s *StdioServer) handleResourceRead(msg *Message) error {
-	if !s.initialized {
-		return s.sendError(msg.ID, InvalidRequestCode, "not initialized", nil)
-	}
-
-	var params ResourceReadParams
-	if err := json.Unmarshal(msg.Params, &params); err != nil {
-		return s.sendError(msg.ID, InvalidParamsCode, "invalid resource read params", nil)
-	}
-
-	// 这里应该调用实际的资源处理器
-	// 暂时返回一个简单的响应
-	result := ResourceReadResult{
-		Contents: []Content{
-			{
-				Type: "text",
-				Text: fmt.Sprintf("读取资源: %s", params.URI),
-			},
-		},
-		MimeType: "text/plain",
-	}
-
-	return s.sendResponse(msg.ID, result)
-}
-
-// handleShutdown 处理关闭请求
-func (s *StdioServer) handleShutdown(msg *Message) error {
-	// 发送关闭响应
-	if err := s.sendResponse(msg.ID, nil); err != nil {
-		return err
-	}
-
-	// 停止服务器
-	go s.Stop()
-	return nil
-}
-
-// handleNotification 处理通知
-func (s *StdioServer) handleNotification(msg *Message) error {
-	// 服务器通常不处理通知
-	log.Printf("收到通知: %s", msg.Method)
-	return nil
-}
-
-// sendResponse 发送响应
-func (s *StdioServer) sendResponse(id interface{}, result interface{}) error {
-	response := NewResponse(id, result)
-	return s.sendMessage(response)
-}
-
-// sendError 发送错误响应
-func (s *StdioServer) sendError(id interface{}, code int, message string, data interface{}) error {
-	response := NewErrorResponse(id, code, message, data)
-	return s.sendMessage(response)
-}
-
-// sendMessage 发送消息
-func (s *StdioServer) sendMessage(msg *Message) error {
-	data, err := json.Marshal(msg)
+// writeFrame 发送一帧消息，多个goroutine写入时保证每帧完整
+func (s *StdioServer) writeFrame(v interface{}) error {
+	data, err := json.Marshal(v)
 	if err != nil {
 		return fmt.Errorf("序列化消息失败: %v", err)
 	}
 
 	data = append(data, '\n')
 
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+
 	_, err = s.writer.Write(data)
 	if err != nil {
 		return fmt.Errorf("发送消息失败: %v", err)
@@ -0,0 +1,311 @@
+package mcp
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Session 单个客户端会话的协议状态
+// 初始化状态、协商的协议版本、客户端信息、资源订阅和正在执行的请求都属于会话，
+// 不同会话之间的请求ID互不影响。
+type Session struct {
+	id                 string
+	remoteAddr         string
+	principal          *Principal
+	createdAt          time.Time
+	inFlight           *inFlightRequests
+	pending            *pendingRequests
+	mu                 sync.RWMutex
+	initialized        bool
+	protocolVersion    string
+	clientInfo         *ClientInfo
+	clientCapabilities map[string]interface{}
+	subscriptions      map[string]bool
+	notifier           Notifier
+	roots              []Root
+	rootsLoaded        bool
+	rootsFetch         sync.Mutex
+}
+
+// SessionInfo 会话状态快照，包含认证主体和客户端信息，只能在认证后的场景中使用
+type SessionInfo struct {
+	ID                 string                 `json:"id"`
+	RemoteAddr         string                 `json:"remoteAddr,omitempty"`
+	Principal          *Principal             `json:"principal,omitempty"`
+	CreatedAt          string                 `json:"createdAt"`
+	Initialized        bool                   `json:"initialized"`
+	ProtocolVersion    string                 `json:"protocolVersion,omitempty"`
+	ClientInfo         *ClientInfo            `json:"clientInfo,omitempty"`
+	ClientCapabilities map[string]interface{} `json:"clientCapabilities,omitempty"`
+	Subscriptions      []string               `json:"subscriptions,omitempty"`
+	Roots              []Root                 `json:"roots,omitempty"`
+	InFlightRequests   int                    `json:"inFlightRequests"`
+}
+
+type sessionKey struct{}
+
+// NewSession 创建新的会话
+func NewSession(id string) *Session {
+	return &Session{
+		id:            id,
+		createdAt:     time.Now(),
+		inFlight:      newInFlightRequests(),
+		pending:       newPendingRequests(),
+		subscriptions: make(map[string]bool),
+	}
+}
+
+// newSessionID 生成随机会话ID
+func newSessionID() string {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return time.Now().Format("20060102150405.000000000")
+	}
+	return hex.EncodeToString(buf)
+}
+
+// ID 会话ID
+func (s *Session) ID() string {
+	return s.id
+}
+
+// CreatedAt 会话创建时间
+func (s *Session) CreatedAt() time.Time {
+	return s.createdAt
+}
+
+// Principal 获取连接认证时确定的调用方，未启用认证时为nil
+func (s *Session) Principal() *Principal {
+	return s.principal
+}
+
+// IsInitialized 检查会话是否已完成初始化
+func (s *Session) IsInitialized() bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.initialized
+}
+
+// ClientInfo 获取初始化时客户端提供的信息
+func (s *Session) ClientInfo() *ClientInfo {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.clientInfo
+}
+
+// ProtocolVersion 获取协商后的协议版本，未初始化时为空
+func (s *Session) ProtocolVersion() string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.protocolVersion
+}
+
+// ClientCapabilities 获取客户端在初始化时声明的能力
+func (s *Session) ClientCapabilities() map[string]interface{} {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.clientCapabilities
+}
+
+// initialize 保存协商结果并标记会话已初始化，重复初始化返回false
+func (s *Session) initialize(protocolVersion string, params InitializeParams) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.initialized {
+		return false
+	}
+	s.protocolVersion = protocolVersion
+	s.clientInfo = params.ClientInfo
+	s.clientCapabilities = params.Capabilities
+	if s.clientCapabilities == nil {
+		s.clientCapabilities = map[string]interface{}{}
+	}
+	s.initialized = true
+	return true
+}
+
+// reset 清除初始化状态、协商结果和资源订阅
+func (s *Session) reset() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.initialized = false
+	s.protocolVersion = ""
+	s.clientInfo = nil
+	s.clientCapabilities = nil
+	s.subscriptions = make(map[string]bool)
+	s.roots = nil
+	s.rootsLoaded = false
+}
+
+// subscribe 订阅资源更新
+func (s *Session) subscribe(uri string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.subscriptions[uri] = true
+}
+
+// unsubscribe 取消资源订阅
+func (s *Session) unsubscribe(uri string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.subscriptions, uri)
+}
+
+// IsSubscribed 判断会话是否订阅了指定资源
+func (s *Session) IsSubscribed(uri string) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.subscriptions[uri]
+}
+
+// setNotifier 设置向该会话推送服务器通知的函数
+func (s *Session) setNotifier(notifier Notifier) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.notifier = notifier
+}
+
+// Notify 向会话推送服务器通知，会话未初始化或没有可用的连接时返回错误
+func (s *Session) Notify(msg *Message) error {
+	s.mu.RLock()
+	notifier := s.notifier
+	initialized := s.initialized
+	s.mu.RUnlock()
+
+	if !initialized {
+		return fmt.Errorf("会话 %s 尚未初始化", s.id)
+	}
+	if notifier == nil {
+		return fmt.Errorf("会话 %s 没有可用的连接", s.id)
+	}
+	return notifier(msg)
+}
+
+// Info 获取会话状态快照
+func (s *Session) Info() SessionInfo {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	subscriptions := make([]string, 0, len(s.subscriptions))
+	for uri := range s.subscriptions {
+		subscriptions = append(subscriptions, uri)
+	}
+	sort.Strings(subscriptions)
+
+	return SessionInfo{
+		ID:                 s.id,
+		RemoteAddr:         s.remoteAddr,
+		Principal:          s.principal,
+		CreatedAt:          s.createdAt.Format(time.RFC3339),
+		Initialized:        s.initialized,
+		ProtocolVersion:    s.protocolVersion,
+		ClientInfo:         s.clientInfo,
+		ClientCapabilities: s.clientCapabilities,
+		Subscriptions:      subscriptions,
+		Roots:              s.roots,
+		InFlightRequests:   s.inFlight.count(),
+	}
+}
+
+// WithSession 将会话附加到上下文，传输层为每个客户端提供独立的会话
+func WithSession(ctx context.Context, session *Session) context.Context {
+	return context.WithValue(ctx, sessionKey{}, session)
+}
+
+// SessionFromContext 获取上下文中的会话
+func SessionFromContext(ctx context.Context) (*Session, bool) {
+	session, ok := ctx.Value(sessionKey{}).(*Session)
+	return session, ok && session != nil
+}
+
+// registerSession 登记已连接的会话，用于向所有会话广播通知
+func (s *BaseServer) registerSession(session *Session, notifier Notifier) {
+	session.setNotifier(notifier)
+
+	s.mu.Lock()
+	s.sessions[session.id] = session
+	s.mu.Unlock()
+}
+
+// unregisterSession 注销会话并取消其正在执行的请求
+func (s *BaseServer) unregisterSession(session *Session) {
+	s.mu.Lock()
+	delete(s.sessions, session.id)
+	s.mu.Unlock()
+
+	session.setNotifier(nil)
+	session.inFlight.cancelAll()
+}
+
+// Sessions 获取当前所有已连接的会话（按创建时间排序）
+func (s *BaseServer) Sessions() []*Session {
+	s.mu.RLock()
+	sessions := make([]*Session, 0, len(s.sessions))
+	for _, session := range s.sessions {
+		sessions = append(sessions, session)
+	}
+	s.mu.RUnlock()
+
+	sort.Slice(sessions, func(i, j int) bool {
+		return sessions[i].createdAt.Before(sessions[j].createdAt)
+	})
+	return sessions
+}
+
+// sessionInfos 获取所有会话的状态快照，只能返回给认证后的调用方
+func (s *BaseServer) sessionInfos() []SessionInfo {
+	sessions := s.Sessions()
+	infos := make([]SessionInfo, 0, len(sessions))
+	for _, session := range sessions {
+		infos = append(infos, session.Info())
+	}
+	return infos
+}
+
+// sessionStats 汇总会话数量，供未认证的健康检查使用，不包含任何会话详情
+func (s *BaseServer) sessionStats() map[string]int {
+	stats := map[string]int{"total": 0, "initialized": 0, "inFlightRequests": 0}
+	for _, session := range s.Sessions() {
+		stats["total"]++
+		if session.IsInitialized() {
+			stats["initialized"]++
+		}
+		stats["inFlightRequests"] += session.inFlight.count()
+	}
+	return stats
+}
+
+// broadcast 向所有已初始化且满足条件的会话发送通知，filter为nil时发送给所有会话
+func (s *BaseServer) broadcast(msg *Message, filter func(*Session) bool) {
+	for _, session := range s.Sessions() {
+		if !session.IsInitialized() || (filter != nil && !filter(session)) {
+			continue
+		}
+		if err := session.Notify(msg); err != nil {
+			logSendError(err)
+		}
+	}
+}
+
+// sessionFor 获取处理请求所用的会话，上下文中没有会话时使用服务器默认会话
+func (s *BaseServer) sessionFor(ctx context.Context) *Session {
+	if session, ok := SessionFromContext(ctx); ok {
+		return session
+	}
+	return s.session
+}
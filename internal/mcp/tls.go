@@ -0,0 +1,127 @@
+package mcp
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"strings"
+)
+
+// AuthMethodMTLS 通过客户端证书认证
+const AuthMethodMTLS = "mtls"
+
+// NewServerTLSConfig 根据证书文件创建服务端TLS配置
+// clientCAFile不为空时用它校验客户端证书，clientAuth决定是否要求客户端提供证书
+func NewServerTLSConfig(certFile, keyFile, clientCAFile string, clientAuth tls.ClientAuthType) (*tls.Config, error) {
+	certificate, err := tls.LoadX509KeyPair(certFile, keyFile)
+	if err != nil {
+		return nil, fmt.Errorf("加载服务器证书失败: %v", err)
+	}
+
+	config := &tls.Config{
+		MinVersion:   tls.VersionTLS12,
+		Certificates: []tls.Certificate{certificate},
+		ClientAuth:   tls.NoClientCert,
+	}
+
+	if clientCAFile != "" {
+		data, err := os.ReadFile(clientCAFile)
+		if err != nil {
+			return nil, fmt.Errorf("读取客户端CA文件失败: %v", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(data) {
+			return nil, fmt.Errorf("客户端CA文件中没有有效的证书")
+		}
+		config.ClientCAs = pool
+		config.ClientAuth = clientAuth
+	}
+
+	return config, nil
+}
+
+// certificatePrincipal 从已校验的客户端证书中确定认证主体
+// principals为空时使用证书CN；否则按CN、DNS和邮箱SAN的顺序查找映射，找不到映射的证书被拒绝
+// 没有客户端证书时返回nil
+func certificatePrincipal(r *http.Request, principals map[string]string) (*Principal, error) {
+	if r.TLS == nil || len(r.TLS.VerifiedChains) == 0 || len(r.TLS.VerifiedChains[0]) == 0 {
+		return nil, nil
+	}
+	certificate := r.TLS.VerifiedChains[0][0]
+
+	identities := []string{certificate.Subject.CommonName}
+	identities = append(identities, certificate.DNSNames...)
+	identities = append(identities, certificate.EmailAddresses...)
+
+	if len(principals) == 0 {
+		for _, identity := range identities {
+			if identity != "" {
+				return &Principal{Subject: identity, Method: AuthMethodMTLS, ExpiresAt: certificate.NotAfter}, nil
+			}
+		}
+		return nil, &AuthError{Status: http.StatusForbidden, Code: "invalid_client", Description: "客户端证书缺少可用的身份标识"}
+	}
+
+	for _, identity := range identities {
+		if name, ok := principals[identity]; ok && identity != "" {
+			return &Principal{Subject: name, Method: AuthMethodMTLS, ExpiresAt: certificate.NotAfter}, nil
+		}
+	}
+	return nil, &AuthError{
+		Status:      http.StatusForbidden,
+		Code:        "invalid_client",
+		Description: fmt.Sprintf("客户端证书 %s 没有对应的认证主体", certificate.Subject.CommonName),
+	}
+}
+
+// newOriginChecker 创建浏览器请求的Origin校验函数，WebSocket升级请求和Streamable HTTP请求共用，用于防范DNS重绑定
+// allowed为空时只接受来自本机回环地址（localhost、127.0.0.0/8、::1）的页面，
+// 不按Host头判断同源，因为重绑定后的恶意页面与Host同源；
+// "*" 接受所有来源；支持 "https://*.example.com" 形式的子域名通配
+// 没有Origin头的请求来自非浏览器客户端，不受此限制
+func newOriginChecker(allowed []string) func(r *http.Request) bool {
+	patterns := make([]string, 0, len(allowed))
+	for _, origin := range allowed {
+		patterns = append(patterns, strings.ToLower(strings.TrimRight(origin, "/")))
+	}
+
+	return func(r *http.Request) bool {
+		origin := r.Header.Get("Origin")
+		if origin == "" {
+			return true
+		}
+		parsed, err := url.Parse(origin)
+		if err != nil || parsed.Host == "" {
+			return false
+		}
+
+		if len(patterns) == 0 {
+			return isLoopbackHost(parsed.Hostname())
+		}
+		normalized := strings.ToLower(parsed.Scheme + "://" + parsed.Host)
+		for _, pattern := range patterns {
+			if pattern == "*" || pattern == normalized {
+				return true
+			}
+			if matched, _ := path.Match(pattern, normalized); matched {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// isLoopbackHost 判断主机名是否指向本机回环地址
+func isLoopbackHost(host string) bool {
+	host = strings.ToLower(strings.TrimSuffix(host, "."))
+	if host == "localhost" || strings.HasSuffix(host, ".localhost") {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
@@ -0,0 +1,51 @@
+package mcp
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestOriginChecker(t *testing.T) {
+	tests := []struct {
+		name    string
+		allowed []string
+		host    string
+		origin  string
+		want    bool
+	}{
+		{name: "no origin", host: "mcp.example.com", want: true},
+		{name: "localhost", host: "localhost:8081", origin: "http://localhost:3000", want: true},
+		{name: "loopback ip", host: "127.0.0.1:8081", origin: "http://127.0.0.1:3000", want: true},
+		{name: "ipv6 loopback", host: "[::1]:8081", origin: "http://[::1]:3000", want: true},
+		// DNS重绑定后的页面与Host同源，空列表时仍须拒绝
+		{name: "rebound same origin", host: "evil.example:8081", origin: "http://evil.example:8081"},
+		{name: "remote origin", host: "127.0.0.1:8081", origin: "https://evil.example"},
+		{name: "malformed origin", host: "localhost:8081", origin: "null"},
+		{name: "allowed exact", allowed: []string{"https://app.example.com/"}, host: "mcp.example.com", origin: "https://app.example.com", want: true},
+		{name: "allowed wildcard", allowed: []string{"https://*.example.com"}, host: "mcp.example.com", origin: "https://a.example.com", want: true},
+		{name: "allowed list excludes loopback", allowed: []string{"https://app.example.com"}, host: "localhost:8081", origin: "http://localhost:3000"},
+		{name: "allow all", allowed: []string{"*"}, host: "mcp.example.com", origin: "https://evil.example", want: true},
+	}
+
+	webSocketServer := NewWebSocketServer(0)
+	httpServer := NewHTTPServer(0)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			webSocketServer.SetAllowedOrigins(tt.allowed)
+			httpServer.SetAllowedOrigins(tt.allowed)
+
+			r := httptest.NewRequest(http.MethodGet, "/mcp", nil)
+			r.Host = tt.host
+			if tt.origin != "" {
+				r.Header.Set("Origin", tt.origin)
+			}
+			if got := webSocketServer.upgrader.CheckOrigin(r); got != tt.want {
+				t.Errorf("websocket CheckOrigin(%q, host %q) = %v, want %v", tt.origin, tt.host, got, tt.want)
+			}
+			if got := httpServer.checkOrigin(r); got != tt.want {
+				t.Errorf("http checkOrigin(%q, host %q) = %v, want %v", tt.origin, tt.host, got, tt.want)
+			}
+		})
+	}
+}
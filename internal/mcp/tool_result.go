@@ -0,0 +1,48 @@
+package mcp
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// NewTextResult 创建只包含文本内容的工具结果
+func NewTextResult(text string) *ToolCallResult {
+	return &ToolCallResult{
+		Content: []Content{
+			{
+				Type: "text",
+				Text: text,
+			},
+		},
+	}
+}
+
+// NewStructuredResult 创建结构化工具结果
+// 结构化内容同时序列化为格式化JSON文本，兼容不支持structuredContent的客户端
+func NewStructuredResult(v interface{}) *ToolCallResult {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return NewToolErrorResult(fmt.Sprintf("序列化工具结果失败: %v", err))
+	}
+
+	result := NewTextResult(string(data))
+	result.StructuredContent = v
+	return result
+}
+
+// NewToolErrorResult 创建工具执行失败的结果，错误信息返回给模型而不是作为协议错误
+func NewToolErrorResult(text string) *ToolCallResult {
+	result := NewTextResult(text)
+	result.IsError = true
+	return result
+}
+
+// stripStructuredOutput 去掉旧版本协议不支持的结构化输出字段
+func stripStructuredOutput(tools []Tool) []Tool {
+	stripped := make([]Tool, len(tools))
+	for i, tool := range tools {
+		tool.OutputSchema = nil
+		stripped[i] = tool
+	}
+	return stripped
+}
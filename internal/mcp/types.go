@@ -50,26 +50,147 @@ type ServerInfo struct {
 
 // 工具定义
 type Tool struct {
-	Name        string                 `json:"name"`
-	Description string                 `json:"description"`
-	InputSchema map[string]interface{} `json:"inputSchema"`
+	Name         string                 `json:"name"`
+	Description  string                 `json:"description"`
+	InputSchema  map[string]interface{} `json:"inputSchema"`
+	OutputSchema map[string]interface{} `json:"outputSchema,omitempty"`
+}
+
+// 请求元数据
+type RequestMeta struct {
+	ProgressToken interface{} `json:"progressToken,omitempty"`
 }
 
 // 工具调用参数
 type ToolCallParams struct {
 	Name      string                 `json:"name"`
 	Arguments map[string]interface{} `json:"arguments"`
+	Meta      *RequestMeta           `json:"_meta,omitempty"`
+}
+
+// 进度通知参数
+type ProgressParams struct {
+	ProgressToken interface{} `json:"progressToken"`
+	Progress      float64     `json:"progress"`
+	Total         float64     `json:"total,omitempty"`
+	Message       string      `json:"message,omitempty"`
+}
+
+// 取消通知参数
+type CancelledParams struct {
+	RequestID interface{} `json:"requestId"`
+	Reason    string      `json:"reason,omitempty"`
 }
 
 // 工具调用结果
 type ToolCallResult struct {
-	Content []Content `json:"content"`
+	Content           []Content   `json:"content"`
+	StructuredContent interface{} `json:"structuredContent,omitempty"`
+	IsError           bool        `json:"isError,omitempty"`
 }
 
-// 内容结构
+// 内容结构，按Type区分的联合类型：
+//   - text: Text
+//   - image / audio: Data（base64）和 MimeType
+//   - resource: Resource（内嵌的资源内容）
+//   - resource_link: URI、Name、Description、MimeType
 type Content struct {
-	Type string `json:"type"`
-	Text string `json:"text"`
+	Type        string            `json:"type"`
+	Text        string            `json:"text,omitempty"`
+	Data        string            `json:"data,omitempty"`
+	MimeType    string            `json:"mimeType,omitempty"`
+	Resource    *ResourceContents `json:"resource,omitempty"`
+	URI         string            `json:"uri,omitempty"`
+	Name        string            `json:"name,omitempty"`
+	Description string            `json:"description,omitempty"`
+	Annotations *Annotations      `json:"annotations,omitempty"`
+}
+
+// 内容注解，提示客户端内容的目标受众和重要程度
+type Annotations struct {
+	Audience     []string `json:"audience,omitempty"`
+	Priority     *float64 `json:"priority,omitempty"`
+	LastModified string   `json:"lastModified,omitempty"`
+}
+
+// 提示词参数定义
+type PromptArgument struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	Required    bool   `json:"required,omitempty"`
+}
+
+// 提示词定义
+type Prompt struct {
+	Name        string           `json:"name"`
+	Description string           `json:"description,omitempty"`
+	Arguments   []PromptArgument `json:"arguments,omitempty"`
+}
+
+// 提示词列表结果
+type PromptListResult struct {
+	Prompts    []Prompt `json:"prompts"`
+	NextCursor string   `json:"nextCursor,omitempty"`
+}
+
+// 获取提示词参数
+type GetPromptParams struct {
+	Name      string            `json:"name"`
+	Arguments map[string]string `json:"arguments,omitempty"`
+}
+
+// 提示词消息
+type PromptMessage struct {
+	Role    string  `json:"role"`
+	Content Content `json:"content"`
+}
+
+// 获取提示词结果
+type GetPromptResult struct {
+	Description string          `json:"description,omitempty"`
+	Messages    []PromptMessage `json:"messages"`
+}
+
+// 资源定义
+type Resource struct {
+	URI         string       `json:"uri"`
+	Name        string       `json:"name"`
+	Description string       `json:"description,omitempty"`
+	MimeType    string       `json:"mimeType,omitempty"`
+	Annotations *Annotations `json:"annotations,omitempty"`
+}
+
+// 资源模板定义（RFC 6570 URI模板）
+type ResourceTemplate struct {
+	URITemplate string `json:"uriTemplate"`
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	MimeType    string `json:"mimeType,omitempty"`
+}
+
+// 资源内容，文本放在Text中，二进制内容以base64编码放在Blob中
+type ResourceContents struct {
+	URI      string `json:"uri"`
+	MimeType string `json:"mimeType,omitempty"`
+	Text     string `json:"text,omitempty"`
+	Blob     string `json:"blob,omitempty"`
+}
+
+// 分页请求参数
+type PaginatedParams struct {
+	Cursor string `json:"cursor,omitempty"`
+}
+
+// 资源列表结果
+type ResourceListResult struct {
+	Resources  []Resource `json:"resources"`
+	NextCursor string     `json:"nextCursor,omitempty"`
+}
+
+// 资源模板列表结果
+type ResourceTemplateListResult struct {
+	ResourceTemplates []ResourceTemplate `json:"resourceTemplates"`
+	NextCursor        string             `json:"nextCursor,omitempty"`
 }
 
 // 资源读取参数
@@ -79,14 +200,93 @@ type ResourceReadParams struct {
 
 // 资源读取结果
 type ResourceReadResult struct {
-	Contents []Content `json:"contents"`
-	MimeType string    `json:"mimeType"`
+	Contents []ResourceContents `json:"contents"`
+}
+
+// 资源订阅/取消订阅参数
+type ResourceSubscribeParams struct {
+	URI string `json:"uri"`
+}
+
+// 资源更新通知参数
+type ResourceUpdatedParams struct {
+	URI string `json:"uri"`
+}
+
+// 采样消息，sampling/createMessage 的对话内容
+type SamplingMessage struct {
+	Role    string  `json:"role"`
+	Content Content `json:"content"`
+}
+
+// 模型名称提示，客户端按子串匹配选择模型
+type ModelHint struct {
+	Name string `json:"name,omitempty"`
+}
+
+// 模型偏好，各优先级取值范围为0到1
+type ModelPreferences struct {
+	Hints                []ModelHint `json:"hints,omitempty"`
+	CostPriority         *float64    `json:"costPriority,omitempty"`
+	SpeedPriority        *float64    `json:"speedPriority,omitempty"`
+	IntelligencePriority *float64    `json:"intelligencePriority,omitempty"`
+}
+
+// sampling/createMessage 请求参数
+type CreateMessageParams struct {
+	Messages         []SamplingMessage      `json:"messages"`
+	ModelPreferences *ModelPreferences      `json:"modelPreferences,omitempty"`
+	SystemPrompt     string                 `json:"systemPrompt,omitempty"`
+	IncludeContext   string                 `json:"includeContext,omitempty"`
+	Temperature      *float64               `json:"temperature,omitempty"`
+	MaxTokens        int                    `json:"maxTokens"`
+	StopSequences    []string               `json:"stopSequences,omitempty"`
+	Metadata         map[string]interface{} `json:"metadata,omitempty"`
+}
+
+// sampling/createMessage 响应结果
+type CreateMessageResult struct {
+	Role       string  `json:"role"`
+	Content    Content `json:"content"`
+	Model      string  `json:"model"`
+	StopReason string  `json:"stopReason,omitempty"`
+}
+
+// elicitation/create 请求参数，RequestedSchema只能包含基本类型的属性
+type ElicitRequestParams struct {
+	Message         string                 `json:"message"`
+	RequestedSchema map[string]interface{} `json:"requestedSchema"`
+}
+
+// elicitation/create 响应结果，Action为accept时Content包含用户填写的内容
+type ElicitResult struct {
+	Action  string                 `json:"action"`
+	Content map[string]interface{} `json:"content,omitempty"`
+}
+
+// 客户端声明的根目录，URI目前只支持file://
+type Root struct {
+	URI  string `json:"uri"`
+	Name string `json:"name,omitempty"`
+}
+
+// roots/list 响应结果
+type ListRootsResult struct {
+	Roots []Root `json:"roots"`
 }
 
 // 常量定义
 const (
-	JSONRPCVersion  = "2.0"
-	ProtocolVersion = "2024-11-05"
+	JSONRPCVersion = "2.0"
+	// ProtocolVersion 服务器支持的最新协议版本，客户端默认使用该版本发起初始化
+	ProtocolVersion = ProtocolVersion20250618
+)
+
+// 支持的协议版本
+const (
+	ProtocolVersion20241105 = "2024-11-05"
+	ProtocolVersion20250326 = "2025-03-26"
+	ProtocolVersion20250618 = "2025-06-18"
 )
 
 // 错误代码
@@ -97,8 +297,14 @@ const (
 	InvalidParamsCode  = -32602
 	InternalErrorCode  = -32603
 	ServerErrorCode    = -32000
+	NotInitializedCode = -32002
 )
 
+// Error 实现error接口，便于将客户端返回的错误直接向上传递
+func (e *Error) Error() string {
+	return fmt.Sprintf("%s (code %d)", e.Message, e.Code)
+}
+
 // 创建请求消息
 func NewRequest(id interface{}, method string, params interface{}) *Message {
 	paramsBytes, _ := json.Marshal(params)
@@ -2,10 +2,13 @@ package mcp
 
 import (
 	"context"
+	"crypto/tls"
 	"encoding/json"
 	"fmt"
 	"log"
+	"net"
 	"net/http"
+	"strconv"
 	"sync"
 	"time"
 
@@ -15,11 +18,15 @@ import (
 // WebSocketServer WebSocket MCP服务器
 type WebSocketServer struct {
 	*BaseServer
-	port     int
-	upgrader websocket.Upgrader
-	server   *http.Server
-	conns    map[*websocket.Conn]bool
-	connMu   sync.RWMutex
+	host      string
+	port      int
+	path      string
+	tlsConfig *tls.Config
+	upgrader  websocket.Upgrader
+	server    *http.Server
+	conns     map[*websocket.Conn]*Session
+	connMu    sync.RWMutex
+	auth      authGate
 }
 
 // NewWebSocketServer 创建新的WebSocket服务器
@@ -27,13 +34,12 @@ func NewWebSocketServer(port int) *WebSocketServer {
 	return &WebSocketServer{
 		BaseServer: NewBaseServer(),
 		port:       port,
+		path:       "/",
 		upgrader: websocket.Upgrader{
-			CheckOrigin: func(r *http.Request) bool {
-				// 允许所有来源，生产环境中应该限制
-				return true
-			},
+			// 默认只接受本机页面发起的浏览器请求，通过SetAllowedOrigins放开
+			CheckOrigin: newOriginChecker(nil),
 		},
-		conns: make(map[*websocket.Conn]bool),
+		conns: make(map[*websocket.Conn]*Session),
 	}
 }
 
@@ -43,22 +49,33 @@ func (s *WebSocketServer) Start() error {
 	mux := http.NewServeMux()
 
 	// 设置WebSocket处理器
-	mux.HandleFunc("/", s.handleWebSocket)
+	mux.HandleFunc(s.path, s.handleWebSocket)
 
 	// 设置健康检查端点
 	mux.HandleFunc("/health", s.handleHealth)
 
+	// 受保护资源元数据，客户端据此找到授权服务器
+	mux.HandleFunc(ProtectedResourceMetadataPath, s.auth.handleMetadata)
+
 	// 创建HTTP服务器
 	s.server = &http.Server{
-		Addr:    fmt.Sprintf(":%d", s.port),
-		Handler: mux,
+		Addr:      net.JoinHostPort(s.host, strconv.Itoa(s.port)),
+		Handler:   mux,
+		TLSConfig: s.tlsConfig,
 	}
 
-	log.Printf("WebSocket MCP服务器启动在端口 %d", s.port)
-	log.Printf("WebSocket地址: ws://localhost:%d", s.port)
-	log.Printf("健康检查: http://localhost:%d/health", s.port)
+	scheme, healthScheme := "ws", "http"
+	if s.tlsConfig != nil {
+		scheme, healthScheme = "wss", "https"
+	}
+	log.Printf("WebSocket MCP服务器启动在 %s", s.server.Addr)
+	log.Printf("WebSocket地址: %s://localhost:%d%s", scheme, s.port, s.path)
+	log.Printf("健康检查: %s://localhost:%d/health", healthScheme, s.port)
 
-	// 启动HTTP服务器
+	// 启动HTTP服务器，证书已在TLSConfig中
+	if s.tlsConfig != nil {
+		return s.server.ListenAndServeTLS("", "")
+	}
 	return s.server.ListenAndServe()
 }
 
@@ -82,23 +99,15 @@ func (s *WebSocketServer) Stop() error {
 	return nil
 }
 
-// handleHealth 处理健康检查请求
+// handleHealth 处理健康检查请求，匿名调用方只能看到连接和会话数量，认证后还会列出会话详情
 func (s *WebSocketServer) handleHealth(w http.ResponseWriter, r *http.Request) {
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusOK)
-
-	response := map[string]interface{}{
-		"status":    "healthy",
-		"service":   "mcp-ai-websocket",
-		"timestamp": time.Now().Format(time.RFC3339),
-		"connections": func() int {
-			s.connMu.RLock()
-			defer s.connMu.RUnlock()
-			return len(s.conns)
-		}(),
-	}
+	s.connMu.RLock()
+	connections := len(s.conns)
+	s.connMu.RUnlock()
 
-	json.NewEncoder(w).Encode(response)
+	s.writeHealth(w, "mcp-ai-websocket", s.healthSessions(map[string]interface{}{
+		"connections": connections,
+	}, s.auth.identify(r)))
 }
 
 // handleWebSocket 处理WebSocket连接
@@ -112,13 +121,20 @@ func (s *WebSocketServer) handleWebSocket(w http.ResponseWriter, r *http.Request
 		response := map[string]interface{}{
 			"error":   "此端点仅支持WebSocket连接",
 			"usage":   "请使用WebSocket客户端连接此端点",
-			"example": "ws://localhost:8081/",
+			"example": fmt.Sprintf("ws://%s%s", r.Host, s.path),
 		}
 
 		json.NewEncoder(w).Encode(response)
 		return
 	}
 
+	// 在升级前认证，失败时返回401并在WWW-Authenticate中给出元数据地址
+	principal, ok := s.auth.authenticate(w, r)
+	if !ok {
+		log.Printf("WebSocket认证失败: %s", r.RemoteAddr)
+		return
+	}
+
 	// 升级HTTP连接为WebSocket连接
 	conn, err := s.upgrader.Upgrade(w, r, nil)
 	if err != nil {
@@ -126,19 +142,24 @@ func (s *WebSocketServer) handleWebSocket(w http.ResponseWriter, r *http.Request
 		return
 	}
 
+	// 每个连接拥有独立的会话，初始化状态和请求ID互不影响
+	session := NewSession(newSessionID())
+	session.remoteAddr = conn.RemoteAddr().String()
+	session.principal = principal
+
 	// 注册连接
 	s.connMu.Lock()
-	s.conns[conn] = true
+	s.conns[conn] = session
 	s.connMu.Unlock()
 
-	log.Printf("新的WebSocket连接: %s", conn.RemoteAddr())
+	log.Printf("新的WebSocket连接: %s (会话 %s)", conn.RemoteAddr(), session.ID())
 
 	// 启动消息处理协程
-	go s.handleConnection(conn)
+	go s.handleConnection(conn, session)
 }
 
 // handleConnection 处理单个WebSocket连接
-func (s *WebSocketServer) handleConnection(conn *websocket.Conn) {
+func (s *WebSocketServer) handleConnection(conn *websocket.Conn, session *Session) {
 	defer func() {
 		// 清理连接
 		s.connMu.Lock()
@@ -148,15 +169,43 @@ func (s *WebSocketServer) handleConnection(conn *websocket.Conn) {
 		log.Printf("WebSocket连接已关闭: %s", conn.RemoteAddr())
 	}()
 
+	// 连接关闭时取消该连接上所有正在执行的请求
+	connCtx, cancel := context.WithCancel(context.Background())
+
+	// 请求在独立的goroutine中执行，写入需要串行化
+	var writeMu sync.Mutex
+	writeFrame := func(v interface{}) error {
+		writeMu.Lock()
+		defer writeMu.Unlock()
+		conn.SetWriteDeadline(time.Now().Add(90 * time.Second))
+		return conn.WriteJSON(v)
+	}
+	scheduler := s.newRequestScheduler(writeFrame)
+	s.registerSession(session, scheduler.Notifier())
+	ctx := WithNotifier(WithSession(connCtx, session), scheduler.Notifier())
+	defer func() {
+		s.unregisterSession(session)
+		cancel()
+		scheduler.Wait()
+	}()
+
+	// 令牌过期后关闭连接，客户端需要用新令牌重新连接
+	if principal := session.Principal(); principal != nil && !principal.ExpiresAt.IsZero() {
+		timer := time.AfterFunc(time.Until(principal.ExpiresAt), func() {
+			log.Printf("WebSocket连接 %s 的访问令牌已过期，关闭连接", conn.RemoteAddr())
+			conn.Close()
+		})
+		defer timer.Stop()
+	}
+
 	// 设置WebSocket超时
 	conn.SetReadDeadline(time.Now().Add(90 * time.Second))
-	conn.SetWriteDeadline(time.Now().Add(90 * time.Second))
 
 	// 消息循环
 	for {
 		// 设置读取超时
 		conn.SetReadDeadline(time.Now().Add(90 * time.Second))
-		
+
 		// 读取消息
 		_, message, err := conn.ReadMessage()
 		if err != nil {
@@ -166,264 +215,53 @@ func (s *WebSocketServer) handleConnection(conn *websocket.Conn) {
 			break
 		}
 
-		// 处理消息
-		response, err := s.handleMessage(message)
-		if err != nil {
-			log.Printf("处理消息失败: %v", err)
-			// 发送错误响应
-			errorResponse := Message{
-				JSONRPC: "2.0",
-				ID:      nil,
-				Error: &Error{
-					Code:    -32603,
-					Message: "Internal error",
-					Data:    err.Error(),
-				},
-			}
-			if err := conn.WriteJSON(errorResponse); err != nil {
-				log.Printf("发送错误响应失败: %v", err)
-			}
-			continue
-		}
-
-		// 发送响应
-		if response != nil {
-			// 设置写入超时
-			conn.SetWriteDeadline(time.Now().Add(90 * time.Second))
-			if err := conn.WriteJSON(response); err != nil {
-				log.Printf("发送响应失败: %v", err)
-				break
-			}
-		}
+		// 请求并发执行，响应按ID与请求对应；数组帧按批量请求处理
+		scheduler.DispatchFrame(ctx, message)
 	}
 }
 
-// handleMessage 处理单个消息
-func (s *WebSocketServer) handleMessage(message []byte) (*Message, error) {
-	var msg Message
-	if err := json.Unmarshal(message, &msg); err != nil {
-		return nil, fmt.Errorf("解析消息失败: %v", err)
-	}
-
-	// 根据方法类型处理
-	switch msg.Method {
-	case "initialize":
-		return s.handleInitialize(&msg)
-	case "tools/list":
-		return s.handleToolsList(&msg)
-	case "tools/call":
-		return s.handleToolCall(&msg)
-	case "resources/read":
-		return s.handleResourceRead(&msg)
-	case "shutdown":
-		return s.handleShutdown(&msg)
-	default:
-		return &Message{
-			JSONRPC: "2.0",
-			ID:      msg.ID,
-			Error: &Error{
-				Code:    -32601,
-				Message: "Method not found",
-				Data:    fmt.Sprintf("Unknown method: %s", msg.Method),
-			},
-		}, nil
-	}
+// SetListenAddress 设置监听的主机地址，为空时监听所有网卡
+// 必须在Start之前调用
+func (s *WebSocketServer) SetListenAddress(host string) {
+	s.host = host
 }
 
-// handleInitialize 处理初始化请求
-func (s *WebSocketServer) handleInitialize(msg *Message) (*Message, error) {
-	s.mu.Lock()
-	defer s.mu.Unlock()
-
-	if s.initialized {
-		return &Message{
-			JSONRPC: "2.0",
-			ID:      msg.ID,
-			Error: &Error{
-				Code:    -32000,
-				Message: "Already initialized",
-			},
-		}, nil
-	}
-
-	// 解析客户端信息
-	var params map[string]interface{}
-	if err := json.Unmarshal(msg.Params, &params); err == nil {
-		if clientInfo, ok := params["clientInfo"].(map[string]interface{}); ok {
-			if name, ok := clientInfo["name"].(string); ok {
-				s.clientInfo = &ClientInfo{Name: name}
-			}
-		}
-	}
-
-	s.initialized = true
-
-	return &Message{
-		JSONRPC: "2.0",
-		ID:      msg.ID,
-		Result: map[string]interface{}{
-			"protocolVersion": "2024-11-05",
-			"capabilities": map[string]interface{}{
-				"tools": map[string]interface{}{},
-			},
-			"serverInfo": map[string]interface{}{
-				"name":    "mcp-ai-websocket",
-				"version": "1.0.0",
-			},
-		},
-	}, nil
+// SetPath 设置WebSocket端点路径，以 "/" 结尾时匹配其下所有路径
+// 必须在Start之前调用
+func (s *WebSocketServer) SetPath(path string) {
+	s.path = path
 }
 
-// handleToolsList 处理工具列表请求
-func (s *WebSocketServer) handleToolsList(msg *Message) (*Message, error) {
-	s.mu.RLock()
-	defer s.mu.RUnlock()
-
-	if !s.initialized {
-		return &Message{
-			JSONRPC: "2.0",
-			ID:      msg.ID,
-			Error: &Error{
-				Code:    -32002,
-				Message: "Not initialized",
-			},
-		}, nil
-	}
-
-	tools := make([]Tool, 0, len(s.tools))
-	for _, tool := range s.tools {
-		tools = append(tools, tool)
-	}
-
-	return &Message{
-		JSONRPC: "2.0",
-		ID:      msg.ID,
-		Result: map[string]interface{}{
-			"tools": tools,
-		},
-	}, nil
+// SetAllowedOrigins 设置允许的浏览器来源，为空时只接受来自本机回环地址的页面
+// 必须在Start之前调用
+func (s *WebSocketServer) SetAllowedOrigins(origins []string) {
+	s.upgrader.CheckOrigin = newOriginChecker(origins)
 }
 
-// handleToolCall 处理工具调用请求
-func (s *WebSocketServer) handleToolCall(msg *Message) (*Message, error) {
-	s.mu.RLock()
-	defer s.mu.RUnlock()
-
-	if !s.initialized {
-		return &Message{
-			JSONRPC: "2.0",
-			ID:      msg.ID,
-			Error: &Error{
-				Code:    -32002,
-				Message: "Not initialized",
-			},
-		}, nil
-	}
-
-	if s.toolExecutor == nil {
-		return &Message{
-			JSONRPC: "2.0",
-			ID:      msg.ID,
-			Error: &Error{
-				Code:    -32603,
-				Message: "Tool executor not available",
-			},
-		}, nil
-	}
-
-	// 解析工具调用参数
-	var params map[string]interface{}
-	if err := json.Unmarshal(msg.Params, &params); err != nil {
-		return &Message{
-			JSONRPC: "2.0",
-			ID:      msg.ID,
-			Error: &Error{
-				Code:    -32602,
-				Message: "Invalid params",
-			},
-		}, nil
-	}
-
-	toolName, ok := params["name"].(string)
-	if !ok {
-		return &Message{
-			JSONRPC: "2.0",
-			ID:      msg.ID,
-			Error: &Error{
-				Code:    -32602,
-				Message: "Tool name is required",
-			},
-		}, nil
-	}
-
-	arguments, _ := params["arguments"].(map[string]interface{})
-
-	// 执行工具
-	ctx := context.Background()
-	result, err := s.toolExecutor.ExecuteTool(ctx, toolName, arguments)
-	if err != nil {
-		return &Message{
-			JSONRPC: "2.0",
-			ID:      msg.ID,
-			Error: &Error{
-				Code:    -32603,
-				Message: "Tool execution failed",
-				Data:    err.Error(),
-			},
-		}, nil
-	}
-
-	return &Message{
-		JSONRPC: "2.0",
-		ID:      msg.ID,
-		Result:  result,
-	}, nil
+// SetTLSConfig 设置TLS配置，设置后以wss提供服务
+// 必须在Start之前调用
+func (s *WebSocketServer) SetTLSConfig(config *tls.Config) {
+	s.tlsConfig = config
 }
 
-// handleResourceRead 处理资源读取请求
-func (s *WebSocketServer) handleResourceRead(msg *Message) (*Message, error) {
-	s.mu.RLock()
-	defer s.mu.RUnlock()
-
-	if !s.initialized {
-		return &Message{
-			JSONRPC: "2.0",
-			ID:      msg.ID,
-			Error: &Error{
-				Code:    -32002,
-				Message: "Not initialized",
-			},
-		}, nil
-	}
-
-	// 这里可以实现资源读取逻辑
-	return &Message{
-		JSONRPC: "2.0",
-		ID:      msg.ID,
-		Error: &Error{
-			Code:    -32601,
-			Message: "Resource reading not implemented",
-		},
-	}, nil
+// SetClientPrincipals 设置客户端证书标识到认证主体的映射
+// 必须在Start之前调用
+func (s *WebSocketServer) SetClientPrincipals(principals map[string]string) {
+	s.auth.clientPrincipals = principals
 }
 
-// handleShutdown 处理关闭请求
-func (s *WebSocketServer) handleShutdown(msg *Message) (*Message, error) {
-	s.mu.Lock()
-	defer s.mu.Unlock()
-
-	s.initialized = false
+// SetAuthenticator 设置连接认证器，为nil时不认证
+// 必须在Start之前调用
+func (s *WebSocketServer) SetAuthenticator(authenticator Authenticator) {
+	s.auth.authenticator = authenticator
+}
 
-	return &Message{
-		JSONRPC: "2.0",
-		ID:      msg.ID,
-		Result:  nil,
-	}, nil
+// SetResourceMetadata 设置受保护资源元数据，认证失败时在WWW-Authenticate中返回其地址
+func (s *WebSocketServer) SetResourceMetadata(metadata *ProtectedResourceMetadata) {
+	s.auth.metadata = metadata
 }
 
 // SetToolExecutor 设置工具执行器
 func (s *WebSocketServer) SetToolExecutor(executor ToolExecutor) {
-	s.mu.Lock()
-	defer s.mu.Unlock()
-	s.toolExecutor = executor
+	s.BaseServer.SetToolExecutor(executor)
 }
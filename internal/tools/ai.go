@@ -3,6 +3,7 @@ package tools
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
 	"os"
@@ -20,28 +21,31 @@ import (
 type AITools struct {
 	configManager     *config.AIConfigManager
 	databaseConfigMgr *config.DatabaseConfigManager
+	promptManager     *config.PromptConfigManager
 	providers         []AIProvider
 	databaseTools     *DatabaseTools
 	systemTools       *SystemTools
 	dataTools         *DataTools
 	networkTools      *NetworkTools
+	executor          ToolExecutor // 嵌套的工具调用经过它执行，以应用策略并写入审计日志
+	auditor           *Auditor     // 记录不经过工具执行器的文件系统操作
 }
 
 // debugPrintAI 调试输出函数，避免在stdio模式下干扰JSON通信
 func debugPrintAI(format string, args ...interface{}) {
 	// 在stdio模式下，调试信息输出到stderr
-	fmt.Fprintf(os.Stderr, format, args...)
+	fmt.Fprintf(logger.Stderr, format, args...)
 }
 
 // debugPrintAICompatible 兼容fmt.Printf的调试输出函数
 func debugPrintAICompatible(format string, args ...interface{}) {
 	// 重定向所有调试输出到stderr，避免干扰stdio通信
-	fmt.Fprintf(os.Stderr, format, args...)
+	fmt.Fprintf(logger.Stderr, format, args...)
 }
 
 // 初始化日志输出到stderr，避免干扰stdio模式的JSON通信
 func init() {
-	log.SetOutput(os.Stderr)
+	log.SetOutput(logger.Stderr)
 }
 
 // NewAITools 创建AI工具实例
@@ -69,6 +73,13 @@ func NewAITools(configPath string, databaseTools *DatabaseTools, systemTools *Sy
 	}
 	aiTools.databaseConfigMgr = databaseConfigMgr
 
+	// 创建提示词配置管理器
+	promptManager, err := config.NewPromptConfigManager(configPath)
+	if err != nil {
+		return aiTools, fmt.Errorf("创建提示词配置管理器失败: %v", err)
+	}
+	aiTools.promptManager = promptManager
+
 	// 初始化提供商
 	if err := aiTools.initializeProviders(); err != nil {
 		return aiTools, fmt.Errorf("初始化AI提供商失败: %v", err)
@@ -77,6 +88,28 @@ func NewAITools(configPath string, databaseTools *DatabaseTools, systemTools *Sy
 	return aiTools, nil
 }
 
+// SetToolExecutor 设置嵌套工具调用使用的执行器，通常为ToolManager
+func (c *AITools) SetToolExecutor(executor ToolExecutor) {
+	c.executor = executor
+}
+
+// SetAuditor 设置审计器，ai_file_manager直接创建目录时写入审计日志
+func (c *AITools) SetAuditor(auditor *Auditor) {
+	c.auditor = auditor
+}
+
+// callTool 通过工具执行器调用其它工具，策略拒绝等isError结果转换为错误
+func (c *AITools) callTool(ctx context.Context, name string, arguments map[string]interface{}) (*mcp.ToolCallResult, error) {
+	if c.executor == nil {
+		return nil, fmt.Errorf("未设置工具执行器，无法执行 %s", name)
+	}
+	result, err := c.executor.ExecuteTool(ctx, name, arguments)
+	if err == nil && result != nil && result.IsError && len(result.Content) > 0 {
+		return nil, fmt.Errorf("%s", result.Content[0].Text)
+	}
+	return result, err
+}
+
 // initializeProviders 初始化AI提供商
 func (c *AITools) initializeProviders() error {
 	if ollamaConfig, exists := c.configManager.GetProvider("ollama"); exists {
@@ -88,6 +121,14 @@ func (c *AITools) initializeProviders() error {
 	if anthropicConfig, exists := c.configManager.GetProvider("anthropic"); exists {
 		c.providers = append(c.providers, NewAnthropicProvider(anthropicConfig))
 	}
+	if samplingConfig := c.configManager.GetSamplingConfig(); samplingConfig.Enabled {
+		c.providers = append(c.providers, NewSamplingProvider(samplingConfig))
+	}
+
+	// 所有发送给模型的提示词都经过脱敏
+	for i, provider := range c.providers {
+		c.providers[i] = redactedProvider{provider}
+	}
 	return nil
 }
 
@@ -107,8 +148,9 @@ func (c *AITools) GetTools() []mcp.Tool {
 	return []mcp.Tool{
 		// 1. 基础AI对话 - 纯聊天，不涉及数据库
 		{
-			Name:        "ai_chat",
-			Description: "与AI进行基础对话，回答一般问题",
+			Name:         "ai_chat",
+			Description:  "与AI进行基础对话，回答一般问题",
+			OutputSchema: aiChatOutputSchema,
 			InputSchema: map[string]interface{}{
 				"type": "object",
 				"properties": map[string]interface{}{
@@ -118,7 +160,7 @@ func (c *AITools) GetTools() []mcp.Tool {
 					},
 					"provider": map[string]interface{}{
 						"type":        "string",
-						"description": "AI提供商 (ollama, openai, anthropic)",
+						"description": "AI提供商 (ollama, openai, anthropic, sampling)",
 						"enum":        c.configManager.GetAvailableProviders(),
 						"default":     c.configManager.GetDefaultProvider(),
 					},
@@ -147,8 +189,9 @@ func (c *AITools) GetTools() []mcp.Tool {
 
 		// 6. 数据查询+分析 - 查询数据并进行AI分析
 		{
-			Name:        "ai_query_with_analysis",
-			Description: "查询数据并进行AI分析（ai_query_data + ai_analyze_data的组合）",
+			Name:         "ai_query_with_analysis",
+			Description:  "查询数据并进行AI分析（ai_query_data + ai_analyze_data的组合）",
+			OutputSchema: queryAnalysisOutputSchema,
 			InputSchema: map[string]interface{}{
 				"type": "object",
 				"properties": map[string]interface{}{
@@ -189,8 +232,9 @@ func (c *AITools) GetTools() []mcp.Tool {
 
 		// 7. AI智能文件管理 - 自然语言描述的文件操作
 		{
-			Name:        "ai_file_manager",
-			Description: "AI智能文件管理：使用自然语言描述文件操作需求，AI理解后执行相应的文件系统操作",
+			Name:         "ai_file_manager",
+			Description:  "AI智能文件管理：使用自然语言描述文件操作需求，AI理解后执行相应的文件系统操作",
+			OutputSchema: fileManagerOutputSchema,
 			InputSchema: map[string]interface{}{
 				"type": "object",
 				"properties": map[string]interface{}{
@@ -225,8 +269,9 @@ func (c *AITools) GetTools() []mcp.Tool {
 		},
 		// 8. AI智能数据处理 - 自然语言描述的数据转换
 		{
-			Name:        "ai_data_processor",
-			Description: "AI智能数据处理：使用自然语言描述数据处理需求，AI理解后执行相应的数据转换和分析",
+			Name:         "ai_data_processor",
+			Description:  "AI智能数据处理：使用自然语言描述数据处理需求，AI理解后执行相应的数据转换和分析",
+			OutputSchema: dataProcessorOutputSchema,
 			InputSchema: map[string]interface{}{
 				"type": "object",
 				"properties": map[string]interface{}{
@@ -267,8 +312,9 @@ func (c *AITools) GetTools() []mcp.Tool {
 		},
 		// 9. AI智能网络请求 - 自然语言描述的API调用
 		{
-			Name:        "ai_api_client",
-			Description: "AI智能网络请求：使用自然语言描述API调用需求，AI理解后构造和执行HTTP请求",
+			Name:         "ai_api_client",
+			Description:  "AI智能网络请求：使用自然语言描述API调用需求，AI理解后构造和执行HTTP请求",
+			OutputSchema: apiClientOutputSchema,
 			InputSchema: map[string]interface{}{
 				"type": "object",
 				"properties": map[string]interface{}{
@@ -364,14 +410,14 @@ func (c *AITools) executeAIChat(ctx context.Context, arguments map[string]interf
 		return nil, fmt.Errorf("AI对话失败: %v", err)
 	}
 
-	return &mcp.ToolCallResult{
-		Content: []mcp.Content{
-			{
-				Type: "text",
-				Text: response,
-			},
-		},
-	}, nil
+	// 文本内容保持AI的原始回复，结构化内容附带提供商和模型
+	result := mcp.NewTextResult(response)
+	result.StructuredContent = AIChatResult{
+		Response: response,
+		Provider: provider.Name(),
+		Model:    model,
+	}
+	return result, nil
 }
 
 // 2. SQL生成 - 仅生成SQL，不执行（支持自动检测SQL语句）
@@ -473,7 +519,12 @@ func (c *AITools) callAIWithTimeout(ctx context.Context, provider AIProvider, mo
 	for attempt := 0; attempt <= maxRetries; attempt++ {
 		if attempt > 0 {
 			log.Printf("AI调用重试 %d/%d", attempt, maxRetries)
-			time.Sleep(time.Duration(attempt) * 2 * time.Second) // 指数退避
+			// 指数退避，等待期间请求被取消则立即返回
+			select {
+			case <-time.After(time.Duration(attempt) * 2 * time.Second):
+			case <-ctx.Done():
+				return "", fmt.Errorf("AI调用已取消: %v", ctx.Err())
+			}
 		}
 
 		response, err := provider.Call(ctx, model, prompt, options)
@@ -481,6 +532,17 @@ func (c *AITools) callAIWithTimeout(ctx context.Context, provider AIProvider, mo
 			return response, nil
 		}
 
+		// 请求被客户端取消时不再重试
+		if ctx.Err() == context.Canceled {
+			return "", fmt.Errorf("AI调用已取消: %v", ctx.Err())
+		}
+
+		// 客户端不支持或拒绝了采样请求（如用户未批准），重试没有意义
+		var rpcErr *mcp.Error
+		if errors.Is(err, mcp.ErrSamplingNotSupported) || errors.As(err, &rpcErr) {
+			return "", fmt.Errorf("AI调用失败: %v", err)
+		}
+
 		// 检查是否是超时错误
 		if ctx.Err() == context.DeadlineExceeded {
 			log.Printf("AI调用超时，尝试 %d/%d: %v", attempt+1, maxRetries+1, err)
@@ -513,14 +575,20 @@ func (c *AITools) executeAIGenerateSQL(ctx context.Context, arguments map[string
 		return nil, err
 	}
 
+	// 未指定别名时使用默认连接，SQL方言和表结构都来自该连接
+	alias := c.databaseConfigMgr.GetDefaultAlias()
+	if a, ok := arguments["alias"].(string); ok && a != "" {
+		alias = a
+	}
+
 	// 获取表名 - 使用智能表名检测或默认表名
 	var tableName string
 	if tn, ok := arguments["table_name"].(string); ok && tn != "" {
 		tableName = tn
 	} else {
 		// 使用智能表名检测
-		defaultTable := c.getDefaultTableName(ctx)
-		detectedTable, err := c.intelligentTableDetection(ctx, description, defaultTable)
+		defaultTable := c.getDefaultTableName(ctx, alias)
+		detectedTable, err := c.intelligentTableDetection(ctx, alias, description, defaultTable)
 		if err != nil {
 			debugPrintAICompatible("[DEBUG] 智能表名检测失败: %v，使用默认表名: %s\n", err, defaultTable)
 			tableName = defaultTable
@@ -531,18 +599,10 @@ func (c *AITools) executeAIGenerateSQL(ctx context.Context, arguments map[string
 	}
 
 	// 构建SQL生成提示
-	prompt := fmt.Sprintf(`请根据以下描述生成SQL查询语句：
-
-描述：%s
-表名：%s
-
-要求：
-1. 只返回SQL语句，不要其他解释
-2. 使用标准SQL语法
-3. 确保查询安全，避免SQL注入
-4. 如果需要限制结果数量，默认使用LIMIT 100
-
-SQL：`, description, tableName)
+	prompt, err := c.promptManager.Render(config.PromptGenerateSQL, c.sqlPromptArguments(ctx, alias, description, tableName))
+	if err != nil {
+		return nil, err
+	}
 
 	// 调用AI生成SQL
 	aiResponse, err := c.callAIWithTimeout(ctx, provider, model, prompt, map[string]interface{}{
@@ -616,8 +676,8 @@ func (c *AITools) executeAIExecuteSQL(ctx context.Context, arguments map[string]
 		queryArgs["limit"] = limit
 	}
 
-	// 使用数据库工具执行查询
-	result, err := c.databaseTools.ExecuteTool(ctx, "db_query", queryArgs)
+	// 通过工具执行器执行查询
+	result, err := c.callTool(ctx, "db_query", queryArgs)
 	if err != nil {
 		return nil, fmt.Errorf("SQL执行失败: %v", err)
 	}
@@ -670,6 +730,9 @@ func (c *AITools) executeAISmartQuery(ctx context.Context, arguments map[string]
 	if tableName, ok := arguments["table_name"]; ok {
 		sqlGenArgs["table_name"] = tableName
 	}
+	if alias, ok := arguments["alias"]; ok {
+		sqlGenArgs["alias"] = alias
+	}
 
 	log.Printf("[AISmartQuery] 开始生成SQL，提示：%s", prompt)
 
@@ -780,18 +843,11 @@ func (c *AITools) executeAIAnalyzeDataWithChinesePrompt(ctx context.Context, arg
 	}
 
 	// 构建中文分析提示词，更加明确要求使用中文
-	var prompt string
-	switch analysisType {
-	case "summary":
-		prompt = fmt.Sprintf("请严格用中文分析以下数据并提供摘要。必须用中文回答，不要使用英文。请直接返回分析结果：\n\n数据：%s\n\n要求：用中文分析并提供摘要", data)
-	case "insights":
-		prompt = fmt.Sprintf("请严格用中文分析以下数据并提供洞察和发现。必须用中文回答，不要使用英文。请直接返回分析结果：\n\n数据：%s\n\n要求：用中文分析并提供洞察", data)
-	case "recommendations":
-		prompt = fmt.Sprintf("请严格用中文分析以下数据并提供建议和推荐。必须用中文回答，不要使用英文。请直接返回分析结果：\n\n数据：%s\n\n要求：用中文分析并提供建议", data)
-	case "detailed":
-		prompt = fmt.Sprintf("请严格用中文对以下数据进行详细分析。必须用中文回答，不要使用英文。请直接返回分析结果：\n\n数据：%s\n\n要求：用中文进行详细分析，包括数据统计、趋势分析和业务洞察", data)
-	default:
-		prompt = fmt.Sprintf("请严格用中文分析以下数据。必须用中文回答，不要使用英文。请直接返回分析结果：\n\n数据：%s\n\n要求：用中文分析数据", data)
+	prompt, err := c.promptManager.Render(analysisPromptName(analysisType), map[string]string{
+		"data": data,
+	})
+	if err != nil {
+		return nil, err
 	}
 
 	// 调用AI提供商
@@ -1027,6 +1083,7 @@ func (c *AITools) executeAIQueryWithAnalysis(ctx context.Context, arguments map[
 	logger.Performance("📋 [性能] 任务参数 - 描述: %s, 分析类型: %s, SQL模型: %s/%s, 分析模型: %s/%s", description, analysisType, sqlProvider.Name(), sqlModel, analysisProvider.Name(), analysisModel)
 
 	// 第一步：生成SQL
+	mcp.ReportProgress(ctx, 0, 3, "正在生成SQL")
 	sqlGenStartTime := time.Now()
 	log.Printf("[QueryWithAnalysis] 📝 步骤1开始：SQL生成 - %s", sqlGenStartTime.Format("15:04:05.000"))
 	logger.Performance("🚀 [性能] SQL生成开始 - 使用模型: %s/%s", sqlProvider.Name(), sqlModel)
@@ -1039,6 +1096,9 @@ func (c *AITools) executeAIQueryWithAnalysis(ctx context.Context, arguments map[
 	if tableName, ok := arguments["table_name"]; ok {
 		sqlGenArgs["table_name"] = tableName
 	}
+	if alias, ok := arguments["alias"]; ok {
+		sqlGenArgs["alias"] = alias
+	}
 
 	sqlResult, err := c.executeAIGenerateSQL(ctx, sqlGenArgs)
 	if err != nil {
@@ -1061,6 +1121,7 @@ func (c *AITools) executeAIQueryWithAnalysis(ctx context.Context, arguments map[
 	}
 
 	// 第二步：执行SQL
+	mcp.ReportProgress(ctx, 1, 3, "正在执行SQL查询")
 	sqlExecStartTime := time.Now()
 	log.Printf("[QueryWithAnalysis] 🗄️  步骤2开始：执行SQL查询 - %s，SQL: %s", sqlExecStartTime.Format("15:04:05.000"), generatedSQL)
 	logger.Performance("🗄️ [性能] 数据库查询开始 - SQL: %s", generatedSQL)
@@ -1106,21 +1167,17 @@ func (c *AITools) executeAIQueryWithAnalysis(ctx context.Context, arguments map[
 	}
 
 	// 第三步：基于实际员工数据进行AI分析
+	mcp.ReportProgress(ctx, 2, 3, "正在进行AI分析")
 	analysisStartTime := time.Now()
 	log.Printf("[QueryWithAnalysis] 🤖 步骤3开始：AI数据分析 - %s，数据行数：%d", analysisStartTime.Format("15:04:05.000"), len(employeeData))
 	logger.Performance("🤖 [性能] AI分析开始 - 使用模型: %s/%s, 数据行数: %d", analysisProvider.Name(), analysisModel, len(employeeData))
 
 	employeeJSON, _ := json.Marshal(employeeData)
-	var analysisPrompt string
-	switch analysisType {
-	case "insights":
-		analysisPrompt = fmt.Sprintf("请分析以下员工数据，提供业务洞察和发现。请用中文回答，重点关注：1)员工分布情况 2)薪资水平分析 3)部门结构 4)年龄构成 5)潜在的业务建议。\n\n员工数据：%s", string(employeeJSON))
-	case "summary":
-		analysisPrompt = fmt.Sprintf("请用中文总结以下员工数据的关键信息。\n\n员工数据：%s", string(employeeJSON))
-	case "recommendations":
-		analysisPrompt = fmt.Sprintf("请基于以下员工数据用中文提供管理建议和推荐。\n\n员工数据：%s", string(employeeJSON))
-	default:
-		analysisPrompt = fmt.Sprintf("请用中文分析以下员工数据。\n\n员工数据：%s", string(employeeJSON))
+	analysisPrompt, err := c.promptManager.Render(analysisPromptName(analysisType), map[string]string{
+		"data": string(employeeJSON),
+	})
+	if err != nil {
+		return nil, err
 	}
 
 	log.Printf("[QueryWithAnalysis] 🔄 调用AI模型进行分析，模型：%s/%s，提示词长度：%d", analysisProvider.Name(), analysisModel, len(analysisPrompt))
@@ -1137,6 +1194,8 @@ func (c *AITools) executeAIQueryWithAnalysis(ctx context.Context, arguments map[
 	log.Printf("[QueryWithAnalysis] ✅ 步骤3完成：AI分析耗时 %v，响应长度：%d", analysisDuration, len(analysisResponse))
 	logger.Performance("✅ [性能] AI分析完成 - 耗时: %v, 响应长度: %d", analysisDuration, len(analysisResponse))
 
+	mcp.ReportProgress(ctx, 3, 3, "分析完成")
+
 	// 清理分析结果
 	cleanedAnalysis := cleanAIResponse(analysisResponse)
 
@@ -1151,41 +1210,31 @@ func (c *AITools) executeAIQueryWithAnalysis(ctx context.Context, arguments map[
 		analysisDuration, float64(analysisDuration.Nanoseconds())/float64(totalDuration.Nanoseconds())*100)
 
 	// 构建简洁的响应
-	response := map[string]interface{}{
-		"tool":           "ai_query_with_analysis",
-		"status":         "success",
-		"description":    description,
-		"analysis_type":  analysisType,
-		"generated_sql":  generatedSQL,
-		"employee_data":  employeeData,
-		"analysis":       cleanedAnalysis,
-		"sql_provider":   sqlProvider.Name(),
-		"sql_model":      sqlModel,
-		"analysis_provider": analysisProvider.Name(),
-		"analysis_model": analysisModel,
-	}
-
-	jsonResponse, _ := json.MarshalIndent(response, "", "  ")
-	return &mcp.ToolCallResult{
-		Content: []mcp.Content{
-			{
-				Type: "text",
-				Text: string(jsonResponse),
-			},
-		},
-	}, nil
+	return mcp.NewStructuredResult(QueryAnalysisResult{
+		Tool:             "ai_query_with_analysis",
+		Status:           "success",
+		Description:      description,
+		AnalysisType:     analysisType,
+		GeneratedSQL:     generatedSQL,
+		EmployeeData:     employeeData,
+		Analysis:         cleanedAnalysis,
+		SQLProvider:      sqlProvider.Name(),
+		SQLModel:         sqlModel,
+		AnalysisProvider: analysisProvider.Name(),
+		AnalysisModel:    analysisModel,
+	}), nil
 }
 
 // 7. 智能洞察 - 深度智能分析，提供业务洞察和建议
 
 
 // 智能表名识别和获取功能
-func (c *AITools) intelligentTableDetection(ctx context.Context, prompt string, defaultTable string) (string, error) {
+func (c *AITools) intelligentTableDetection(ctx context.Context, alias, prompt string, defaultTable string) (string, error) {
 	debugPrintAICompatible("[DEBUG] ====== 智能表名识别开始 ======\n")
 	debugPrintAICompatible("[DEBUG] 输入prompt: '%s', 默认表名: '%s'\n", prompt, defaultTable)
 
 	// 首先获取数据库中的所有表
-	availableTables, err := c.getAvailableTables(ctx)
+	availableTables, err := c.getAvailableTables(ctx, alias)
 	if err != nil {
 		debugPrintAICompatible("[DEBUG] 获取表列表失败，使用默认表名: %v\n", err)
 		return defaultTable, nil
@@ -1219,47 +1268,57 @@ func (c *AITools) intelligentTableDetection(ctx context.Context, prompt string,
 }
 
 // 获取数据库中的所有表
-func (c *AITools) getAvailableTables(ctx context.Context) ([]string, error) {
+func (c *AITools) getAvailableTables(ctx context.Context, alias string) ([]string, error) {
 	if c.databaseTools == nil {
 		return nil, fmt.Errorf("数据库工具不可用")
 	}
+	return c.databaseTools.ListTables(ctx, alias)
+}
 
-	// 执行 SHOW TABLES 查询
-	dbArgs := map[string]interface{}{
-		"sql":   "SHOW TABLES",
-		"alias": "mysql_test", // 使用默认数据库别名
-		"limit": 100,
+// sqlPromptArguments 构造SQL生成提示词的参数，连接可用时加入数据库方言和表结构
+func (c *AITools) sqlPromptArguments(ctx context.Context, alias, description, tableName string) map[string]string {
+	arguments := map[string]string{
+		"description": description,
+		"table_name":  tableName,
+	}
+	if c.databaseTools == nil {
+		return arguments
 	}
 
-	result, err := c.databaseTools.ExecuteTool(ctx, "db_query", dbArgs)
+	dialect, err := c.databaseTools.Dialect(alias)
 	if err != nil {
-		return nil, fmt.Errorf("查询表列表失败: %v", err)
+		debugPrintAICompatible("[DEBUG] 获取数据库方言失败，使用标准SQL: %v\n", err)
+		return arguments
 	}
+	arguments["dialect"] = dialect.Name()
+	arguments["quoted_table"] = dialect.QuoteIdentifier(tableName)
+	arguments["limit_example"] = dialect.LimitQuery("SELECT * FROM "+dialect.QuoteIdentifier(tableName), 100)
 
-	// 解析查询结果
-	var dbResponse map[string]interface{}
-	if err := json.Unmarshal([]byte(result.Content[0].Text), &dbResponse); err != nil {
-		return nil, fmt.Errorf("解析表列表结果失败: %v", err)
-	}
-
-	// 提取表名
-	var tables []string
-	if rawResult, ok := dbResponse["raw_result"].(map[string]interface{}); ok {
-		if rows, ok := rawResult["rows"].([]interface{}); ok {
-			for _, row := range rows {
-				if rowMap, ok := row.(map[string]interface{}); ok {
-					// SHOW TABLES 返回的列名可能是 "Tables_in_database_name"
-					for _, value := range rowMap {
-						if tableName, ok := value.(string); ok {
-							tables = append(tables, tableName)
-						}
-					}
-				}
-			}
+	schema, err := c.databaseTools.DescribeTable(ctx, alias, tableName)
+	if err != nil {
+		debugPrintAICompatible("[DEBUG] 获取表结构失败: %v\n", err)
+		return arguments
+	}
+	lines := make([]string, 0, len(schema.Columns)+len(schema.ForeignKeys))
+	for _, column := range schema.Columns {
+		line := "- " + column.Name + " " + column.Type
+		if column.PrimaryKey {
+			line += " PRIMARY KEY"
+		}
+		if !column.Nullable {
+			line += " NOT NULL"
+		}
+		if column.Default != nil {
+			line += " DEFAULT " + *column.Default
 		}
+		lines = append(lines, line)
 	}
-
-	return tables, nil
+	// 外键帮助模型写出正确的JOIN条件
+	for _, fk := range schema.ForeignKeys {
+		lines = append(lines, fmt.Sprintf("- (%s) REFERENCES %s(%s)", strings.Join(fk.Columns, ", "), fk.ReferencedTable, strings.Join(fk.ReferencedColumns, ", ")))
+	}
+	arguments["columns"] = strings.Join(lines, "\n")
+	return arguments
 }
 
 // 从自然语言中提取表名关键词
@@ -1309,13 +1368,13 @@ func (c *AITools) aiMatchTable(ctx context.Context, prompt string, availableTabl
 
 	// 构建AI提示词
 	tablesStr := strings.Join(availableTables, ", ")
-	aiPrompt := fmt.Sprintf(`根据用户的查询需求，从可用的数据库表中选择最合适的表。
-
-用户查询：%s
-
-可用表名：%s
-
-请只返回最合适的一个表名，不要包含其他解释。如果无法确定，返回第一个表名。`, prompt, tablesStr)
+	aiPrompt, err := c.promptManager.Render(config.PromptMatchTable, map[string]string{
+		"query":  prompt,
+		"tables": tablesStr,
+	})
+	if err != nil {
+		return "", err
+	}
 
 	debugPrintAICompatible("[DEBUG] AI表名匹配提示词: %s\n", aiPrompt)
 
@@ -1342,8 +1401,8 @@ func (c *AITools) aiMatchTable(ctx context.Context, prompt string, availableTabl
 }
 
 // 获取默认表名（从数据库中的第一个可用表）
-func (c *AITools) getDefaultTableName(ctx context.Context) string {
-	availableTables, err := c.getAvailableTables(ctx)
+func (c *AITools) getDefaultTableName(ctx context.Context, alias string) string {
+	availableTables, err := c.getAvailableTables(ctx, alias)
 	if err != nil || len(availableTables) == 0 {
 		return "users" // 最后的回退值
 	}
@@ -1374,27 +1433,14 @@ func (c *AITools) executeAIFileManager(ctx context.Context, arguments map[string
 	}
 
 	// 构建AI提示，让AI理解文件操作需求
-	aiPrompt := fmt.Sprintf(`你是一个智能文件管理助手。用户的指令是："%s"
-
-当前目标路径：%s
-
-请分析这个指令并：
-1. 理解用户想要进行的文件操作类型
-2. 确定具体需要执行的操作步骤
-3. 如果需要，生成相应的文件操作命令
-
-操作模式：%s
-- 如果是 plan_only，只输出分析和计划，不执行实际操作
-- 如果是 execute，除了分析还要说明具体执行的操作
-
-请用JSON格式回复，包含以下字段：
-{
-  "analysis": "对指令的分析",
-  "operation_type": "操作类型(read/write/create/delete/list/search等)",
-  "action_plan": ["具体的操作步骤"],
-  "commands": ["如果需要执行，具体的命令或操作"],
-  "warnings": ["任何安全警告或注意事项"]
-}`, instruction, targetPath, operationMode)
+	aiPrompt, err := c.promptManager.Render(config.PromptFileManagerPlan, map[string]string{
+		"instruction":    instruction,
+		"target_path":    targetPath,
+		"operation_mode": operationMode,
+	})
+	if err != nil {
+		return nil, err
+	}
 
 	result, err := c.callAIWithTimeout(ctx, provider, model, aiPrompt, nil)
 	if err != nil {
@@ -1404,50 +1450,100 @@ func (c *AITools) executeAIFileManager(ctx context.Context, arguments map[string
 	// 如果是execute模式且有systemTools，尝试执行文件操作
 	var executionResults []string
 	if operationMode == "execute" && c.systemTools != nil {
-		instructionLower := strings.ToLower(instruction)
+		executionResults = c.executeFileOperations(ctx, instruction, targetPath)
+	}
 
-		if strings.Contains(instructionLower, "创建") || strings.Contains(instructionLower, "新建") {
-			if targetPath != "" {
-				// 首先确保目标目录存在 - 使用Go的os.MkdirAll，更简洁可靠
-				err := os.MkdirAll(targetPath, 0755)
-				if err != nil {
-					executionResults = append(executionResults, fmt.Sprintf("创建目录失败: %v", err))
-				} else {
-					executionResults = append(executionResults, "✅ 目录创建成功")
+	if executionResults == nil {
+		executionResults = []string{}
+	}
+	response := FileManagerResult{
+		AIAnalysis:       result,
+		OperationMode:    operationMode,
+		TargetPath:       targetPath,
+		ExecutionResults: executionResults,
+	}
+
+	toolResult := mcp.NewTextResult(fmt.Sprintf("AI文件管理结果：\n%s", formatJSONResponse(response)))
+	toolResult.StructuredContent = response
+	return toolResult, nil
+}
+
+// executeFileOperations 检查目标路径并预演文件操作，经用户确认后实际执行
+func (c *AITools) executeFileOperations(ctx context.Context, instruction, targetPath string) []string {
+	// 目标路径必须位于客户端声明的根目录内
+	if targetPath != "" {
+		if err := checkPathInRoots(ctx, targetPath); err != nil {
+			return []string{fmt.Sprintf("⚠️ 未执行文件操作: %v", err)}
+		}
+	}
 
-					// 根据指令内容判断创建类型并创建相应文件
-					if strings.Contains(instructionLower, "go") && strings.Contains(instructionLower, "项目") {
-						// 创建Go项目文件
-						files := map[string]string{
-							"main.go": `package main
+	plan := &recordingFileOperator{}
+	c.runFileOperations(ctx, instruction, targetPath, plan)
+
+	confirmer := c.systemTools.confirmer
+	if confirmer.Required(ctx, "ai_file_manager") && !plan.empty() {
+		if err := confirmer.Confirm(ctx, plan.pendingAction(instruction, targetPath)); err != nil {
+			return []string{fmt.Sprintf("⚠️ 未执行文件操作: %v", err)}
+		}
+		// 已整体确认，内部的file_write不再逐个询问
+		ctx = withConfirmed(ctx)
+	}
+
+	return c.runFileOperations(ctx, instruction, targetPath, &systemFileOperator{
+		executor:        c.executor,
+		securityManager: c.systemTools.securityManager,
+		auditor:         c.auditor,
+	})
+}
+
+// runFileOperations 按指令执行文件操作，返回每一步的执行结果
+// ops为recordingFileOperator时只记录将要执行的操作，不修改文件系统
+func (c *AITools) runFileOperations(ctx context.Context, instruction, targetPath string, ops fileOperator) []string {
+	var executionResults []string
+	instructionLower := strings.ToLower(instruction)
+
+	if strings.Contains(instructionLower, "创建") || strings.Contains(instructionLower, "新建") {
+		if targetPath != "" {
+			// 首先确保目标目录存在
+			err := ops.MkdirAll(ctx, targetPath)
+			if err != nil {
+				executionResults = append(executionResults, fmt.Sprintf("创建目录失败: %v", err))
+			} else {
+				executionResults = append(executionResults, "✅ 目录创建成功")
+
+				// 根据指令内容判断创建类型并创建相应文件
+				if strings.Contains(instructionLower, "go") && strings.Contains(instructionLower, "项目") {
+					// 创建Go项目文件
+					files := map[string]string{
+						"main.go": `package main
 
 import "fmt"
 
 func main() {
 	fmt.Println("Hello, Go!")
 }`,
-							"go.mod": `module example
+						"go.mod": `module example
 
 go 1.19`,
-							"README.md": "# Go Project\n\nThis is a Go project.",
-						}
+						"README.md": "# Go Project\n\nThis is a Go project.",
+					}
 
-						for filename, content := range files {
-							filePath := filepath.Join(targetPath, filename)
-							writeArgs := map[string]interface{}{
-								"path":    filePath,
-								"content": content,
-							}
-							_, err := c.systemTools.ExecuteTool(ctx, "file_write", writeArgs)
-							if err != nil {
-								executionResults = append(executionResults, fmt.Sprintf("创建文件 %s 失败: %v", filename, err))
-							} else {
-								executionResults = append(executionResults, fmt.Sprintf("✅ 文件 %s 创建成功", filename))
-							}
+					for filename, content := range files {
+						filePath := filepath.Join(targetPath, filename)
+						writeArgs := map[string]interface{}{
+							"path":    filePath,
+							"content": content,
+						}
+						_, err := ops.ExecuteTool(ctx, "file_write", writeArgs)
+						if err != nil {
+							executionResults = append(executionResults, fmt.Sprintf("创建文件 %s 失败: %v", filename, err))
+						} else {
+							executionResults = append(executionResults, fmt.Sprintf("✅ 文件 %s 创建成功", filename))
 						}
-					} else if strings.Contains(instructionLower, "nodejs") || strings.Contains(instructionLower, "node.js") {
-						// 创建Node.js项目文件
-						packageJSON := `{
+					}
+				} else if strings.Contains(instructionLower, "nodejs") || strings.Contains(instructionLower, "node.js") {
+					// 创建Node.js项目文件
+					packageJSON := `{
   "name": "nodejs-project",
   "version": "1.0.0",
   "description": "A Node.js project",
@@ -1459,61 +1555,59 @@ go 1.19`,
   "dependencies": {},
   "devDependencies": {}
 }`
-						indexJS := `console.log('Hello, Node.js!');`
-						readme := "# Node.js Project\n\nThis is a Node.js project."
+					indexJS := `console.log('Hello, Node.js!');`
+					readme := "# Node.js Project\n\nThis is a Node.js project."
 
-						files := map[string]string{
-							"package.json": packageJSON,
-							"index.js":     indexJS,
-							"README.md":    readme,
-						}
+					files := map[string]string{
+						"package.json": packageJSON,
+						"index.js":     indexJS,
+						"README.md":    readme,
+					}
 
-						for filename, content := range files {
-							filePath := filepath.Join(targetPath, filename)
-							writeArgs := map[string]interface{}{
-								"path":    filePath,
-								"content": content,
-							}
-							_, err := c.systemTools.ExecuteTool(ctx, "file_write", writeArgs)
-							if err != nil {
-								executionResults = append(executionResults, fmt.Sprintf("创建文件 %s 失败: %v", filename, err))
-							} else {
-								executionResults = append(executionResults, fmt.Sprintf("✅ 文件 %s 创建成功", filename))
-							}
+					for filename, content := range files {
+						filePath := filepath.Join(targetPath, filename)
+						writeArgs := map[string]interface{}{
+							"path":    filePath,
+							"content": content,
 						}
-					} else if strings.Contains(instructionLower, "文档") || strings.Contains(instructionLower, "docs") {
-						// 创建文档项目文件
-						files := map[string]string{
-							"README.md":     "# 文档项目\n\n这是一个文档项目。",
-							"docs/index.md": "# 首页\n\n欢迎来到文档站点。",
-							"docs/guide.md": "# 使用指南\n\n这里是使用指南。",
-							"docs/api.md":   "# API 文档\n\n这里是API文档。",
+						_, err := ops.ExecuteTool(ctx, "file_write", writeArgs)
+						if err != nil {
+							executionResults = append(executionResults, fmt.Sprintf("创建文件 %s 失败: %v", filename, err))
+						} else {
+							executionResults = append(executionResults, fmt.Sprintf("✅ 文件 %s 创建成功", filename))
 						}
+					}
+				} else if strings.Contains(instructionLower, "文档") || strings.Contains(instructionLower, "docs") {
+					// 创建文档项目文件
+					files := map[string]string{
+						"README.md":     "# 文档项目\n\n这是一个文档项目。",
+						"docs/index.md": "# 首页\n\n欢迎来到文档站点。",
+						"docs/guide.md": "# 使用指南\n\n这里是使用指南。",
+						"docs/api.md":   "# API 文档\n\n这里是API文档。",
+					}
+
+					// 创建docs子目录
+					docsDir := filepath.Join(targetPath, "docs")
+					if err := ops.MkdirAll(ctx, docsDir); err != nil {
+						executionResults = append(executionResults, fmt.Sprintf("创建目录 %s 失败: %v", docsDir, err))
+					}
 
-						// 创建docs子目录
-						docsDir := filepath.Join(targetPath, "docs")
-						mkdirArgs := map[string]interface{}{
-							"command": "mkdir",
-							"args":    []string{"-p", docsDir},
+					for filename, content := range files {
+						filePath := filepath.Join(targetPath, filename)
+						writeArgs := map[string]interface{}{
+							"path":    filePath,
+							"content": content,
 						}
-						c.systemTools.ExecuteTool(ctx, "command_execute", mkdirArgs)
-
-						for filename, content := range files {
-							filePath := filepath.Join(targetPath, filename)
-							writeArgs := map[string]interface{}{
-								"path":    filePath,
-								"content": content,
-							}
-							_, err := c.systemTools.ExecuteTool(ctx, "file_write", writeArgs)
-							if err != nil {
-								executionResults = append(executionResults, fmt.Sprintf("创建文件 %s 失败: %v", filename, err))
-							} else {
-								executionResults = append(executionResults, fmt.Sprintf("✅ 文件 %s 创建成功", filename))
-							}
+						_, err := ops.ExecuteTool(ctx, "file_write", writeArgs)
+						if err != nil {
+							executionResults = append(executionResults, fmt.Sprintf("创建文件 %s 失败: %v", filename, err))
+						} else {
+							executionResults = append(executionResults, fmt.Sprintf("✅ 文件 %s 创建成功", filename))
 						}
-					} else if strings.Contains(instructionLower, "json") || strings.Contains(instructionLower, "配置") {
-						// 创建JSON配置文件
-						configContent := `{
+					}
+				} else if strings.Contains(instructionLower, "json") || strings.Contains(instructionLower, "配置") {
+					// 创建JSON配置文件
+					configContent := `{
   "name": "project-config",
   "version": "1.0.0",
   "environment": "development",
@@ -1532,10 +1626,10 @@ go 1.19`,
   }
 }`
 
-						filename := "config.json"
-						if strings.Contains(instructionLower, "package") {
-							filename = "package.json"
-							configContent = `{
+					filename := "config.json"
+					if strings.Contains(instructionLower, "package") {
+						filename = "package.json"
+						configContent = `{
   "name": "my-project",
   "version": "1.0.0",
   "description": "My project description",
@@ -1544,58 +1638,58 @@ go 1.19`,
     "start": "node index.js"
   }
 }`
-						}
+					}
 
-						filePath := filepath.Join(targetPath, filename)
-						writeArgs := map[string]interface{}{
-							"path":    filePath,
-							"content": configContent,
-						}
-						_, err := c.systemTools.ExecuteTool(ctx, "file_write", writeArgs)
-						if err != nil {
-							executionResults = append(executionResults, fmt.Sprintf("创建配置文件失败: %v", err))
-						} else {
-							executionResults = append(executionResults, fmt.Sprintf("✅ 配置文件 %s 创建成功", filename))
-						}
+					filePath := filepath.Join(targetPath, filename)
+					writeArgs := map[string]interface{}{
+						"path":    filePath,
+						"content": configContent,
+					}
+					_, err := ops.ExecuteTool(ctx, "file_write", writeArgs)
+					if err != nil {
+						executionResults = append(executionResults, fmt.Sprintf("创建配置文件失败: %v", err))
 					} else {
-						// 创建默认文件
-						defaultContent := fmt.Sprintf("# 文件\n\n创建时间: %s\n指令: %s\n",
+						executionResults = append(executionResults, fmt.Sprintf("✅ 配置文件 %s 创建成功", filename))
+					}
+				} else {
+					// 创建默认文件
+					defaultContent := fmt.Sprintf("# 文件\n\n创建时间: %s\n指令: %s\n",
+						time.Now().Format("2006-01-02 15:04:05"), instruction)
+
+					filename := "README.md"
+					if strings.Contains(instructionLower, ".txt") {
+						filename = "file.txt"
+						defaultContent = fmt.Sprintf("文件创建时间: %s\n指令: %s\n",
 							time.Now().Format("2006-01-02 15:04:05"), instruction)
+					}
 
-						filename := "README.md"
-						if strings.Contains(instructionLower, ".txt") {
-							filename = "file.txt"
-							defaultContent = fmt.Sprintf("文件创建时间: %s\n指令: %s\n",
-								time.Now().Format("2006-01-02 15:04:05"), instruction)
-						}
-
-						filePath := filepath.Join(targetPath, filename)
-						writeArgs := map[string]interface{}{
-							"path":    filePath,
-							"content": defaultContent,
-						}
-						_, err := c.systemTools.ExecuteTool(ctx, "file_write", writeArgs)
-						if err != nil {
-							executionResults = append(executionResults, fmt.Sprintf("创建文件失败: %v", err))
-						} else {
-							executionResults = append(executionResults, fmt.Sprintf("✅ 文件 %s 创建成功", filename))
-						}
+					filePath := filepath.Join(targetPath, filename)
+					writeArgs := map[string]interface{}{
+						"path":    filePath,
+						"content": defaultContent,
+					}
+					_, err := ops.ExecuteTool(ctx, "file_write", writeArgs)
+					if err != nil {
+						executionResults = append(executionResults, fmt.Sprintf("创建文件失败: %v", err))
+					} else {
+						executionResults = append(executionResults, fmt.Sprintf("✅ 文件 %s 创建成功", filename))
 					}
 				}
 			}
-		} else if strings.Contains(instructionLower, "修改") || strings.Contains(instructionLower, "添加") || strings.Contains(instructionLower, "更新") {
-			// 修改或添加文件操作
-			if targetPath != "" {
-				// 检查目标目录是否存在
-				if _, err := os.Stat(targetPath); os.IsNotExist(err) {
-					executionResults = append(executionResults, "⚠️ 目标目录不存在，请先创建目录")
-				} else {
-					executionResults = append(executionResults, "✅ 找到目标目录")
+		}
+	} else if strings.Contains(instructionLower, "修改") || strings.Contains(instructionLower, "添加") || strings.Contains(instructionLower, "更新") {
+		// 修改或添加文件操作
+		if targetPath != "" {
+			// 检查目标目录是否存在
+			if _, err := os.Stat(targetPath); os.IsNotExist(err) {
+				executionResults = append(executionResults, "⚠️ 目标目录不存在，请先创建目录")
+			} else {
+				executionResults = append(executionResults, "✅ 找到目标目录")
 
-					// 根据指令内容判断要添加的文件类型
-					if strings.Contains(instructionLower, "http") || strings.Contains(instructionLower, "服务器") || strings.Contains(instructionLower, "server") {
-						// 添加HTTP服务器文件
-						serverContent := `package main
+				// 根据指令内容判断要添加的文件类型
+				if strings.Contains(instructionLower, "http") || strings.Contains(instructionLower, "服务器") || strings.Contains(instructionLower, "server") {
+					// 添加HTTP服务器文件
+					serverContent := `package main
 
 import (
 	"fmt"
@@ -1617,22 +1711,22 @@ func main() {
 	log.Fatal(http.ListenAndServe(":8080", nil))
 }`
 
-						filePath := filepath.Join(targetPath, "server.go")
-						writeArgs := map[string]interface{}{
-							"path":    filePath,
-							"content": serverContent,
-						}
-						_, err := c.systemTools.ExecuteTool(ctx, "file_write", writeArgs)
-						if err != nil {
-							executionResults = append(executionResults, fmt.Sprintf("添加服务器文件失败: %v", err))
-						} else {
-							executionResults = append(executionResults, "✅ HTTP服务器文件 server.go 添加成功")
-						}
+					filePath := filepath.Join(targetPath, "server.go")
+					writeArgs := map[string]interface{}{
+						"path":    filePath,
+						"content": serverContent,
+					}
+					_, err := ops.ExecuteTool(ctx, "file_write", writeArgs)
+					if err != nil {
+						executionResults = append(executionResults, fmt.Sprintf("添加服务器文件失败: %v", err))
+					} else {
+						executionResults = append(executionResults, "✅ HTTP服务器文件 server.go 添加成功")
 					}
+				}
 
-					if strings.Contains(instructionLower, "配置") || strings.Contains(instructionLower, "config") {
-						// 添加配置文件
-						configContent := `# 应用配置
+				if strings.Contains(instructionLower, "配置") || strings.Contains(instructionLower, "config") {
+					// 添加配置文件
+					configContent := `# 应用配置
 server:
   host: localhost
   port: 8080
@@ -1651,51 +1745,36 @@ features:
   debug: true
   cache: false`
 
-						filePath := filepath.Join(targetPath, "config.yaml")
-						writeArgs := map[string]interface{}{
-							"path":    filePath,
-							"content": configContent,
-						}
-						_, err := c.systemTools.ExecuteTool(ctx, "file_write", writeArgs)
-						if err != nil {
-							executionResults = append(executionResults, fmt.Sprintf("添加配置文件失败: %v", err))
-						} else {
-							executionResults = append(executionResults, "✅ 配置文件 config.yaml 添加成功")
-						}
+					filePath := filepath.Join(targetPath, "config.yaml")
+					writeArgs := map[string]interface{}{
+						"path":    filePath,
+						"content": configContent,
+					}
+					_, err := ops.ExecuteTool(ctx, "file_write", writeArgs)
+					if err != nil {
+						executionResults = append(executionResults, fmt.Sprintf("添加配置文件失败: %v", err))
+					} else {
+						executionResults = append(executionResults, "✅ 配置文件 config.yaml 添加成功")
 					}
 				}
 			}
-		} else if strings.Contains(instructionLower, "查找") || strings.Contains(instructionLower, "列出") {
-			// 文件查找操作
-			listArgs := map[string]interface{}{
-				"path": targetPath,
-			}
-			_, err := c.systemTools.ExecuteTool(ctx, "directory_list", listArgs)
-			if err != nil {
-				executionResults = append(executionResults, fmt.Sprintf("列出目录失败: %v", err))
-			} else {
-				executionResults = append(executionResults, "✅ 目录列表获取成功")
-			}
+		}
+	} else if strings.Contains(instructionLower, "查找") || strings.Contains(instructionLower, "列出") {
+		// 文件查找操作
+		listArgs := map[string]interface{}{
+			"path": targetPath,
+		}
+		_, err := ops.ExecuteTool(ctx, "directory_list", listArgs)
+		if err != nil {
+			executionResults = append(executionResults, fmt.Sprintf("列出目录失败: %v", err))
 		} else {
-			executionResults = append(executionResults, "⚠️ 当前操作类型暂不支持自动执行，仅提供分析结果")
+			executionResults = append(executionResults, "✅ 目录列表获取成功")
 		}
+	} else {
+		executionResults = append(executionResults, "⚠️ 当前操作类型暂不支持自动执行，仅提供分析结果")
 	}
 
-	response := map[string]interface{}{
-		"ai_analysis":       result,
-		"operation_mode":    operationMode,
-		"target_path":       targetPath,
-		"execution_results": executionResults,
-	}
-
-	return &mcp.ToolCallResult{
-		Content: []mcp.Content{
-			{
-				Type: "text",
-				Text: fmt.Sprintf("AI文件管理结果：\n%s", formatJSONResponse(response)),
-			},
-		},
-	}, nil
+	return executionResults
 }
 
 // executeAIDataProcessor 执行AI数据处理
@@ -1858,26 +1937,31 @@ func (c *AITools) executeAIDataProcessor(ctx context.Context, arguments map[stri
 		}
 	}
 
-	response := map[string]interface{}{
-		"tool":               "ai_data_processor",
-		"status":             "success",
-		"instruction":        instruction,
-		"ai_analysis":        result,
-		"data_type":          dataType,
-		"output_format":      outputFormat,
-		"processing_results": processingResults,
-		"duration":           time.Since(startTime).String(),
-	}
+	return mcp.NewStructuredResult(DataProcessorResult{
+		Tool:              "ai_data_processor",
+		Status:            "success",
+		Instruction:       instruction,
+		AIAnalysis:        result,
+		DataType:          dataType,
+		OutputFormat:      outputFormat,
+		ProcessingResults: processingResults,
+		Duration:          time.Since(startTime).String(),
+	}), nil
+}
 
-	jsonResponse, _ := json.MarshalIndent(response, "", "  ")
-	return &mcp.ToolCallResult{
-		Content: []mcp.Content{
-			{
-				Type: "text",
-				Text: string(jsonResponse),
-			},
-		},
-	}, nil
+// describeAuthInfo 描述认证信息的类型，认证信息本身不会出现在提示词中
+func describeAuthInfo(authInfo string) string {
+	authInfo = strings.TrimSpace(authInfo)
+	if authInfo == "" {
+		return "未提供"
+	}
+	if scheme, _, ok := strings.Cut(authInfo, " "); ok {
+		switch strings.ToLower(scheme) {
+		case "bearer", "basic", "digest", "token":
+			return fmt.Sprintf("已提供（%s 认证，内容已隐藏）", scheme)
+		}
+	}
+	return "已提供（内容已隐藏）"
 }
 
 // executeAIAPIClient 执行AI网络请求
@@ -1936,7 +2020,7 @@ func (c *AITools) executeAIAPIClient(ctx context.Context, arguments map[string]i
   "body": "请求体数据(如果需要)",
   "security_notes": ["安全注意事项"],
   "expected_response": "预期的响应格式"
-}`, instruction, baseURL, authInfo, requestMode)
+}`, instruction, baseURL, describeAuthInfo(authInfo), requestMode)
 
 	result, err := c.callAIWithTimeout(ctx, provider, model, aiPrompt, nil)
 	if err != nil {
@@ -1978,7 +2062,7 @@ func (c *AITools) executeAIAPIClient(ctx context.Context, arguments map[string]i
 				"timeout": 30,
 			}
 
-			httpResult, err := c.networkTools.ExecuteTool(ctx, "http_get", httpArgs)
+			httpResult, err := c.callTool(ctx, "http_get", httpArgs)
 			if err != nil {
 				executionResults["error"] = fmt.Sprintf("HTTP请求失败: %v", err)
 				executionResults["success"] = false
@@ -2027,27 +2111,17 @@ func (c *AITools) executeAIAPIClient(ctx context.Context, arguments map[string]i
 		}
 	}
 
-	response := map[string]interface{}{
-		"tool":                      "ai_api_client",
-		"status":                    "success",
-		"instruction":               instruction,
-		"base_url":                  baseURL,
-		"ai_analysis":               result,
-		"request_mode":              requestMode,
-		"response_analysis_enabled": responseAnalysis,
-		"execution_results":         executionResults,
-		"duration":                  time.Since(startTime).String(),
-	}
-
-	jsonResponse, _ := json.MarshalIndent(response, "", "  ")
-	return &mcp.ToolCallResult{
-		Content: []mcp.Content{
-			{
-				Type: "text",
-				Text: string(jsonResponse),
-			},
-		},
-	}, nil
+	return mcp.NewStructuredResult(APIClientResult{
+		Tool:                    "ai_api_client",
+		Status:                  "success",
+		Instruction:             instruction,
+		BaseURL:                 baseURL,
+		AIAnalysis:              result,
+		RequestMode:             requestMode,
+		ResponseAnalysisEnabled: responseAnalysis,
+		ExecutionResults:        executionResults,
+		Duration:                time.Since(startTime).String(),
+	}), nil
 }
 
 // formatJSONResponse 格式化JSON响应
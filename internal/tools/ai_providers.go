@@ -10,6 +10,8 @@ import (
 	"time"
 
 	"mcp-ai-server/internal/config"
+	"mcp-ai-server/internal/logger"
+	"mcp-ai-server/internal/mcp"
 )
 
 // AIProvider AI提供商接口
@@ -19,6 +21,16 @@ type AIProvider interface {
 	Call(ctx context.Context, model, prompt string, options map[string]interface{}) (string, error)
 }
 
+// redactedProvider 发送前对提示词脱敏的提供商包装，避免密钥和凭据被发送给模型
+type redactedProvider struct {
+	AIProvider
+}
+
+// Call 脱敏提示词后调用被包装的提供商
+func (p redactedProvider) Call(ctx context.Context, model, prompt string, options map[string]interface{}) (string, error) {
+	return p.AIProvider.Call(ctx, model, logger.Redact(prompt), options)
+}
+
 // BaseProvider 基础提供商
 type BaseProvider struct {
 	config *config.ProviderConfig
@@ -282,6 +294,66 @@ func (p *AnthropicProvider) Call(ctx context.Context, model, prompt string, opti
 	return text, nil
 }
 
+// SamplingProvider 通过MCP客户端采样调用模型的提供商
+// 请求经由当前会话发送 sampling/createMessage，由客户端选择并调用模型
+type SamplingProvider struct {
+	config *config.SamplingConfig
+}
+
+// NewSamplingProvider 创建客户端采样提供商
+func NewSamplingProvider(config *config.SamplingConfig) *SamplingProvider {
+	return &SamplingProvider{
+		config: config,
+	}
+}
+
+// Name 提供商名称
+func (p *SamplingProvider) Name() string {
+	return "sampling"
+}
+
+// IsEnabled 检查是否启用
+func (p *SamplingProvider) IsEnabled() bool {
+	return p.config.Enabled
+}
+
+// Call 请求客户端生成回复，model作为模型提示，客户端可以选择其他模型
+func (p *SamplingProvider) Call(ctx context.Context, model, prompt string, options map[string]interface{}) (string, error) {
+	params := mcp.CreateMessageParams{
+		Messages: []mcp.SamplingMessage{
+			{
+				Role:    mcp.RoleUser,
+				Content: mcp.NewTextContent(prompt),
+			},
+		},
+		ModelPreferences: &mcp.ModelPreferences{
+			CostPriority:         p.config.CostPriority,
+			SpeedPriority:        p.config.SpeedPriority,
+			IntelligencePriority: p.config.IntelligencePriority,
+		},
+		SystemPrompt:   p.config.SystemPrompt,
+		IncludeContext: p.config.IncludeContext,
+		MaxTokens:      getIntOption(options, "max_tokens", 1000),
+	}
+	if model != "" {
+		params.ModelPreferences.Hints = []mcp.ModelHint{{Name: model}}
+	}
+	if _, ok := options["temperature"]; ok {
+		temperature := getFloatOption(options, "temperature", 0.7)
+		params.Temperature = &temperature
+	}
+
+	result, err := mcp.CreateMessage(ctx, params)
+	if err != nil {
+		return "", fmt.Errorf("客户端采样失败: %w", err)
+	}
+	if result.Content.Type != mcp.ContentTypeText {
+		return "", fmt.Errorf("客户端返回了不支持的内容类型: %s", result.Content.Type)
+	}
+
+	return result.Content.Text, nil
+}
+
 // 辅助函数
 func getIntOption(options map[string]interface{}, key string, defaultValue int) int {
 	if value, ok := options[key]; ok {
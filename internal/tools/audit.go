@@ -0,0 +1,227 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"path/filepath"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"mcp-ai-server/internal/config"
+	"mcp-ai-server/internal/logger"
+	"mcp-ai-server/internal/mcp"
+)
+
+// Auditor 将每次工具调用写入审计日志
+// 为nil时不记录
+type Auditor struct {
+	log      *logger.AuditLog
+	settings *config.AuditSettings
+}
+
+// NewAuditor 根据审计配置创建审计器，未启用时返回nil
+func NewAuditor(configManager *config.AuditConfigManager) (*Auditor, error) {
+	if !configManager.IsEnabled() {
+		return nil, nil
+	}
+
+	settings := configManager.GetSettings()
+	auditLog, err := logger.NewAuditLog(settings.Path, int64(settings.MaxSize), settings.MaxBackups)
+	if err != nil {
+		return nil, err
+	}
+	return &Auditor{
+		log:      auditLog,
+		settings: settings,
+	}, nil
+}
+
+// callingToolKey 上下文中正在执行的工具名称
+type callingToolKey struct{}
+
+// withCallingTool 标记上下文中正在执行的工具，其内部发起的嵌套调用在审计记录中注明发起方
+func withCallingTool(ctx context.Context, tool string) context.Context {
+	return context.WithValue(ctx, callingToolKey{}, tool)
+}
+
+// callingTool 获取发起当前调用的工具，客户端直接调用时为空
+func callingTool(ctx context.Context) string {
+	tool, _ := ctx.Value(callingToolKey{}).(string)
+	return tool
+}
+
+// Record 记录一次工具调用，写入失败只打印日志，不影响工具结果
+func (a *Auditor) Record(ctx context.Context, tool string, arguments map[string]interface{}, duration time.Duration, result *mcp.ToolCallResult, err error) {
+	if a == nil {
+		return
+	}
+
+	record := logger.AuditRecord{
+		Time:       time.Now().UTC(),
+		Tool:       tool,
+		ParentTool: callingTool(ctx),
+		Arguments:  a.redactArguments(arguments),
+		DurationMS: float64(duration.Microseconds()) / 1000,
+	}
+
+	if session, ok := mcp.SessionFromContext(ctx); ok {
+		info := session.Info()
+		record.Session = info.ID
+		record.RemoteAddr = info.RemoteAddr
+		if info.ClientInfo != nil {
+			record.Client = info.ClientInfo.Name
+		}
+		if principal := session.Principal(); principal != nil {
+			record.Principal = principal.Subject
+			record.AuthMethod = principal.Method
+		}
+	}
+
+	switch {
+	case err != nil:
+		record.IsError = true
+		record.Error = a.truncate(err.Error())
+	case result != nil:
+		if data, marshalErr := json.Marshal(result); marshalErr == nil {
+			record.ResultSize = len(data)
+		}
+		if result.IsError {
+			record.IsError = true
+			record.Error = a.truncate(resultText(result))
+		}
+	}
+
+	// 只有执行成功的调用才产生副作用
+	if extract, ok := sideEffectExtractors[tool]; ok && result != nil && !record.IsError {
+		record.SideEffect, _ = a.redactValue(extract(arguments, result)).(map[string]interface{})
+	}
+
+	if writeErr := a.log.Write(record); writeErr != nil {
+		log.Printf("写入审计日志失败: %v", writeErr)
+	}
+}
+
+// redactArguments 复制参数并脱敏敏感字段、截断过长的值
+func (a *Auditor) redactArguments(arguments map[string]interface{}) map[string]interface{} {
+	if len(arguments) == 0 {
+		return nil
+	}
+	redacted, _ := a.redactValue(arguments).(map[string]interface{})
+	return redacted
+}
+
+// redactValue 递归处理参数值，map中的敏感键整体替换，其余字符串按脱敏规则处理
+func (a *Auditor) redactValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		copied := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			if a.isSensitiveKey(key) {
+				copied[key] = logger.RedactedText
+				continue
+			}
+			copied[key] = a.redactValue(item)
+		}
+		return copied
+	case []interface{}:
+		copied := make([]interface{}, len(v))
+		for i, item := range v {
+			copied[i] = a.redactValue(item)
+		}
+		return copied
+	case string:
+		return a.truncate(logger.Redact(v))
+	default:
+		return v
+	}
+}
+
+// isSensitiveKey 判断参数名是否属于需要脱敏的字段
+func (a *Auditor) isSensitiveKey(key string) bool {
+	key = strings.ToLower(key)
+	for _, sensitive := range a.settings.RedactKeys {
+		if strings.Contains(key, sensitive) {
+			return true
+		}
+	}
+	return false
+}
+
+// truncate 截断过长的字符串并注明原始长度，截断位置回退到UTF-8字符边界
+func (a *Auditor) truncate(value string) string {
+	limit := a.settings.MaxArgumentLength
+	if len(value) <= limit {
+		return value
+	}
+	cut := limit
+	for cut > 0 && !utf8.RuneStart(value[cut]) {
+		cut--
+	}
+	return fmt.Sprintf("%s...(共 %d 字节)", value[:cut], len(value))
+}
+
+// resultText 合并结果中的文本内容
+func resultText(result *mcp.ToolCallResult) string {
+	var texts []string
+	for _, content := range result.Content {
+		if content.Text != "" {
+			texts = append(texts, content.Text)
+		}
+	}
+	return strings.Join(texts, "\n")
+}
+
+// sideEffectExtractors 会修改外部状态的工具，从参数和结果中提取实际产生的副作用
+var sideEffectExtractors = map[string]func(arguments map[string]interface{}, result *mcp.ToolCallResult) map[string]interface{}{
+	"file_write": func(arguments map[string]interface{}, result *mcp.ToolCallResult) map[string]interface{} {
+		path, _ := arguments["path"].(string)
+		content, _ := arguments["content"].(string)
+		if absPath, err := filepath.Abs(path); err == nil {
+			path = absPath
+		}
+		return map[string]interface{}{
+			"action": "write_file",
+			"path":   path,
+			"bytes":  len(content),
+		}
+	},
+	"mkdir": func(arguments map[string]interface{}, result *mcp.ToolCallResult) map[string]interface{} {
+		path, _ := arguments["path"].(string)
+		if absPath, err := filepath.Abs(path); err == nil {
+			path = absPath
+		}
+		return map[string]interface{}{
+			"action": "create_directory",
+			"path":   path,
+		}
+	},
+	"db_execute": func(arguments map[string]interface{}, result *mcp.ToolCallResult) map[string]interface{} {
+		effect := map[string]interface{}{
+			"action": "execute_sql",
+			"alias":  arguments["alias"],
+			"sql":    arguments["sql"],
+		}
+		if execResult, ok := result.StructuredContent.(DBExecuteResult); ok {
+			effect["rows_affected"] = execResult.RowsAffected
+			effect["last_insert_id"] = execResult.LastInsertID
+		}
+		return effect
+	},
+	"command_execute": func(arguments map[string]interface{}, result *mcp.ToolCallResult) map[string]interface{} {
+		effect := map[string]interface{}{
+			"action":  "run_command",
+			"command": arguments["command"],
+		}
+		if args, ok := arguments["args"]; ok {
+			effect["args"] = args
+		}
+		if workingDir, ok := arguments["working_dir"]; ok {
+			effect["working_dir"] = workingDir
+		}
+		effect["output_bytes"] = len(resultText(result))
+		return effect
+	},
+}
@@ -0,0 +1,37 @@
+package tools
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"mcp-ai-server/internal/config"
+)
+
+func TestAuditorTruncate(t *testing.T) {
+	auditor := &Auditor{settings: &config.AuditSettings{MaxArgumentLength: 10}}
+
+	tests := []struct {
+		name   string
+		value  string
+		prefix string
+	}{
+		{name: "short", value: "短参数", prefix: "短参数"},
+		{name: "ascii", value: strings.Repeat("a", 20), prefix: strings.Repeat("a", 10) + "..."},
+		// 每个汉字3字节，第10字节位于第4个汉字中间
+		{name: "multibyte", value: strings.Repeat("数据库", 4), prefix: "数据库...(共 36 字节)"},
+		{name: "boundary", value: "a数据库连接", prefix: "a数据库..."},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := auditor.truncate(tt.value)
+			if !utf8.ValidString(got) {
+				t.Fatalf("truncate(%q) = %q, not valid UTF-8", tt.value, got)
+			}
+			if !strings.HasPrefix(got, tt.prefix) {
+				t.Errorf("truncate(%q) = %q, want prefix %q", tt.value, got, tt.prefix)
+			}
+		})
+	}
+}
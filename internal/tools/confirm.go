@@ -0,0 +1,137 @@
+package tools
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"mcp-ai-server/internal/config"
+	"mcp-ai-server/internal/mcp"
+)
+
+// confirmedKey 标记上下文中的操作已经由上层工具确认过
+type confirmedKey struct{}
+
+// withConfirmed 标记操作已确认，嵌套调用的工具不再重复询问用户
+func withConfirmed(ctx context.Context) context.Context {
+	return context.WithValue(ctx, confirmedKey{}, true)
+}
+
+// isConfirmed 判断上下文中的操作是否已经确认
+func isConfirmed(ctx context.Context) bool {
+	confirmed, _ := ctx.Value(confirmedKey{}).(bool)
+	return confirmed
+}
+
+// ActionField 待确认操作的一项说明，在确认表单中作为只读信息展示
+type ActionField struct {
+	Name        string
+	Title       string
+	Type        string
+	Value       interface{}
+	Description string
+}
+
+// PendingAction 需要用户确认的操作
+type PendingAction struct {
+	Tool    string
+	Message string
+	Fields  []ActionField
+}
+
+// Confirmer 通过elicitation在执行破坏性操作前向用户确认
+// 为nil时所有操作直接执行
+type Confirmer struct {
+	configManager *config.ConfirmationConfigManager
+}
+
+// NewConfirmer 创建确认器
+func NewConfirmer(configManager *config.ConfirmationConfigManager) *Confirmer {
+	return &Confirmer{
+		configManager: configManager,
+	}
+}
+
+// Required 判断本次工具调用是否需要确认，调用方据此决定是否准备操作说明
+func (c *Confirmer) Required(ctx context.Context, tool string) bool {
+	if c == nil || isConfirmed(ctx) {
+		return false
+	}
+	return c.configManager.RequiresConfirmation(tool)
+}
+
+// Confirm 请求用户确认操作，只有用户明确接受并勾选确认时返回nil
+// 客户端不支持elicitation时按on_unsupported配置直接执行或拒绝
+func (c *Confirmer) Confirm(ctx context.Context, action PendingAction) error {
+	if !c.Required(ctx, action.Tool) {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, c.configManager.GetTimeout())
+	defer cancel()
+
+	result, err := mcp.Elicit(ctx, action.Message, action.schema())
+	if errors.Is(err, mcp.ErrElicitationNotSupported) {
+		if c.configManager.DenyWhenUnsupported() {
+			return fmt.Errorf("%s 需要用户确认，但客户端不支持elicitation", action.Tool)
+		}
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("请求用户确认失败: %v", err)
+	}
+
+	switch result.Action {
+	case mcp.ElicitActionAccept:
+		if confirmed, _ := result.Content["confirm"].(bool); confirmed {
+			return nil
+		}
+		return fmt.Errorf("用户未勾选确认")
+	case mcp.ElicitActionDecline:
+		return fmt.Errorf("用户拒绝执行")
+	default:
+		return fmt.Errorf("用户取消了确认")
+	}
+}
+
+// schema 构建确认表单，操作说明作为带默认值的字段展示，confirm为唯一必填项
+func (a PendingAction) schema() map[string]interface{} {
+	properties := make(map[string]interface{}, len(a.Fields)+1)
+	for _, field := range a.Fields {
+		property := map[string]interface{}{
+			"type":    field.Type,
+			"title":   field.Title,
+			"default": field.Value,
+		}
+		if field.Description != "" {
+			property["description"] = field.Description
+		}
+		properties[field.Name] = property
+	}
+	properties["confirm"] = map[string]interface{}{
+		"type":        "boolean",
+		"title":       "确认执行",
+		"description": "勾选后才会执行上述操作，表单中的其他字段仅供查看，修改不会生效",
+		"default":     false,
+	}
+
+	return map[string]interface{}{
+		"type":       "object",
+		"properties": properties,
+		"required":   []string{"confirm"},
+	}
+}
+
+// declinedResult 操作未获确认时返回给模型的结果
+func declinedResult(tool string, err error) *mcp.ToolCallResult {
+	return mcp.NewToolErrorResult(fmt.Sprintf("%s 未执行: %v", tool, err))
+}
+
+// summarizeList 将列表压缩为适合在确认表单中展示的文本
+func summarizeList(items []string, limit int) string {
+	if len(items) <= limit {
+		return strings.Join(items, "\n")
+	}
+	return fmt.Sprintf("%s\n... 共 %d 项", strings.Join(items[:limit], "\n"), len(items))
+}
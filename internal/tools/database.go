@@ -3,25 +3,37 @@ package tools
 import (
 	"context"
 	"database/sql"
-	"encoding/json"
 	"fmt"
-	"os"
+	"net/url"
+	"sort"
 	"strings"
+	"sync"
 	"time"
 
-	_ "github.com/go-sql-driver/mysql" // MySQL driver
-	_ "github.com/lib/pq"              // PostgreSQL driver
-	_ "github.com/mattn/go-sqlite3"    // SQLite driver
+	_ "github.com/go-sql-driver/mysql"  // MySQL driver
+	"github.com/jackc/pgx/v5/stdlib"    // PostgreSQL driver
+	_ "github.com/microsoft/go-mssqldb" // SQL Server driver
 
 	"mcp-ai-server/internal/config"
+	"mcp-ai-server/internal/logger"
 	"mcp-ai-server/internal/mcp"
 )
 
+func init() {
+	// pgx注册的驱动名是pgx，保留postgres驱动名以兼容已有配置
+	sql.Register("postgres", stdlib.GetDefaultDriver())
+}
+
 // DatabaseTools 数据库操作工具集合
 type DatabaseTools struct {
 	securityManager *config.SecurityManager
 	connections     []*sql.DB          // 使用切片管理连接
 	aliasMap        map[string]*sql.DB // 别名到连接的映射
+	aliasDialects   map[string]Dialect // 别名到数据库方言的映射
+	mu              sync.RWMutex       // 保护连接映射，工具调用会并发执行
+	onChange        func()             // 连接别名变化时的回调
+	confirmer       *Confirmer         // 执行写操作前的确认器
+	schemaCache     *schemaCache       // 按别名缓存的数据库结构
 }
 
 // DatabaseResource 数据库资源
@@ -40,12 +52,14 @@ func NewDatabaseTools(securityManager *config.SecurityManager) *DatabaseTools {
 		securityManager: securityManager,
 		connections:     make([]*sql.DB, 0),
 		aliasMap:        make(map[string]*sql.DB),
+		aliasDialects:   make(map[string]Dialect),
+		schemaCache:     newSchemaCache(config.DefaultSchemaCacheTTL * time.Second),
 	}
 
 	// 尝试建立默认数据库连接
 	if err := dt.initializeDefaultConnection(); err != nil {
 		// 如果默认连接失败，只记录错误但不阻止创建工具
-		fmt.Fprintf(os.Stderr, "[DEBUG] 警告：初始化默认数据库连接失败: %v\n", err)
+		fmt.Fprintf(logger.Stderr, "[DEBUG] 警告：初始化默认数据库连接失败: %v\n", err)
 	}
 
 	return dt
@@ -65,6 +79,11 @@ func (t *DatabaseTools) initializeDefaultConnection() error {
 		return fmt.Errorf("获取默认连接配置失败: %v", err)
 	}
 
+	dialect, err := DialectFor(driver)
+	if err != nil {
+		return err
+	}
+
 	// 建立默认连接
 	db, err := sql.Open(driver, dsn)
 	if err != nil {
@@ -84,31 +103,53 @@ func (t *DatabaseTools) initializeDefaultConnection() error {
 
 	// 存储连接
 	t.aliasMap[alias] = db
+	t.aliasDialects[alias] = dialect
 	t.connections = append(t.connections, db)
 
-	fmt.Fprintf(os.Stderr, "[DEBUG] 成功建立默认数据库连接: %s (%s)\n", alias, driver)
+	fmt.Fprintf(logger.Stderr, "[DEBUG] 成功建立默认数据库连接: %s (%s)\n", alias, driver)
 	return nil
 }
 
+// SetConnectionsChangedHandler 设置连接别名变化时的回调
+func (t *DatabaseTools) SetConnectionsChangedHandler(handler func()) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.onChange = handler
+}
+
+// SetConfirmer 设置写操作的确认器
+func (t *DatabaseTools) SetConfirmer(confirmer *Confirmer) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.confirmer = confirmer
+}
+
 // DBConnectTool 数据库连接工具
 func (t *DatabaseTools) DBConnectTool() mcp.Tool {
 	return mcp.Tool{
-		Name:        "db_connect",
-		Description: "连接到数据库，并返回连接索引。",
+		Name:         "db_connect",
+		Description:  "连接到数据库，并返回连接索引。",
+		OutputSchema: dbConnectOutputSchema,
 		InputSchema: map[string]interface{}{
 			"type": "object",
 			"properties": map[string]interface{}{
 				"driver": map[string]interface{}{
 					"type":        "string",
-					"description": "数据库驱动类型 (mysql, postgres, sqlite3)",
-					"enum":        []string{"mysql", "postgres", "sqlite3"},
+					"description": "数据库驱动类型 (mysql, postgres, sqlite3, sqlserver)",
+					"enum":        SupportedDrivers(),
 				},
 				"dsn": map[string]interface{}{
 					"type":        "string",
 					"description": "数据库连接字符串",
 				},
+				"alias": map[string]interface{}{
+					"type":        "string",
+					"description": "连接别名，后续查询通过别名引用该连接",
+				},
 			},
-			"required": []string{"driver", "dsn"},
+			"required": []string{"driver", "dsn", "alias"},
 		},
 	}
 }
@@ -116,8 +157,9 @@ func (t *DatabaseTools) DBConnectTool() mcp.Tool {
 // DBQueryTool 数据库查询工具
 func (t *DatabaseTools) DBQueryTool() mcp.Tool {
 	return mcp.Tool{
-		Name:        "db_query",
-		Description: "执行数据库查询",
+		Name:         "db_query",
+		Description:  "执行数据库查询",
+		OutputSchema: dbQueryOutputSchema,
 		InputSchema: map[string]interface{}{
 			"type": "object",
 			"properties": map[string]interface{}{
@@ -134,6 +176,11 @@ func (t *DatabaseTools) DBQueryTool() mcp.Tool {
 					"description": "查询结果限制数量",
 					"default":     100,
 				},
+				"explain": map[string]interface{}{
+					"type":        "boolean",
+					"description": "只返回查询的执行计划，不执行查询",
+					"default":     false,
+				},
 			},
 			"required": []string{"alias", "sql"},
 		},
@@ -143,8 +190,9 @@ func (t *DatabaseTools) DBQueryTool() mcp.Tool {
 // DBExecuteTool 数据库执行工具
 func (t *DatabaseTools) DBExecuteTool() mcp.Tool {
 	return mcp.Tool{
-		Name:        "db_execute",
-		Description: "执行数据库操作 (INSERT, UPDATE, DELETE)",
+		Name:         "db_execute",
+		Description:  "执行数据库操作 (INSERT, UPDATE, DELETE)",
+		OutputSchema: dbExecuteOutputSchema,
 		InputSchema: map[string]interface{}{
 			"type": "object",
 			"properties": map[string]interface{}{
@@ -162,12 +210,87 @@ func (t *DatabaseTools) DBExecuteTool() mcp.Tool {
 	}
 }
 
+// DBListTablesTool 列出表和视图的工具
+func (t *DatabaseTools) DBListTablesTool() mcp.Tool {
+	return mcp.Tool{
+		Name:         "db_list_tables",
+		Description:  "列出数据库中的表和视图及估算行数",
+		OutputSchema: dbListTablesOutputSchema,
+		InputSchema: map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"alias":   aliasProperty,
+				"refresh": refreshProperty,
+			},
+			"required": []string{"alias"},
+		},
+	}
+}
+
+// DBDescribeTableTool 查看表结构的工具
+func (t *DatabaseTools) DBDescribeTableTool() mcp.Tool {
+	return mcp.Tool{
+		Name:         "db_describe_table",
+		Description:  "查看表的列（类型、是否可空、默认值）、主键、索引、外键和估算行数",
+		OutputSchema: dbDescribeTableOutputSchema,
+		InputSchema: map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"alias": aliasProperty,
+				"table": map[string]interface{}{
+					"type":        "string",
+					"description": "表名或视图名",
+				},
+				"refresh": refreshProperty,
+			},
+			"required": []string{"alias", "table"},
+		},
+	}
+}
+
+// DBRelationshipsTool 查看外键关系的工具
+func (t *DatabaseTools) DBRelationshipsTool() mcp.Tool {
+	return mcp.Tool{
+		Name:         "db_relationships",
+		Description:  "列出数据库中表之间的外键关系",
+		OutputSchema: dbRelationshipsOutputSchema,
+		InputSchema: map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"alias": aliasProperty,
+				"table": map[string]interface{}{
+					"type":        "string",
+					"description": "只返回引用该表或被该表引用的关系（可选）",
+				},
+				"refresh": refreshProperty,
+			},
+			"required": []string{"alias"},
+		},
+	}
+}
+
+// 结构查询工具共用的参数
+var (
+	aliasProperty = map[string]interface{}{
+		"type":        "string",
+		"description": "数据库连接别名",
+	}
+	refreshProperty = map[string]interface{}{
+		"type":        "boolean",
+		"description": "忽略缓存，重新读取数据库结构",
+		"default":     false,
+	}
+)
+
 // GetTools 获取所有数据库工具
 func (t *DatabaseTools) GetTools() []mcp.Tool {
 	return []mcp.Tool{
 		t.DBConnectTool(),
 		t.DBQueryTool(),
 		t.DBExecuteTool(),
+		t.DBListTablesTool(),
+		t.DBDescribeTableTool(),
+		t.DBRelationshipsTool(),
 	}
 }
 
@@ -180,6 +303,12 @@ func (t *DatabaseTools) ExecuteTool(ctx context.Context, name string, arguments
 		return t.executeDBQuery(ctx, arguments)
 	case "db_execute":
 		return t.executeDBExecute(ctx, arguments)
+	case "db_list_tables":
+		return t.executeDBListTables(ctx, arguments)
+	case "db_describe_table":
+		return t.executeDBDescribeTable(ctx, arguments)
+	case "db_relationships":
+		return t.executeDBRelationships(ctx, arguments)
 	default:
 		return nil, fmt.Errorf("未知的数据库工具: %s", name)
 	}
@@ -200,9 +329,18 @@ func (t *DatabaseTools) executeDBConnect(ctx context.Context, arguments map[stri
 		return nil, fmt.Errorf("alias参数必须是字符串")
 	}
 
-	// 如果别名已存在，关闭旧连接
-	if existingDB, exists := t.aliasMap[alias]; exists {
-		existingDB.Close()
+	dialect, err := DialectFor(driver)
+	if err != nil {
+		return nil, err
+	}
+
+	// SQLite的DSN是本地文件路径，打开时不存在会被创建，与文件工具同样受允许路径限制
+	if _, ok := dialect.(sqliteDialect); ok {
+		if path, ok := sqliteFilePath(dsn); ok {
+			if err := t.securityManager.IsPathAllowed(path); err != nil {
+				return nil, fmt.Errorf("数据库文件路径访问被拒绝: %v", err)
+			}
+		}
 	}
 
 	db, err := sql.Open(driver, dsn)
@@ -217,19 +355,59 @@ func (t *DatabaseTools) executeDBConnect(ctx context.Context, arguments map[stri
 	db.SetMaxIdleConns(5)
 	db.SetConnMaxLifetime(time.Hour)
 
-	// 将连接存储到别名映射中
+	// 将连接存储到别名映射中，如果别名已存在，关闭旧连接
+	t.mu.Lock()
+	if existingDB, exists := t.aliasMap[alias]; exists {
+		existingDB.Close()
+	}
 	t.aliasMap[alias] = db
+	t.aliasDialects[alias] = dialect
 	t.connections = append(t.connections, db)
 	newIndex := len(t.connections) - 1
+	onChange := t.onChange
+	t.mu.Unlock()
 
-	return &mcp.ToolCallResult{
-		Content: []mcp.Content{
-			{
-				Type: "text",
-				Text: fmt.Sprintf("成功连接到数据库 %s，别名: %s，连接索引为: %d", driver, alias, newIndex),
-			},
-		},
-	}, nil
+	// 别名可能指向了另一个数据库
+	t.InvalidateSchema(alias)
+
+	if onChange != nil {
+		onChange()
+	}
+
+	result := mcp.NewTextResult(fmt.Sprintf("成功连接到数据库 %s，别名: %s，连接索引为: %d", driver, alias, newIndex))
+	result.StructuredContent = DBConnectResult{
+		Alias:           alias,
+		Driver:          driver,
+		ConnectionIndex: newIndex,
+	}
+	return result, nil
+}
+
+// sqliteFilePath 从SQLite DSN中取出数据库文件路径，内存数据库返回false
+// DSN可以是普通路径或file:URI，"?"之后是连接参数，URI中的路径可能经过百分号编码
+func sqliteFilePath(dsn string) (string, bool) {
+	path, params, _ := strings.Cut(dsn, "?")
+	if rest, ok := strings.CutPrefix(path, "file:"); ok {
+		path = rest
+		// file://host/path 形式，SQLite只接受空主机或localhost
+		if rest, ok := strings.CutPrefix(path, "//"); ok {
+			if idx := strings.Index(rest, "/"); idx >= 0 {
+				path = rest[idx:]
+			} else {
+				path = ""
+			}
+		}
+		if unescaped, err := url.PathUnescape(path); err == nil {
+			path = unescaped
+		}
+		if values, err := url.ParseQuery(params); err == nil && values.Get("mode") == "memory" {
+			return "", false
+		}
+	}
+	if path == "" || path == ":memory:" {
+		return "", false
+	}
+	return path, true
 }
 
 // executeDBQuery 执行数据库查询
@@ -239,7 +417,7 @@ func (t *DatabaseTools) executeDBQuery(ctx context.Context, arguments map[string
 		return nil, fmt.Errorf("alias参数必须是字符串")
 	}
 
-	db, exists := t.aliasMap[alias]
+	db, dialect, exists := t.getConnection(alias)
 	if !exists {
 		return nil, fmt.Errorf("未找到别名为 %s 的数据库连接", alias)
 	}
@@ -254,25 +432,255 @@ func (t *DatabaseTools) executeDBQuery(ctx context.Context, arguments map[string
 		limit = int(limitVal)
 	}
 
-	sqlLower := strings.ToLower(strings.TrimSpace(sqlQuery))
+	sqlQuery, err := singleStatement(sqlQuery)
+	if err != nil {
+		return nil, err
+	}
+
+	sqlLower := strings.ToLower(sqlQuery)
 	if !strings.HasPrefix(sqlLower, "select") {
 		return nil, fmt.Errorf("db_query只允许SELECT查询语句")
 	}
 
+	var columns []string
+	var results []map[string]interface{}
+	if explain, _ := arguments["explain"].(bool); explain {
+		columns, results, err = t.explain(ctx, db, dialect, sqlQuery, limit)
+	} else {
+		columns, results, err = t.query(ctx, db, sqlQuery, limit)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if results == nil {
+		results = []map[string]interface{}{}
+	}
+
+	return mcp.NewStructuredResult(DBQueryResult{
+		Columns:  columns,
+		Rows:     results,
+		RowCount: len(results),
+		Limited:  len(results) >= limit,
+	}), nil
+}
+
+// query 执行查询并读取最多limit行
+func (t *DatabaseTools) query(ctx context.Context, db *sql.DB, sqlQuery string, limit int) ([]string, []map[string]interface{}, error) {
 	rows, err := db.QueryContext(ctx, sqlQuery)
 	if err != nil {
-		return nil, fmt.Errorf("查询执行失败: %v", err)
+		return nil, nil, fmt.Errorf("查询执行失败: %v", err)
 	}
 	defer rows.Close()
 
+	return scanRows(rows, limit)
+}
+
+// explain 获取查询的执行计划，部分数据库需要在同一连接上切换会话设置，因此使用独占连接
+func (t *DatabaseTools) explain(ctx context.Context, db *sql.DB, dialect Dialect, sqlQuery string, limit int) ([]string, []map[string]interface{}, error) {
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return nil, nil, fmt.Errorf("获取数据库连接失败: %v", err)
+	}
+	defer conn.Close()
+
+	return dialect.Explain(ctx, conn, sqlQuery, limit)
+}
+
+// executeDBExecute 执行数据库操作
+func (t *DatabaseTools) executeDBExecute(ctx context.Context, arguments map[string]interface{}) (*mcp.ToolCallResult, error) {
+	alias, ok := arguments["alias"].(string)
+	if !ok {
+		return nil, fmt.Errorf("alias参数必须是字符串")
+	}
+
+	db, dialect, exists := t.getConnection(alias)
+	if !exists {
+		return nil, fmt.Errorf("未找到别名为 %s 的数据库连接", alias)
+	}
+
+	sqlQuery, ok := arguments["sql"].(string)
+	if !ok {
+		return nil, fmt.Errorf("sql参数必须是字符串")
+	}
+
+	// 只接受单条语句，避免在确认之前或之外夹带执行其它语句
+	sqlQuery, err := singleStatement(sqlQuery)
+	if err != nil {
+		return nil, err
+	}
+
+	// ATTACH和VACUUM INTO可以读写任意路径的数据库文件，绕过db_connect的路径检查
+	// 只有MySQL会执行/*!...*/中的内容；无法识别语句类型时一律拒绝，避免借注释等形式绕过检查
+	_, executableComments := dialect.(mysqlDialect)
+	keyword := leadingKeyword(sqlQuery, executableComments)
+	switch keyword {
+	case "":
+		return nil, fmt.Errorf("无法识别SQL语句类型，语句必须以关键字开头")
+	case "TRUNCATE", "ALTER", "ATTACH":
+		return nil, fmt.Errorf("不允许执行 %s 语句", keyword)
+	case "VACUUM":
+		if strings.Contains(strings.ToUpper(sqlQuery), "INTO") {
+			return nil, fmt.Errorf("不允许执行 VACUUM INTO 语句")
+		}
+	}
+
+	// 执行前请求用户确认
+	t.mu.RLock()
+	confirmer := t.confirmer
+	t.mu.RUnlock()
+	if confirmer.Required(ctx, "db_execute") {
+		fields := []ActionField{
+			{Name: "alias", Title: "数据库连接", Type: "string", Value: alias},
+			{Name: "sql", Title: "将要执行的SQL", Type: "string", Value: sqlQuery},
+			t.estimatedRowsField(ctx, db, dialect, keyword, sqlQuery),
+		}
+		message := fmt.Sprintf("即将在 %s 上执行SQL，是否继续？", alias)
+		if err := confirmer.Confirm(ctx, PendingAction{Tool: "db_execute", Message: message, Fields: fields}); err != nil {
+			return declinedResult("db_execute", err), nil
+		}
+	}
+
+	result, err := db.ExecContext(ctx, sqlQuery)
+	if err != nil {
+		return nil, fmt.Errorf("执行SQL失败: %v", err)
+	}
+	if ddlStatementPattern.MatchString(sqlQuery) {
+		t.InvalidateSchema(alias)
+	}
+
+	rowsAffected, _ := result.RowsAffected()
+	lastInsertId, _ := result.LastInsertId()
+
+	return mcp.NewStructuredResult(DBExecuteResult{
+		RowsAffected: rowsAffected,
+		LastInsertID: lastInsertId,
+		Status:       "success",
+	}), nil
+}
+
+// estimatedRowsField 确认表单中的预计影响行数
+// 只估算数据修改语句；无法估算时以文字说明原因，不影响确认流程
+func (t *DatabaseTools) estimatedRowsField(ctx context.Context, db *sql.DB, dialect Dialect, keyword, sqlQuery string) ActionField {
+	field := ActionField{Name: "estimated_rows", Title: "预计影响行数", Type: "string"}
+
+	switch keyword {
+	case "INSERT", "UPDATE", "DELETE", "REPLACE", "MERGE":
+	default:
+		field.Value = "不适用（非数据修改语句）"
+		return field
+	}
+
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		field.Value = fmt.Sprintf("无法估算: 获取数据库连接失败: %v", err)
+		return field
+	}
+	defer conn.Close()
+
+	rows, err := dialect.EstimateRows(ctx, conn, sqlQuery)
+	if err != nil {
+		field.Value = fmt.Sprintf("无法估算: %v", err)
+		return field
+	}
+	field.Type = "integer"
+	field.Value = rows
+	return field
+}
+
+// schemaAlias 解析结构查询工具的alias参数，refresh为true时先清除该别名的缓存
+func (t *DatabaseTools) schemaAlias(arguments map[string]interface{}) (string, Dialect, error) {
+	alias, ok := arguments["alias"].(string)
+	if !ok {
+		return "", nil, fmt.Errorf("alias参数必须是字符串")
+	}
+	dialect, err := t.Dialect(alias)
+	if err != nil {
+		return "", nil, err
+	}
+	if refresh, _ := arguments["refresh"].(bool); refresh {
+		t.InvalidateSchema(alias)
+	}
+	return alias, dialect, nil
+}
+
+// executeDBListTables 列出表和视图
+func (t *DatabaseTools) executeDBListTables(ctx context.Context, arguments map[string]interface{}) (*mcp.ToolCallResult, error) {
+	alias, dialect, err := t.schemaAlias(arguments)
+	if err != nil {
+		return nil, err
+	}
+
+	tables, err := t.ListTableInfos(ctx, alias)
+	if err != nil {
+		return nil, err
+	}
+
+	return mcp.NewStructuredResult(DBListTablesResult{
+		Alias:   alias,
+		Dialect: dialect.Name(),
+		Tables:  tables,
+	}), nil
+}
+
+// executeDBDescribeTable 查看表结构
+func (t *DatabaseTools) executeDBDescribeTable(ctx context.Context, arguments map[string]interface{}) (*mcp.ToolCallResult, error) {
+	alias, dialect, err := t.schemaAlias(arguments)
+	if err != nil {
+		return nil, err
+	}
+	table, ok := arguments["table"].(string)
+	if !ok || table == "" {
+		return nil, fmt.Errorf("table参数必须是非空字符串")
+	}
+
+	schema, err := t.DescribeTable(ctx, alias, table)
+	if err != nil {
+		return nil, err
+	}
+
+	return mcp.NewStructuredResult(DBDescribeTableResult{
+		Alias:       alias,
+		Dialect:     dialect.Name(),
+		TableSchema: schema,
+	}), nil
+}
+
+// executeDBRelationships 列出外键关系
+func (t *DatabaseTools) executeDBRelationships(ctx context.Context, arguments map[string]interface{}) (*mcp.ToolCallResult, error) {
+	alias, _, err := t.schemaAlias(arguments)
+	if err != nil {
+		return nil, err
+	}
+
+	relationships, err := t.Relationships(ctx, alias)
+	if err != nil {
+		return nil, err
+	}
+	if table, _ := arguments["table"].(string); table != "" {
+		filtered := []ForeignKey{}
+		for _, fk := range relationships {
+			if fk.Table == table || fk.ReferencedTable == table {
+				filtered = append(filtered, fk)
+			}
+		}
+		relationships = filtered
+	}
+
+	return mcp.NewStructuredResult(DBRelationshipsResult{
+		Alias:         alias,
+		Relationships: relationships,
+	}), nil
+}
+
+// scanRows 读取结果集，最多返回limit行，[]byte列转换为字符串
+func scanRows(rows *sql.Rows, limit int) ([]string, []map[string]interface{}, error) {
 	columns, err := rows.Columns()
 	if err != nil {
-		return nil, fmt.Errorf("获取列信息失败: %v", err)
+		return nil, nil, fmt.Errorf("获取列信息失败: %v", err)
 	}
 
 	var results []map[string]interface{}
-	rowCount := 0
-	for rows.Next() && rowCount < limit {
+	for len(results) < limit && rows.Next() {
 		values := make([]interface{}, len(columns))
 		valuePtrs := make([]interface{}, len(columns))
 		for i := range columns {
@@ -280,7 +688,7 @@ func (t *DatabaseTools) executeDBQuery(ctx context.Context, arguments map[string
 		}
 
 		if err := rows.Scan(valuePtrs...); err != nil {
-			return nil, fmt.Errorf("扫描行数据失败: %v", err)
+			return nil, nil, fmt.Errorf("扫描行数据失败: %v", err)
 		}
 
 		row := make(map[string]interface{})
@@ -293,79 +701,124 @@ func (t *DatabaseTools) executeDBQuery(ctx context.Context, arguments map[string
 			}
 		}
 		results = append(results, row)
-		rowCount++
 	}
 
 	if err := rows.Err(); err != nil {
-		return nil, fmt.Errorf("遍历结果集失败: %v", err)
+		return nil, nil, fmt.Errorf("遍历结果集失败: %v", err)
 	}
 
-	output := map[string]interface{}{
-		"columns":   columns,
-		"rows":      results,
-		"row_count": rowCount,
-		"limited":   rowCount >= limit,
+	return columns, results, nil
+}
+
+// getConnection 按别名获取连接和数据库方言
+func (t *DatabaseTools) getConnection(alias string) (*sql.DB, Dialect, bool) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	db, exists := t.aliasMap[alias]
+	return db, t.aliasDialects[alias], exists
+}
+
+// Dialect 获取指定别名连接的数据库方言
+func (t *DatabaseTools) Dialect(alias string) (Dialect, error) {
+	_, dialect, exists := t.getConnection(alias)
+	if !exists {
+		return nil, fmt.Errorf("未找到别名为 %s 的数据库连接", alias)
 	}
+	return dialect, nil
+}
 
-	outputJSON, _ := json.MarshalIndent(output, "", "  ")
+// GetAliases 获取所有已连接的数据库别名
+func (t *DatabaseTools) GetAliases() []string {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
 
-	return &mcp.ToolCallResult{
-		Content: []mcp.Content{
-			{
-				Type: "text",
-				Text: string(outputJSON),
-			},
-		},
-	}, nil
+	aliases := make([]string, 0, len(t.aliasMap))
+	for alias := range t.aliasMap {
+		aliases = append(aliases, alias)
+	}
+	sort.Strings(aliases)
+	return aliases
 }
 
-// executeDBExecute 执行数据库操作
-func (t *DatabaseTools) executeDBExecute(ctx context.Context, arguments map[string]interface{}) (*mcp.ToolCallResult, error) {
-	alias, ok := arguments["alias"].(string)
-	if !ok {
-		return nil, fmt.Errorf("alias参数必须是字符串")
+// ListTables 列出指定别名数据库中的表，不包括视图
+func (t *DatabaseTools) ListTables(ctx context.Context, alias string) ([]string, error) {
+	infos, err := t.ListTableInfos(ctx, alias)
+	if err != nil {
+		return nil, err
 	}
 
-	db, exists := t.aliasMap[alias]
+	var tables []string
+	for _, info := range infos {
+		if info.Type == "TABLE" {
+			tables = append(tables, info.Name)
+		}
+	}
+	return tables, nil
+}
+
+// ListColumns 列出指定别名数据库中表的列，表不存在时返回错误
+func (t *DatabaseTools) ListColumns(ctx context.Context, alias, table string) ([]ColumnInfo, error) {
+	db, dialect, exists := t.getConnection(alias)
 	if !exists {
 		return nil, fmt.Errorf("未找到别名为 %s 的数据库连接", alias)
 	}
 
-	sqlQuery, ok := arguments["sql"].(string)
-	if !ok {
-		return nil, fmt.Errorf("sql参数必须是字符串")
+	rows, err := db.QueryContext(ctx, dialect.ListColumnsQuery(), table)
+	if err != nil {
+		return nil, fmt.Errorf("查询表结构失败: %v", err)
 	}
+	defer rows.Close()
 
-	sqlLower := strings.ToLower(strings.TrimSpace(sqlQuery))
-	dangerousKeywords := []string{"truncate", "alter"}
-	for _, keyword := range dangerousKeywords {
-		if strings.HasPrefix(sqlLower, keyword) {
-			return nil, fmt.Errorf("不允许执行 %s 语句", strings.ToUpper(keyword))
+	var columns []ColumnInfo
+	for rows.Next() {
+		var column ColumnInfo
+		var columnType, nullable, defaultValue sql.NullString
+		if err := rows.Scan(&column.Name, &columnType, &nullable, &defaultValue); err != nil {
+			return nil, fmt.Errorf("扫描列信息失败: %v", err)
 		}
+		column.Type = columnType.String
+		column.Nullable = strings.EqualFold(nullable.String, "YES")
+		if defaultValue.Valid {
+			column.Default = &defaultValue.String
+		}
+		columns = append(columns, column)
 	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("遍历列信息失败: %v", err)
+	}
+	if len(columns) == 0 {
+		return nil, fmt.Errorf("表 %s 在 %s 中不存在", table, alias)
+	}
+	return columns, nil
+}
 
-	result, err := db.ExecContext(ctx, sqlQuery)
+// SampleTable 读取表中的前limit行，表名必须存在于表列表中
+func (t *DatabaseTools) SampleTable(ctx context.Context, alias, table string, limit int) ([]string, []map[string]interface{}, error) {
+	tables, err := t.ListTables(ctx, alias)
 	if err != nil {
-		return nil, fmt.Errorf("执行SQL失败: %v", err)
+		return nil, nil, err
 	}
 
-	rowsAffected, _ := result.RowsAffected()
-	lastInsertId, _ := result.LastInsertId()
-
-	output := map[string]interface{}{
-		"rows_affected":  rowsAffected,
-		"last_insert_id": lastInsertId,
-		"status":         "success",
+	found := false
+	for _, name := range tables {
+		if name == table {
+			found = true
+			break
+		}
+	}
+	if !found {
+		return nil, nil, fmt.Errorf("表 %s 在 %s 中不存在", table, alias)
 	}
 
-	outputJSON, _ := json.MarshalIndent(output, "", "  ")
+	db, dialect, _ := t.getConnection(alias)
+	query := dialect.LimitQuery("SELECT * FROM "+dialect.QuoteIdentifier(table), limit)
 
-	return &mcp.ToolCallResult{
-		Content: []mcp.Content{
-			{
-				Type: "text",
-				Text: string(outputJSON),
-			},
-		},
-	}, nil
+	rows, err := db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, nil, fmt.Errorf("查询表数据失败: %v", err)
+	}
+	defer rows.Close()
+
+	return scanRows(rows, limit)
 }
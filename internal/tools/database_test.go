@@ -0,0 +1,342 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"mcp-ai-server/internal/config"
+)
+
+const testSchema = `
+CREATE TABLE customers (
+	id INTEGER PRIMARY KEY,
+	name TEXT NOT NULL,
+	email TEXT UNIQUE
+);
+CREATE TABLE orders (
+	id INTEGER PRIMARY KEY,
+	customer_id INTEGER NOT NULL REFERENCES customers(id),
+	total REAL DEFAULT 0
+);
+CREATE INDEX idx_orders_customer ON orders(customer_id);
+CREATE VIEW order_totals AS SELECT customer_id, SUM(total) AS total FROM orders GROUP BY customer_id;
+INSERT INTO customers (id, name, email) VALUES (1, 'alice', 'alice@example.com'), (2, 'bob', NULL);
+INSERT INTO orders (customer_id, total) VALUES (1, 10.5), (1, 4.5), (2, 7);
+`
+
+// newTestDatabaseTools 在临时目录中创建SQLite数据库，以该目录为唯一允许目录，并以别名test连接
+func newTestDatabaseTools(t *testing.T) (*DatabaseTools, string) {
+	t.Helper()
+
+	dir := t.TempDir()
+	configPath := filepath.Join(dir, "config.yaml")
+	configYAML := fmt.Sprintf("security:\n  paths:\n    allowed: [%q]\n", dir)
+	if err := os.WriteFile(configPath, []byte(configYAML), 0644); err != nil {
+		t.Fatal(err)
+	}
+	securityManager, err := config.NewSecurityManager(configPath)
+	if err != nil {
+		t.Fatalf("NewSecurityManager: %v", err)
+	}
+
+	dt := NewDatabaseTools(securityManager)
+	t.Cleanup(func() {
+		for _, db := range dt.connections {
+			db.Close()
+		}
+	})
+
+	dbPath := filepath.Join(dir, "test.db")
+	runDBTool(t, dt, "db_connect", map[string]interface{}{"driver": "sqlite3", "dsn": dbPath, "alias": "test"})
+
+	db, _, _ := dt.getConnection("test")
+	if _, err := db.Exec(testSchema); err != nil {
+		t.Fatalf("创建测试表失败: %v", err)
+	}
+	return dt, dir
+}
+
+func runDBTool(t *testing.T, dt *DatabaseTools, name string, arguments map[string]interface{}) interface{} {
+	t.Helper()
+
+	result, err := dt.ExecuteTool(context.Background(), name, arguments)
+	if err != nil {
+		t.Fatalf("%s: %v", name, err)
+	}
+	if result.IsError {
+		t.Fatalf("%s returned error result: %+v", name, result.Content)
+	}
+	return result.StructuredContent
+}
+
+func TestDBConnect(t *testing.T) {
+	dt, dir := newTestDatabaseTools(t)
+
+	if aliases := dt.GetAliases(); len(aliases) != 1 || aliases[0] != "test" {
+		t.Errorf("aliases = %v, want [test]", aliases)
+	}
+	if dialect, err := dt.Dialect("test"); err != nil || dialect.Name() != "SQLite" {
+		t.Errorf("dialect = %v, %v, want SQLite", dialect, err)
+	}
+
+	tests := []struct {
+		name    string
+		driver  string
+		dsn     string
+		allowed bool
+	}{
+		{name: "file in allowed dir", driver: "sqlite3", dsn: filepath.Join(dir, "other.db"), allowed: true},
+		{name: "file uri in allowed dir", driver: "sqlite3", dsn: "file:" + filepath.Join(dir, "uri.db") + "?cache=shared", allowed: true},
+		{name: "memory", driver: "sqlite3", dsn: ":memory:", allowed: true},
+		{name: "memory uri", driver: "sqlite3", dsn: "file:mem?mode=memory&cache=shared", allowed: true},
+		{name: "outside allowed dir", driver: "sqlite3", dsn: filepath.Join(t.TempDir(), "outside.db")},
+		{name: "dotdot", driver: "sqlite3", dsn: dir + "/../outside.db"},
+		{name: "file uri outside", driver: "sqlite3", dsn: "file:/etc/passwd?mode=ro"},
+		{name: "file uri with authority", driver: "sqlite3", dsn: "file://localhost/etc/passwd"},
+		{name: "percent encoded", driver: "sqlite3", dsn: "file:" + dir + "/%2E%2E/outside.db"},
+		{name: "unsupported driver", driver: "oracle", dsn: "x"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := dt.ExecuteTool(context.Background(), "db_connect", map[string]interface{}{"driver": tt.driver, "dsn": tt.dsn, "alias": "other"})
+			if tt.allowed && err != nil {
+				t.Errorf("db_connect(%q) = %v, want success", tt.dsn, err)
+			}
+			if !tt.allowed && err == nil {
+				t.Errorf("db_connect(%q) succeeded, want error", tt.dsn)
+			}
+		})
+	}
+}
+
+func TestDBQuery(t *testing.T) {
+	dt, _ := newTestDatabaseTools(t)
+
+	result := runDBTool(t, dt, "db_query", map[string]interface{}{
+		"alias": "test",
+		"sql":   "SELECT name FROM customers ORDER BY id;",
+	}).(DBQueryResult)
+	if result.RowCount != 2 || result.Rows[0]["name"] != "alice" || result.Limited {
+		t.Errorf("db_query = %+v", result)
+	}
+
+	limited := runDBTool(t, dt, "db_query", map[string]interface{}{
+		"alias": "test",
+		"sql":   "SELECT * FROM orders",
+		"limit": float64(2),
+	}).(DBQueryResult)
+	if limited.RowCount != 2 || !limited.Limited {
+		t.Errorf("limited db_query = %+v", limited)
+	}
+
+	plan := runDBTool(t, dt, "db_query", map[string]interface{}{
+		"alias":   "test",
+		"sql":     "SELECT * FROM orders WHERE customer_id = 1",
+		"explain": true,
+	}).(DBQueryResult)
+	if plan.RowCount == 0 {
+		t.Errorf("explain returned no rows: %+v", plan)
+	}
+
+	rejected := []struct {
+		name string
+		sql  string
+	}{
+		{name: "not select", sql: "DELETE FROM orders"},
+		{name: "stacked statement", sql: "SELECT 1; DELETE FROM orders"},
+		{name: "empty", sql: " ; "},
+	}
+	for _, tt := range rejected {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := dt.ExecuteTool(context.Background(), "db_query", map[string]interface{}{"alias": "test", "sql": tt.sql}); err == nil {
+				t.Errorf("db_query(%q) succeeded", tt.sql)
+			}
+		})
+	}
+	if _, err := dt.ExecuteTool(context.Background(), "db_query", map[string]interface{}{"alias": "missing", "sql": "SELECT 1"}); err == nil {
+		t.Error("db_query on unknown alias succeeded")
+	}
+}
+
+func TestDBExecute(t *testing.T) {
+	dt, _ := newTestDatabaseTools(t)
+	ctx := context.Background()
+
+	inserted := runDBTool(t, dt, "db_execute", map[string]interface{}{
+		"alias": "test",
+		"sql":   "INSERT INTO customers (name) VALUES ('carol')",
+	}).(DBExecuteResult)
+	if inserted.RowsAffected != 1 || inserted.LastInsertID != 3 {
+		t.Errorf("insert = %+v", inserted)
+	}
+
+	updated := runDBTool(t, dt, "db_execute", map[string]interface{}{
+		"alias": "test",
+		"sql":   "UPDATE orders SET total = total + 1 WHERE customer_id = 1;",
+	}).(DBExecuteResult)
+	if updated.RowsAffected != 2 {
+		t.Errorf("update = %+v", updated)
+	}
+
+	rejected := []struct {
+		name string
+		sql  string
+	}{
+		{name: "stacked statement", sql: "UPDATE orders SET total = 0 WHERE id = 1; DROP TABLE customers"},
+		{name: "comment hiding statement", sql: "DELETE FROM orders WHERE id = 1 /* x */; DELETE FROM orders"},
+		{name: "truncate", sql: "TRUNCATE TABLE orders"},
+		{name: "alter", sql: "ALTER TABLE orders ADD COLUMN note TEXT"},
+		{name: "alter after comment", sql: "/* x */ ALTER TABLE orders ADD COLUMN note TEXT"},
+		{name: "attach", sql: "ATTACH DATABASE '" + filepath.Join(t.TempDir(), "x.db") + "' AS x"},
+		{name: "attach lower case after comment", sql: "-- x\nattach '/tmp/x.db' as x"},
+		{name: "vacuum into", sql: "VACUUM INTO '" + filepath.Join(t.TempDir(), "copy.db") + "'"},
+		{name: "attach after bang comment", sql: "/*! */ ATTACH DATABASE '" + filepath.Join(t.TempDir(), "x.db") + "' AS x"},
+		{name: "vacuum into after bang comment", sql: "/*!x*/VACUUM INTO '" + filepath.Join(t.TempDir(), "copy.db") + "'"},
+		{name: "no leading keyword", sql: "(DELETE FROM orders)"},
+	}
+	for _, tt := range rejected {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := dt.ExecuteTool(ctx, "db_execute", map[string]interface{}{"alias": "test", "sql": tt.sql}); err == nil {
+				t.Errorf("db_execute(%q) succeeded", tt.sql)
+			}
+		})
+	}
+
+	// 被拒绝的语句不能有任何部分被执行
+	count := runDBTool(t, dt, "db_query", map[string]interface{}{"alias": "test", "sql": "SELECT COUNT(*) AS n FROM orders"}).(DBQueryResult)
+	if count.Rows[0]["n"] != int64(3) {
+		t.Errorf("orders count = %v, want 3", count.Rows[0]["n"])
+	}
+}
+
+func TestDBExecuteEstimatedRows(t *testing.T) {
+	dt, _ := newTestDatabaseTools(t)
+	ctx := context.Background()
+	db, dialect, _ := dt.getConnection("test")
+
+	tests := []struct {
+		sql  string
+		want interface{}
+	}{
+		{sql: "UPDATE orders SET total = 0 WHERE customer_id = 1", want: int64(2)},
+		{sql: "DELETE FROM orders", want: int64(3)},
+		{sql: "INSERT INTO customers (name) VALUES ('carol'), ('dave')", want: int64(2)},
+		{sql: "DELETE FROM orders WHERE id = 100", want: int64(0)},
+		{sql: "CREATE TABLE notes (id INTEGER)", want: "不适用（非数据修改语句）"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.sql, func(t *testing.T) {
+			field := dt.estimatedRowsField(ctx, db, dialect, leadingKeyword(tt.sql, false), tt.sql)
+			if field.Name != "estimated_rows" || field.Value != tt.want {
+				t.Errorf("estimated_rows = %+v, want %v", field, tt.want)
+			}
+		})
+	}
+
+	// 估算时的试执行已经回滚
+	count := runDBTool(t, dt, "db_query", map[string]interface{}{"alias": "test", "sql": "SELECT (SELECT COUNT(*) FROM orders) + (SELECT COUNT(*) FROM customers) AS n"}).(DBQueryResult)
+	if count.Rows[0]["n"] != int64(5) {
+		t.Errorf("row count = %v after estimating, want 5", count.Rows[0]["n"])
+	}
+	if _, err := db.ExecContext(ctx, "SELECT * FROM notes"); err == nil {
+		t.Errorf("table notes exists after estimating")
+	}
+
+	// 语句本身有错误时给出原因
+	field := dt.estimatedRowsField(ctx, db, dialect, "UPDATE", "UPDATE missing SET x = 1")
+	if value, _ := field.Value.(string); !strings.HasPrefix(value, "无法估算") {
+		t.Errorf("estimated_rows = %+v, want explanation", field)
+	}
+}
+
+func TestDBListTables(t *testing.T) {
+	dt, _ := newTestDatabaseTools(t)
+
+	result := runDBTool(t, dt, "db_list_tables", map[string]interface{}{"alias": "test"}).(DBListTablesResult)
+	if result.Dialect != "SQLite" {
+		t.Errorf("dialect = %s, want SQLite", result.Dialect)
+	}
+	got := map[string]string{}
+	for _, table := range result.Tables {
+		got[table.Name] = table.Type
+	}
+	want := map[string]string{"customers": "TABLE", "orders": "TABLE", "order_totals": "VIEW"}
+	for name, typ := range want {
+		if got[name] != typ {
+			t.Errorf("tables = %v, want %s %s", got, name, typ)
+		}
+	}
+	if len(got) != len(want) {
+		t.Errorf("tables = %v, want %v", got, want)
+	}
+
+	// DDL使缓存失效
+	runDBTool(t, dt, "db_execute", map[string]interface{}{"alias": "test", "sql": "CREATE TABLE notes (id INTEGER PRIMARY KEY)"})
+	result = runDBTool(t, dt, "db_list_tables", map[string]interface{}{"alias": "test"}).(DBListTablesResult)
+	if len(result.Tables) != 4 {
+		t.Errorf("tables after CREATE TABLE = %+v, want 4 entries", result.Tables)
+	}
+}
+
+func TestDBDescribeTable(t *testing.T) {
+	dt, _ := newTestDatabaseTools(t)
+
+	result := runDBTool(t, dt, "db_describe_table", map[string]interface{}{"alias": "test", "table": "orders"}).(DBDescribeTableResult)
+	if result.Name != "orders" || len(result.Columns) != 3 {
+		t.Fatalf("db_describe_table = %+v", result.TableSchema)
+	}
+	if strings.Join(result.PrimaryKey, ",") != "id" {
+		t.Errorf("primary key = %v, want [id]", result.PrimaryKey)
+	}
+	total := result.Columns[2]
+	if total.Name != "total" || !total.Nullable || total.Default == nil || *total.Default != "0" {
+		t.Errorf("total column = %+v", total)
+	}
+	if customer := result.Columns[1]; customer.Nullable {
+		t.Errorf("customer_id column = %+v, want NOT NULL", customer)
+	}
+	foundIndex := false
+	for _, index := range result.Indexes {
+		if index.Name == "idx_orders_customer" && strings.Join(index.Columns, ",") == "customer_id" {
+			foundIndex = true
+		}
+	}
+	if !foundIndex {
+		t.Errorf("indexes = %+v, want idx_orders_customer", result.Indexes)
+	}
+	if len(result.ForeignKeys) != 1 || result.ForeignKeys[0].ReferencedTable != "customers" {
+		t.Errorf("foreign keys = %+v", result.ForeignKeys)
+	}
+
+	customers := runDBTool(t, dt, "db_describe_table", map[string]interface{}{"alias": "test", "table": "customers"}).(DBDescribeTableResult)
+	if len(customers.ReferencedBy) != 1 || customers.ReferencedBy[0].Table != "orders" {
+		t.Errorf("referenced by = %+v", customers.ReferencedBy)
+	}
+
+	if _, err := dt.ExecuteTool(context.Background(), "db_describe_table", map[string]interface{}{"alias": "test", "table": "missing"}); err == nil {
+		t.Error("db_describe_table on missing table succeeded")
+	}
+}
+
+func TestDBRelationships(t *testing.T) {
+	dt, _ := newTestDatabaseTools(t)
+
+	result := runDBTool(t, dt, "db_relationships", map[string]interface{}{"alias": "test"}).(DBRelationshipsResult)
+	if len(result.Relationships) != 1 {
+		t.Fatalf("relationships = %+v", result.Relationships)
+	}
+	fk := result.Relationships[0]
+	if fk.Table != "orders" || strings.Join(fk.Columns, ",") != "customer_id" ||
+		fk.ReferencedTable != "customers" || strings.Join(fk.ReferencedColumns, ",") != "id" {
+		t.Errorf("relationship = %+v", fk)
+	}
+
+	filtered := runDBTool(t, dt, "db_relationships", map[string]interface{}{"alias": "test", "table": "order_totals"}).(DBRelationshipsResult)
+	if len(filtered.Relationships) != 0 {
+		t.Errorf("filtered relationships = %+v, want none", filtered.Relationships)
+	}
+}
@@ -0,0 +1,377 @@
+package tools
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Dialect 数据库方言，封装不同数据库在元数据查询、标识符引用、行数限制和执行计划上的差异
+type Dialect interface {
+	// Name 方言名称，用于提示词和结果展示
+	Name() string
+	// ListObjectsQuery 列出当前库（schema）中的表和视图，结果依次为名称、类型（TABLE/VIEW）
+	ListObjectsQuery() string
+	// ListColumnsQuery 列出指定表的列的查询，唯一参数为表名
+	// 结果依次为列名、类型、是否可空（YES/NO）、默认值
+	ListColumnsQuery() string
+	// PrimaryKeyQuery 列出指定表的主键列，唯一参数为表名，按主键中的顺序排列
+	PrimaryKeyQuery() string
+	// IndexesQuery 列出指定表的索引，唯一参数为表名
+	// 结果依次为索引名、是否唯一（1/0）、列名（表达式索引为NULL），按索引名和列顺序排列
+	IndexesQuery() string
+	// ForeignKeysQuery 列出当前库中的所有外键
+	// 结果依次为约束名、表名、列名、引用的表名、引用的列名，同一约束的列按顺序相邻排列
+	ForeignKeysQuery() string
+	// RowCountsQuery 各表的估算行数，结果依次为表名、行数；返回空字符串时逐表COUNT(*)
+	RowCountsQuery() string
+	// QuoteIdentifier 引用标识符
+	QuoteIdentifier(name string) string
+	// LimitQuery 为简单的SELECT语句加上行数限制
+	LimitQuery(query string, limit int) string
+	// Explain 在独占的连接上获取查询的执行计划，最多返回limit行
+	Explain(ctx context.Context, conn *sql.Conn, query string, limit int) ([]string, []map[string]interface{}, error)
+	// EstimateRows 在独占的连接上估算写语句影响的行数，用于执行前的确认，不会留下任何修改
+	EstimateRows(ctx context.Context, conn *sql.Conn, statement string) (int64, error)
+}
+
+// ColumnInfo 表中一列的信息
+type ColumnInfo struct {
+	Name       string  `json:"name"`
+	Type       string  `json:"type"`
+	Nullable   bool    `json:"nullable"`
+	Default    *string `json:"default,omitempty"`
+	PrimaryKey bool    `json:"primary_key"`
+}
+
+// dialects 驱动名称到方言的映射
+var dialects = map[string]Dialect{
+	"mysql":     mysqlDialect{},
+	"postgres":  postgresDialect{},
+	"pgx":       postgresDialect{},
+	"sqlite3":   sqliteDialect{},
+	"sqlserver": sqlServerDialect{},
+	"mssql":     sqlServerDialect{},
+}
+
+// DialectFor 获取驱动对应的方言
+func DialectFor(driver string) (Dialect, error) {
+	dialect, ok := dialects[driver]
+	if !ok {
+		return nil, fmt.Errorf("不支持的数据库驱动: %s，支持的驱动: %s", driver, strings.Join(SupportedDrivers(), ", "))
+	}
+	return dialect, nil
+}
+
+// SupportedDrivers 获取已注册且有对应方言的驱动名称（已排序）
+func SupportedDrivers() []string {
+	var drivers []string
+	for _, driver := range sql.Drivers() {
+		if _, ok := dialects[driver]; ok {
+			drivers = append(drivers, driver)
+		}
+	}
+	sort.Strings(drivers)
+	return drivers
+}
+
+// mysqlDialect MySQL方言
+type mysqlDialect struct{}
+
+func (mysqlDialect) Name() string { return "MySQL" }
+
+func (mysqlDialect) ListObjectsQuery() string {
+	return "SELECT table_name, CASE table_type WHEN 'VIEW' THEN 'VIEW' ELSE 'TABLE' END FROM information_schema.tables WHERE table_schema = DATABASE() ORDER BY table_name"
+}
+
+func (mysqlDialect) ListColumnsQuery() string {
+	return "SELECT column_name, column_type, is_nullable, column_default FROM information_schema.columns WHERE table_schema = DATABASE() AND table_name = ? ORDER BY ordinal_position"
+}
+
+func (mysqlDialect) PrimaryKeyQuery() string {
+	return "SELECT column_name FROM information_schema.key_column_usage WHERE table_schema = DATABASE() AND table_name = ? AND constraint_name = 'PRIMARY' ORDER BY ordinal_position"
+}
+
+func (mysqlDialect) IndexesQuery() string {
+	return "SELECT index_name, CASE WHEN non_unique = 0 THEN 1 ELSE 0 END, column_name FROM information_schema.statistics WHERE table_schema = DATABASE() AND table_name = ? ORDER BY index_name, seq_in_index"
+}
+
+func (mysqlDialect) ForeignKeysQuery() string {
+	return "SELECT constraint_name, table_name, column_name, referenced_table_name, referenced_column_name FROM information_schema.key_column_usage WHERE table_schema = DATABASE() AND referenced_table_name IS NOT NULL ORDER BY table_name, constraint_name, ordinal_position"
+}
+
+// RowCountsQuery InnoDB的table_rows来自采样统计，是估算值
+func (mysqlDialect) RowCountsQuery() string {
+	return "SELECT table_name, table_rows FROM information_schema.tables WHERE table_schema = DATABASE() AND table_type = 'BASE TABLE'"
+}
+
+func (mysqlDialect) QuoteIdentifier(name string) string {
+	return "`" + strings.ReplaceAll(name, "`", "``") + "`"
+}
+
+func (mysqlDialect) LimitQuery(query string, limit int) string {
+	return appendLimit(query, limit)
+}
+
+func (mysqlDialect) Explain(ctx context.Context, conn *sql.Conn, query string, limit int) ([]string, []map[string]interface{}, error) {
+	return queryPlan(ctx, conn, "EXPLAIN "+query, limit)
+}
+
+// EstimateRows MySQL不能回滚非事务表（如MyISAM）上的修改，改为读取执行计划中第一张表的rows估算值
+func (mysqlDialect) EstimateRows(ctx context.Context, conn *sql.Conn, statement string) (int64, error) {
+	_, plan, err := queryPlan(ctx, conn, "EXPLAIN "+statement, 1)
+	if err != nil {
+		return 0, err
+	}
+	if len(plan) == 0 || plan[0]["rows"] == nil {
+		return 0, fmt.Errorf("执行计划中没有行数估算")
+	}
+	rows, err := strconv.ParseInt(fmt.Sprint(plan[0]["rows"]), 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("解析执行计划中的行数失败: %v", err)
+	}
+	return rows, nil
+}
+
+// postgresDialect PostgreSQL方言
+type postgresDialect struct{}
+
+func (postgresDialect) Name() string { return "PostgreSQL" }
+
+func (postgresDialect) ListObjectsQuery() string {
+	return "SELECT table_name, CASE table_type WHEN 'VIEW' THEN 'VIEW' ELSE 'TABLE' END FROM information_schema.tables WHERE table_schema = current_schema() ORDER BY table_name"
+}
+
+func (postgresDialect) ListColumnsQuery() string {
+	return "SELECT column_name, data_type, is_nullable, column_default FROM information_schema.columns WHERE table_schema = current_schema() AND table_name = $1 ORDER BY ordinal_position"
+}
+
+func (postgresDialect) PrimaryKeyQuery() string {
+	return `SELECT kcu.column_name
+FROM information_schema.table_constraints tc
+JOIN information_schema.key_column_usage kcu
+  ON kcu.constraint_schema = tc.constraint_schema AND kcu.constraint_name = tc.constraint_name AND kcu.table_name = tc.table_name
+WHERE tc.table_schema = current_schema() AND tc.table_name = $1 AND tc.constraint_type = 'PRIMARY KEY'
+ORDER BY kcu.ordinal_position`
+}
+
+func (postgresDialect) IndexesQuery() string {
+	return `SELECT i.relname, CASE WHEN ix.indisunique THEN 1 ELSE 0 END, a.attname
+FROM pg_index ix
+JOIN pg_class t ON t.oid = ix.indrelid
+JOIN pg_class i ON i.oid = ix.indexrelid
+JOIN pg_namespace n ON n.oid = t.relnamespace
+CROSS JOIN LATERAL unnest(ix.indkey) WITH ORDINALITY AS k(attnum, ord)
+LEFT JOIN pg_attribute a ON a.attrelid = t.oid AND a.attnum = k.attnum
+WHERE n.nspname = current_schema() AND t.relname = $1
+ORDER BY i.relname, k.ord`
+}
+
+func (postgresDialect) ForeignKeysQuery() string {
+	return `SELECT kcu.constraint_name, kcu.table_name, kcu.column_name, ref.table_name, ref.column_name
+FROM information_schema.referential_constraints rc
+JOIN information_schema.key_column_usage kcu
+  ON kcu.constraint_schema = rc.constraint_schema AND kcu.constraint_name = rc.constraint_name
+JOIN information_schema.key_column_usage ref
+  ON ref.constraint_schema = rc.unique_constraint_schema AND ref.constraint_name = rc.unique_constraint_name
+  AND ref.ordinal_position = kcu.position_in_unique_constraint
+WHERE kcu.table_schema = current_schema()
+ORDER BY kcu.table_name, kcu.constraint_name, kcu.ordinal_position`
+}
+
+// RowCountsQuery reltuples由VACUUM/ANALYZE维护，从未分析过的表为-1，视为未知
+func (postgresDialect) RowCountsQuery() string {
+	return `SELECT c.relname, CASE WHEN c.reltuples < 0 THEN NULL ELSE c.reltuples::bigint END
+FROM pg_class c
+JOIN pg_namespace n ON n.oid = c.relnamespace
+WHERE n.nspname = current_schema() AND c.relkind IN ('r', 'p')`
+}
+
+func (postgresDialect) QuoteIdentifier(name string) string {
+	return quoteDouble(name)
+}
+
+func (postgresDialect) LimitQuery(query string, limit int) string {
+	return appendLimit(query, limit)
+}
+
+func (postgresDialect) Explain(ctx context.Context, conn *sql.Conn, query string, limit int) ([]string, []map[string]interface{}, error) {
+	return queryPlan(ctx, conn, "EXPLAIN "+query, limit)
+}
+
+func (postgresDialect) EstimateRows(ctx context.Context, conn *sql.Conn, statement string) (int64, error) {
+	return dryRunRowsAffected(ctx, conn, statement)
+}
+
+// sqliteDialect SQLite方言
+type sqliteDialect struct{}
+
+func (sqliteDialect) Name() string { return "SQLite" }
+
+func (sqliteDialect) ListObjectsQuery() string {
+	return "SELECT name, UPPER(type) FROM sqlite_master WHERE type IN ('table', 'view') AND name NOT LIKE 'sqlite_%' ORDER BY name"
+}
+
+func (sqliteDialect) ListColumnsQuery() string {
+	return `SELECT name, type, CASE WHEN "notnull" = 0 THEN 'YES' ELSE 'NO' END, dflt_value FROM pragma_table_info(?) ORDER BY cid`
+}
+
+func (sqliteDialect) PrimaryKeyQuery() string {
+	return "SELECT name FROM pragma_table_info(?) WHERE pk > 0 ORDER BY pk"
+}
+
+func (sqliteDialect) IndexesQuery() string {
+	return `SELECT il.name, il."unique", ii.name FROM pragma_index_list(?) il JOIN pragma_index_info(il.name) ii ORDER BY il.name, ii.seqno`
+}
+
+// ForeignKeysQuery SQLite的外键没有名称，用表名和序号代替；省略引用列时引用的是对方主键，"to"为NULL
+func (sqliteDialect) ForeignKeysQuery() string {
+	return `SELECT m.name || '_fk_' || p.id, m.name, p."from", p."table", p."to"
+FROM sqlite_master m
+JOIN pragma_foreign_key_list(m.name) p
+WHERE m.type = 'table'
+ORDER BY m.name, p.id, p.seq`
+}
+
+// RowCountsQuery SQLite只在ANALYZE后才有统计信息，改为逐表计数
+func (sqliteDialect) RowCountsQuery() string {
+	return ""
+}
+
+func (sqliteDialect) QuoteIdentifier(name string) string {
+	return quoteDouble(name)
+}
+
+func (sqliteDialect) LimitQuery(query string, limit int) string {
+	return appendLimit(query, limit)
+}
+
+func (sqliteDialect) Explain(ctx context.Context, conn *sql.Conn, query string, limit int) ([]string, []map[string]interface{}, error) {
+	return queryPlan(ctx, conn, "EXPLAIN QUERY PLAN "+query, limit)
+}
+
+func (sqliteDialect) EstimateRows(ctx context.Context, conn *sql.Conn, statement string) (int64, error) {
+	return dryRunRowsAffected(ctx, conn, statement)
+}
+
+// sqlServerDialect SQL Server方言
+type sqlServerDialect struct{}
+
+func (sqlServerDialect) Name() string { return "SQL Server" }
+
+func (sqlServerDialect) ListObjectsQuery() string {
+	return "SELECT table_name, CASE table_type WHEN 'VIEW' THEN 'VIEW' ELSE 'TABLE' END FROM information_schema.tables WHERE table_schema = SCHEMA_NAME() ORDER BY table_name"
+}
+
+func (sqlServerDialect) ListColumnsQuery() string {
+	return "SELECT column_name, data_type, is_nullable, column_default FROM information_schema.columns WHERE table_schema = SCHEMA_NAME() AND table_name = @p1 ORDER BY ordinal_position"
+}
+
+func (sqlServerDialect) PrimaryKeyQuery() string {
+	return `SELECT kcu.column_name
+FROM information_schema.table_constraints tc
+JOIN information_schema.key_column_usage kcu
+  ON kcu.constraint_schema = tc.constraint_schema AND kcu.constraint_name = tc.constraint_name
+WHERE tc.table_schema = SCHEMA_NAME() AND tc.table_name = @p1 AND tc.constraint_type = 'PRIMARY KEY'
+ORDER BY kcu.ordinal_position`
+}
+
+func (sqlServerDialect) IndexesQuery() string {
+	return `SELECT i.name, CASE WHEN i.is_unique = 1 THEN 1 ELSE 0 END, c.name
+FROM sys.indexes i
+JOIN sys.index_columns ic ON ic.object_id = i.object_id AND ic.index_id = i.index_id
+JOIN sys.columns c ON c.object_id = ic.object_id AND c.column_id = ic.column_id
+WHERE i.object_id = OBJECT_ID(QUOTENAME(SCHEMA_NAME()) + '.' + QUOTENAME(@p1)) AND i.name IS NOT NULL AND ic.is_included_column = 0
+ORDER BY i.name, ic.key_ordinal`
+}
+
+func (sqlServerDialect) ForeignKeysQuery() string {
+	return `SELECT fk.name, tp.name, cp.name, tr.name, cr.name
+FROM sys.foreign_keys fk
+JOIN sys.foreign_key_columns fkc ON fkc.constraint_object_id = fk.object_id
+JOIN sys.tables tp ON tp.object_id = fkc.parent_object_id
+JOIN sys.columns cp ON cp.object_id = fkc.parent_object_id AND cp.column_id = fkc.parent_column_id
+JOIN sys.tables tr ON tr.object_id = fkc.referenced_object_id
+JOIN sys.columns cr ON cr.object_id = fkc.referenced_object_id AND cr.column_id = fkc.referenced_column_id
+WHERE tp.schema_id = SCHEMA_ID()
+ORDER BY tp.name, fk.name, fkc.constraint_column_id`
+}
+
+func (sqlServerDialect) RowCountsQuery() string {
+	return `SELECT t.name, SUM(p.rows)
+FROM sys.tables t
+JOIN sys.partitions p ON p.object_id = t.object_id AND p.index_id IN (0, 1)
+WHERE t.schema_id = SCHEMA_ID()
+GROUP BY t.name`
+}
+
+func (sqlServerDialect) QuoteIdentifier(name string) string {
+	return "[" + strings.ReplaceAll(name, "]", "]]") + "]"
+}
+
+// LimitQuery SQL Server没有LIMIT，在SELECT（或SELECT DISTINCT）之后插入TOP
+func (sqlServerDialect) LimitQuery(query string, limit int) string {
+	query = strings.TrimRight(strings.TrimSpace(query), ";")
+	top := "TOP (" + strconv.Itoa(limit) + ") "
+	upper := strings.ToUpper(query)
+	for _, prefix := range []string{"SELECT DISTINCT ", "SELECT "} {
+		if strings.HasPrefix(upper, prefix) {
+			return query[:len(prefix)] + top + query[len(prefix):]
+		}
+	}
+	return query
+}
+
+// Explain SHOWPLAN是会话级设置，开启期间语句只返回执行计划而不执行，结束后必须在同一连接上关闭
+func (sqlServerDialect) Explain(ctx context.Context, conn *sql.Conn, query string, limit int) ([]string, []map[string]interface{}, error) {
+	if _, err := conn.ExecContext(ctx, "SET SHOWPLAN_TEXT ON"); err != nil {
+		return nil, nil, fmt.Errorf("开启SHOWPLAN失败: %v", err)
+	}
+	defer conn.ExecContext(context.WithoutCancel(ctx), "SET SHOWPLAN_TEXT OFF")
+
+	return queryPlan(ctx, conn, query, limit)
+}
+
+func (sqlServerDialect) EstimateRows(ctx context.Context, conn *sql.Conn, statement string) (int64, error) {
+	return dryRunRowsAffected(ctx, conn, statement)
+}
+
+// appendLimit 在语句末尾追加LIMIT子句
+func appendLimit(query string, limit int) string {
+	return strings.TrimRight(strings.TrimSpace(query), ";") + " LIMIT " + strconv.Itoa(limit)
+}
+
+// quoteDouble 使用双引号引用标识符
+func quoteDouble(name string) string {
+	return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+}
+
+// dryRunRowsAffected 在事务中执行语句，读取影响的行数后回滚
+// 回滚不会撤销序列（自增）值的消耗
+func dryRunRowsAffected(ctx context.Context, conn *sql.Conn, statement string) (int64, error) {
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("开启事务失败: %v", err)
+	}
+	defer tx.Rollback()
+
+	result, err := tx.ExecContext(ctx, statement)
+	if err != nil {
+		return 0, fmt.Errorf("试执行SQL失败: %v", err)
+	}
+	return result.RowsAffected()
+}
+
+// queryPlan 执行获取执行计划的语句并读取结果
+func queryPlan(ctx context.Context, conn *sql.Conn, query string, limit int) ([]string, []map[string]interface{}, error) {
+	rows, err := conn.QueryContext(ctx, query)
+	if err != nil {
+		return nil, nil, fmt.Errorf("获取执行计划失败: %v", err)
+	}
+	defer rows.Close()
+
+	return scanRows(rows, limit)
+}
@@ -0,0 +1,259 @@
+package tools
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"mcp-ai-server/internal/config"
+)
+
+// nonPublicPrefixes IsPrivate、IsLoopback等方法之外的非公网地址段
+var nonPublicPrefixes = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),      // 本网络
+	netip.MustParsePrefix("100.64.0.0/10"),  // 运营商级NAT
+	netip.MustParsePrefix("192.0.0.0/24"),   // IETF协议分配
+	netip.MustParsePrefix("198.18.0.0/15"),  // 基准测试
+	netip.MustParsePrefix("240.0.0.0/4"),    // 保留地址和广播地址
+	netip.MustParsePrefix("64:ff9b::/96"),   // NAT64，可映射到任意IPv4地址
+	netip.MustParsePrefix("64:ff9b:1::/48"), // 本地NAT64
+	netip.MustParsePrefix("2002::/16"),      // 6to4，可映射到任意IPv4地址
+}
+
+// EgressPolicy 网络工具的出站访问策略
+// 请求前检查URL的协议和主机名，连接时检查实际拨号的IP，重定向的每一跳重新检查，并按主机限制请求速率
+// 为nil时只限制协议
+type EgressPolicy struct {
+	allowedHosts    []string
+	deniedHosts     []string
+	allowedPrefixes []netip.Prefix
+	deniedPrefixes  []netip.Prefix
+	blockPrivate    bool
+	maxRedirects    int
+	limiter         *hostRateLimiter
+}
+
+// NewEgressPolicy 根据网络工具配置创建出站访问策略
+func NewEgressPolicy(configManager *config.NetworkConfigManager) *EgressPolicy {
+	settings := configManager.GetEgressSettings()
+	return &EgressPolicy{
+		allowedHosts:    settings.AllowedHosts,
+		deniedHosts:     settings.DeniedHosts,
+		allowedPrefixes: configManager.GetAllowedPrefixes(),
+		deniedPrefixes:  configManager.GetDeniedPrefixes(),
+		blockPrivate:    configManager.BlockPrivate(),
+		maxRedirects:    configManager.GetMaxRedirects(),
+		limiter:         newHostRateLimiter(settings.RateLimit.RequestsPerMinute, settings.RateLimit.Burst),
+	}
+}
+
+// CheckURL 检查请求URL是否允许访问，并占用一次目标主机的请求配额
+func (p *EgressPolicy) CheckURL(target *url.URL) error {
+	if target.Scheme != "http" && target.Scheme != "https" {
+		return fmt.Errorf("只允许HTTP和HTTPS协议")
+	}
+	host := strings.ToLower(strings.TrimSuffix(target.Hostname(), "."))
+	if host == "" {
+		return fmt.Errorf("URL缺少主机名")
+	}
+	if p == nil {
+		return nil
+	}
+
+	if err := p.checkHost(host); err != nil {
+		return err
+	}
+	if !p.limiter.Allow(host) {
+		return fmt.Errorf("对主机 %s 的请求过于频繁，请稍后再试", host)
+	}
+	return nil
+}
+
+// checkHost 按主机名规则检查，IP形式的主机同时按IP规则检查
+func (p *EgressPolicy) checkHost(host string) error {
+	if matchAnyGlob(p.deniedHosts, host) {
+		return fmt.Errorf("主机 %s 被出站策略禁止", host)
+	}
+
+	addr, err := netip.ParseAddr(host)
+	isIP := err == nil
+	if isIP {
+		if err := p.CheckIP(addr); err != nil {
+			return err
+		}
+	}
+
+	if len(p.allowedHosts) > 0 && !matchAnyGlob(p.allowedHosts, host) {
+		// IP形式的主机位于allowed_cidrs内时同样允许
+		if !isIP || !prefixesContain(p.allowedPrefixes, addr.Unmap()) {
+			return fmt.Errorf("主机 %s 不在出站策略允许的范围内", host)
+		}
+	}
+	return nil
+}
+
+// CheckIP 检查实际连接的IP地址
+func (p *EgressPolicy) CheckIP(addr netip.Addr) error {
+	if p == nil {
+		return nil
+	}
+	addr = addr.Unmap()
+
+	if prefixesContain(p.deniedPrefixes, addr) {
+		return fmt.Errorf("目标地址 %s 被出站策略禁止", addr)
+	}
+	if prefixesContain(p.allowedPrefixes, addr) {
+		return nil
+	}
+	if p.blockPrivate && !isPublicAddr(addr) {
+		return fmt.Errorf("目标地址 %s 不是公网地址，被出站策略禁止", addr)
+	}
+	return nil
+}
+
+// newHTTPClient 创建遵守出站策略的HTTP客户端
+// 连接在拨号时检查解析后的IP，DNS重绑定无法绕过；不使用环境变量中的代理，否则检查的会是代理地址
+func (p *EgressPolicy) newHTTPClient(timeout time.Duration) *http.Client {
+	if p == nil {
+		return &http.Client{Timeout: timeout}
+	}
+
+	dialer := &net.Dialer{
+		Timeout:   30 * time.Second,
+		KeepAlive: 30 * time.Second,
+		Control:   p.control,
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+
+	return &http.Client{
+		Timeout:       timeout,
+		Transport:     transport,
+		CheckRedirect: p.checkRedirect,
+	}
+}
+
+// control 在建立连接前检查实际拨号的地址
+func (p *EgressPolicy) control(network, address string, _ syscall.RawConn) error {
+	addrPort, err := netip.ParseAddrPort(address)
+	if err != nil {
+		return fmt.Errorf("无法解析连接地址 %s: %v", address, err)
+	}
+	return p.CheckIP(addrPort.Addr())
+}
+
+// checkRedirect 限制重定向次数，并对每个重定向目标重新检查
+func (p *EgressPolicy) checkRedirect(req *http.Request, via []*http.Request) error {
+	if len(via) > p.maxRedirects {
+		return fmt.Errorf("重定向次数超过上限 %d", p.maxRedirects)
+	}
+	if err := p.CheckURL(req.URL); err != nil {
+		return fmt.Errorf("重定向到 %s 被拒绝: %v", req.URL.Redacted(), err)
+	}
+	return nil
+}
+
+// isPublicAddr 判断地址是否属于公网单播地址
+func isPublicAddr(addr netip.Addr) bool {
+	if !addr.IsValid() || addr.IsUnspecified() || addr.IsLoopback() || addr.IsPrivate() ||
+		addr.IsLinkLocalUnicast() || addr.IsLinkLocalMulticast() || addr.IsInterfaceLocalMulticast() ||
+		addr.IsMulticast() {
+		return false
+	}
+	return !prefixesContain(nonPublicPrefixes, addr)
+}
+
+// prefixesContain 判断地址是否位于任一网段内
+func prefixesContain(prefixes []netip.Prefix, addr netip.Addr) bool {
+	for _, prefix := range prefixes {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// hostRateLimiter 按主机的令牌桶限流器
+// 为nil时不限制
+type hostRateLimiter struct {
+	mu      sync.Mutex
+	rate    float64 // 每秒补充的令牌数
+	burst   float64
+	buckets map[string]*tokenBucket
+}
+
+// tokenBucket 单个主机的令牌桶
+type tokenBucket struct {
+	tokens  float64
+	updated time.Time
+}
+
+// maxRateLimitHosts 记录的主机数超过此值时清理已回满的令牌桶
+const maxRateLimitHosts = 4096
+
+// newHostRateLimiter 创建限流器，requestsPerMinute为0时返回nil
+func newHostRateLimiter(requestsPerMinute, burst int) *hostRateLimiter {
+	if requestsPerMinute <= 0 {
+		return nil
+	}
+	return &hostRateLimiter{
+		rate:    float64(requestsPerMinute) / 60,
+		burst:   float64(max(burst, 1)),
+		buckets: make(map[string]*tokenBucket),
+	}
+}
+
+// Allow 消耗主机的一个令牌，没有可用令牌时返回false
+func (l *hostRateLimiter) Allow(host string) bool {
+	if l == nil {
+		return true
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	bucket, ok := l.buckets[host]
+	if !ok {
+		if len(l.buckets) >= maxRateLimitHosts {
+			l.prune(now)
+		}
+		bucket = &tokenBucket{tokens: l.burst, updated: now}
+		l.buckets[host] = bucket
+	}
+
+	bucket.tokens = min(l.burst, bucket.tokens+now.Sub(bucket.updated).Seconds()*l.rate)
+	bucket.updated = now
+	if bucket.tokens < 1 {
+		return false
+	}
+	bucket.tokens--
+	return true
+}
+
+// prune 删除已经回满的令牌桶，它们与新建的令牌桶等价
+func (l *hostRateLimiter) prune(now time.Time) {
+	for host, bucket := range l.buckets {
+		if bucket.tokens+now.Sub(bucket.updated).Seconds()*l.rate >= l.burst {
+			delete(l.buckets, host)
+		}
+	}
+}
+
+// parseRequestURL 解析并检查请求URL
+func (t *NetworkTools) parseRequestURL(rawURL string) (*url.URL, error) {
+	target, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("无效的URL: %v", err)
+	}
+	if err := t.egress.CheckURL(target); err != nil {
+		return nil, err
+	}
+	return target, nil
+}
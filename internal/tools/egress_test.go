@@ -0,0 +1,300 @@
+package tools
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func mustPrefixes(cidrs ...string) []netip.Prefix {
+	prefixes := make([]netip.Prefix, 0, len(cidrs))
+	for _, cidr := range cidrs {
+		prefixes = append(prefixes, netip.MustParsePrefix(cidr))
+	}
+	return prefixes
+}
+
+func TestEgressPolicyCheckIP(t *testing.T) {
+	blockPrivate := &EgressPolicy{blockPrivate: true}
+	withCIDRs := &EgressPolicy{
+		blockPrivate:    true,
+		allowedPrefixes: mustPrefixes("10.20.0.0/16", "203.0.113.0/24"),
+		deniedPrefixes:  mustPrefixes("10.20.5.0/24", "203.0.113.7/32"),
+	}
+	allowPrivate := &EgressPolicy{deniedPrefixes: mustPrefixes("198.51.100.0/24")}
+
+	tests := []struct {
+		name    string
+		policy  *EgressPolicy
+		addr    string
+		allowed bool
+	}{
+		{name: "public", policy: blockPrivate, addr: "93.184.216.34", allowed: true},
+		{name: "public ipv6", policy: blockPrivate, addr: "2606:2800:220:1::1", allowed: true},
+		{name: "loopback", policy: blockPrivate, addr: "127.0.0.1"},
+		{name: "ipv6 loopback", policy: blockPrivate, addr: "::1"},
+		{name: "private 10/8", policy: blockPrivate, addr: "10.1.2.3"},
+		{name: "private 172.16/12", policy: blockPrivate, addr: "172.16.0.1"},
+		{name: "private 192.168/16", policy: blockPrivate, addr: "192.168.1.1"},
+		{name: "metadata", policy: blockPrivate, addr: "169.254.169.254"},
+		{name: "ipv4-mapped metadata", policy: blockPrivate, addr: "::ffff:169.254.169.254"},
+		{name: "unspecified", policy: blockPrivate, addr: "0.0.0.0"},
+		{name: "cgnat", policy: blockPrivate, addr: "100.64.0.1"},
+		{name: "unique local ipv6", policy: blockPrivate, addr: "fd00::1"},
+		{name: "link local ipv6", policy: blockPrivate, addr: "fe80::1"},
+		{name: "nat64", policy: blockPrivate, addr: "64:ff9b::a9fe:a9fe"},
+		{name: "6to4", policy: blockPrivate, addr: "2002:a9fe:a9fe::1"},
+		{name: "multicast", policy: blockPrivate, addr: "224.0.0.1"},
+		{name: "broadcast", policy: blockPrivate, addr: "255.255.255.255"},
+		// allowed_cidrs放行内部网段，denied_cidrs优先于allowed_cidrs
+		{name: "allowed cidr", policy: withCIDRs, addr: "10.20.1.1", allowed: true},
+		{name: "denied inside allowed cidr", policy: withCIDRs, addr: "10.20.5.1"},
+		{name: "denied public inside allowed cidr", policy: withCIDRs, addr: "203.0.113.7"},
+		{name: "private outside allowed cidr", policy: withCIDRs, addr: "10.21.0.1"},
+		{name: "block_private off", policy: allowPrivate, addr: "127.0.0.1", allowed: true},
+		{name: "denied cidr with block_private off", policy: allowPrivate, addr: "198.51.100.1"},
+		{name: "nil policy", addr: "127.0.0.1", allowed: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.policy.CheckIP(netip.MustParseAddr(tt.addr))
+			if (err == nil) != tt.allowed {
+				t.Errorf("CheckIP(%s) = %v, allowed %v", tt.addr, err, tt.allowed)
+			}
+		})
+	}
+}
+
+func TestEgressPolicyCheckHost(t *testing.T) {
+	defaults := &EgressPolicy{
+		blockPrivate: true,
+		deniedHosts:  []string{"localhost", "*.localhost", "*.internal", "metadata.google.internal"},
+	}
+	allowList := &EgressPolicy{
+		blockPrivate:    true,
+		allowedHosts:    []string{"api.example.com", "*.cdn.example.com"},
+		deniedHosts:     []string{"blocked.cdn.example.com"},
+		allowedPrefixes: mustPrefixes("10.20.0.0/16"),
+		deniedPrefixes:  mustPrefixes("10.20.5.0/24"),
+	}
+
+	tests := []struct {
+		name    string
+		policy  *EgressPolicy
+		host    string
+		allowed bool
+	}{
+		{name: "public host", policy: defaults, host: "example.com", allowed: true},
+		{name: "denied host", policy: defaults, host: "localhost"},
+		{name: "denied glob", policy: defaults, host: "app.localhost"},
+		{name: "metadata host", policy: defaults, host: "metadata.google.internal"},
+		{name: "private ip host", policy: defaults, host: "10.0.0.1"},
+		{name: "metadata ip host", policy: defaults, host: "169.254.169.254"},
+		{name: "public ip host", policy: defaults, host: "93.184.216.34", allowed: true},
+		{name: "allowed host", policy: allowList, host: "api.example.com", allowed: true},
+		{name: "allowed glob", policy: allowList, host: "img.cdn.example.com", allowed: true},
+		{name: "not in allow list", policy: allowList, host: "example.org"},
+		// denied_hosts优先于allowed_hosts
+		{name: "denied inside allowed glob", policy: allowList, host: "blocked.cdn.example.com"},
+		// 不在allowed_hosts中的IP主机位于allowed_cidrs内时同样允许
+		{name: "ip in allowed cidr", policy: allowList, host: "10.20.1.1", allowed: true},
+		{name: "ip in denied cidr", policy: allowList, host: "10.20.5.1"},
+		{name: "public ip not in allow list", policy: allowList, host: "93.184.216.34"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.policy.checkHost(tt.host)
+			if (err == nil) != tt.allowed {
+				t.Errorf("checkHost(%s) = %v, allowed %v", tt.host, err, tt.allowed)
+			}
+		})
+	}
+}
+
+func TestEgressPolicyCheckURL(t *testing.T) {
+	policy := &EgressPolicy{blockPrivate: true, deniedHosts: []string{"localhost"}}
+
+	tests := []struct {
+		rawURL  string
+		allowed bool
+	}{
+		{rawURL: "https://example.com/path", allowed: true},
+		{rawURL: "http://EXAMPLE.com./", allowed: true},
+		{rawURL: "ftp://example.com/"},
+		{rawURL: "file:///etc/passwd"},
+		{rawURL: "http:///path"},
+		{rawURL: "http://LOCALHOST./"},
+		{rawURL: "http://[::ffff:127.0.0.1]/"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.rawURL, func(t *testing.T) {
+			target, err := url.Parse(tt.rawURL)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := policy.CheckURL(target); (err == nil) != tt.allowed {
+				t.Errorf("CheckURL(%s) = %v, allowed %v", tt.rawURL, err, tt.allowed)
+			}
+		})
+	}
+}
+
+func TestHostRateLimiter(t *testing.T) {
+	if limiter := newHostRateLimiter(0, 10); limiter != nil || !limiter.Allow("example.com") {
+		t.Fatalf("newHostRateLimiter(0) = %v, want nil limiter that allows everything", limiter)
+	}
+
+	limiter := newHostRateLimiter(60, 2)
+	for i := 0; i < 2; i++ {
+		if !limiter.Allow("a.example.com") {
+			t.Fatalf("request %d within burst rejected", i+1)
+		}
+	}
+	if limiter.Allow("a.example.com") {
+		t.Error("request beyond burst allowed")
+	}
+	// 每个主机有独立的令牌桶
+	if !limiter.Allow("b.example.com") {
+		t.Error("other host rejected")
+	}
+
+	// 60次每分钟即每秒补充一个令牌，且不超过burst
+	limiter.buckets["a.example.com"].updated = time.Now().Add(-time.Second)
+	if !limiter.Allow("a.example.com") {
+		t.Error("request after refill rejected")
+	}
+	if limiter.Allow("a.example.com") {
+		t.Error("second request after refilling one token allowed")
+	}
+	limiter.buckets["a.example.com"].updated = time.Now().Add(-time.Hour)
+	for i := 0; i < 2; i++ {
+		if !limiter.Allow("a.example.com") {
+			t.Fatalf("request %d after full refill rejected", i+1)
+		}
+	}
+	if limiter.Allow("a.example.com") {
+		t.Error("refill exceeded burst")
+	}
+
+	// 清理只删除已经回满的令牌桶
+	limiter.buckets["b.example.com"].updated = time.Now().Add(-time.Hour)
+	limiter.prune(time.Now())
+	if _, ok := limiter.buckets["b.example.com"]; ok {
+		t.Error("refilled bucket not pruned")
+	}
+	if _, ok := limiter.buckets["a.example.com"]; !ok {
+		t.Error("empty bucket pruned")
+	}
+
+	// 通过CheckURL的请求占用目标主机的配额
+	policy := &EgressPolicy{limiter: newHostRateLimiter(60, 1)}
+	target, _ := url.Parse("https://example.com/")
+	if err := policy.CheckURL(target); err != nil {
+		t.Fatal(err)
+	}
+	if err := policy.CheckURL(target); err == nil {
+		t.Error("CheckURL beyond rate limit allowed")
+	}
+}
+
+// newRedirectServer /hop/N 重定向到 /hop/N-1，/hop/0 返回ok；/to?url=X 重定向到X
+func newRedirectServer(t *testing.T) *httptest.Server {
+	t.Helper()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if target := r.URL.Query().Get("url"); r.URL.Path == "/to" && target != "" {
+			http.Redirect(w, r, target, http.StatusFound)
+			return
+		}
+		n, err := strconv.Atoi(strings.TrimPrefix(r.URL.Path, "/hop/"))
+		if err != nil {
+			http.NotFound(w, r)
+			return
+		}
+		if n == 0 {
+			io.WriteString(w, "ok")
+			return
+		}
+		http.Redirect(w, r, fmt.Sprintf("/hop/%d", n-1), http.StatusFound)
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestEgressHTTPClientRedirects(t *testing.T) {
+	server := newRedirectServer(t)
+
+	// 测试服务器监听在回环地址，通过allowed_cidrs放行
+	policy := &EgressPolicy{
+		blockPrivate:    true,
+		allowedPrefixes: mustPrefixes("127.0.0.1/32"),
+		deniedHosts:     []string{"denied.test"},
+		maxRedirects:    3,
+	}
+	client := policy.newHTTPClient(5 * time.Second)
+	serverURL, _ := url.Parse(server.URL)
+
+	tests := []struct {
+		name    string
+		path    string
+		wantErr string
+	}{
+		{name: "no redirect", path: "/hop/0"},
+		{name: "at max_redirects", path: "/hop/3"},
+		{name: "over max_redirects", path: "/hop/4", wantErr: "重定向次数超过上限 3"},
+		{name: "redirect to denied host", path: "/to?url=" + url.QueryEscape("http://denied.test:"+serverURL.Port()+"/hop/0"), wantErr: "被出站策略禁止"},
+		{name: "redirect to private ip", path: "/to?url=" + url.QueryEscape("http://[::1]:"+serverURL.Port()+"/hop/0"), wantErr: "不是公网地址"},
+		{name: "redirect to metadata", path: "/to?url=" + url.QueryEscape("http://169.254.169.254/latest/meta-data/"), wantErr: "不是公网地址"},
+		{name: "redirect to other scheme", path: "/to?url=" + url.QueryEscape("ftp://example.com/"), wantErr: "只允许HTTP和HTTPS协议"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, err := client.Get(server.URL + tt.path)
+			if tt.wantErr != "" {
+				if err == nil {
+					resp.Body.Close()
+					t.Fatalf("GET %s succeeded, want error containing %q", tt.path, tt.wantErr)
+				}
+				if !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("GET %s error = %v, want %q", tt.path, err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("GET %s: %v", tt.path, err)
+			}
+			defer resp.Body.Close()
+			if body, _ := io.ReadAll(resp.Body); string(body) != "ok" {
+				t.Errorf("GET %s body = %q, want ok", tt.path, body)
+			}
+		})
+	}
+}
+
+func TestEgressHTTPClientChecksDialedAddress(t *testing.T) {
+	server := newRedirectServer(t)
+	serverURL, _ := url.Parse(server.URL)
+
+	// 主机名通过了检查，解析出的回环地址在拨号时被拒绝
+	policy := &EgressPolicy{blockPrivate: true}
+	target := "http://localhost:" + serverURL.Port() + "/hop/0"
+	parsed, _ := url.Parse(target)
+	if err := policy.CheckURL(parsed); err != nil {
+		t.Fatalf("CheckURL(%s) = %v, want the host name to pass", target, err)
+	}
+	resp, err := policy.newHTTPClient(5 * time.Second).Get(target)
+	if err == nil {
+		resp.Body.Close()
+		t.Fatalf("GET %s succeeded, want dial rejected", target)
+	}
+	if !strings.Contains(err.Error(), "不是公网地址") {
+		t.Errorf("GET %s error = %v, want private address rejection", target, err)
+	}
+}
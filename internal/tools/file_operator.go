@@ -0,0 +1,112 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"time"
+
+	"mcp-ai-server/internal/config"
+	"mcp-ai-server/internal/mcp"
+)
+
+// fileOperator ai_file_manager执行文件操作时使用的接口
+// 执行前先用recordingFileOperator预演，得到需要用户确认的操作清单
+type fileOperator interface {
+	MkdirAll(ctx context.Context, path string) error
+	ExecuteTool(ctx context.Context, name string, arguments map[string]interface{}) (*mcp.ToolCallResult, error)
+}
+
+// systemFileOperator 实际执行文件操作
+// 工具调用经过ToolManager，与客户端直接调用一样受策略约束并写入审计日志
+type systemFileOperator struct {
+	executor        ToolExecutor
+	securityManager *config.SecurityManager
+	auditor         *Auditor
+}
+
+// MkdirAll 检查路径后创建目录，结果作为mkdir操作写入审计日志
+func (o *systemFileOperator) MkdirAll(ctx context.Context, path string) (err error) {
+	start := time.Now()
+	var result *mcp.ToolCallResult
+	defer func() {
+		o.auditor.Record(ctx, "mkdir", map[string]interface{}{"path": path}, time.Since(start), result, err)
+	}()
+
+	if err := o.securityManager.IsPathAllowed(path); err != nil {
+		return fmt.Errorf("路径访问被拒绝: %v", err)
+	}
+	if err := os.MkdirAll(path, 0755); err != nil {
+		return err
+	}
+	result = mcp.NewTextResult(fmt.Sprintf("目录 %s 已创建", path))
+	return nil
+}
+
+// ExecuteTool 通过工具执行器调用工具，isError结果（如策略拒绝或用户拒绝确认）转换为错误
+func (o *systemFileOperator) ExecuteTool(ctx context.Context, name string, arguments map[string]interface{}) (*mcp.ToolCallResult, error) {
+	if o.executor == nil {
+		return nil, fmt.Errorf("未设置工具执行器，无法执行 %s", name)
+	}
+	result, err := o.executor.ExecuteTool(ctx, name, arguments)
+	if err == nil && result != nil && result.IsError && len(result.Content) > 0 {
+		return result, fmt.Errorf("%s", result.Content[0].Text)
+	}
+	return result, err
+}
+
+// recordingFileOperator 只记录将要执行的文件操作
+type recordingFileOperator struct {
+	directories []string
+	files       []string
+	commands    []string
+}
+
+// MkdirAll 记录将要创建的目录
+func (o *recordingFileOperator) MkdirAll(ctx context.Context, path string) error {
+	o.directories = append(o.directories, path)
+	return nil
+}
+
+// ExecuteTool 记录将要写入的文件和执行的命令，只读工具不记录
+func (o *recordingFileOperator) ExecuteTool(ctx context.Context, name string, arguments map[string]interface{}) (*mcp.ToolCallResult, error) {
+	switch name {
+	case "file_write":
+		path, _ := arguments["path"].(string)
+		o.files = append(o.files, path)
+	case "command_execute":
+		command, _ := arguments["command"].(string)
+		o.commands = append(o.commands, fmt.Sprintf("%s %v", command, arguments["args"]))
+	}
+	return mcp.NewTextResult(""), nil
+}
+
+// empty 预演中没有任何会修改文件系统的操作
+func (o *recordingFileOperator) empty() bool {
+	return len(o.directories) == 0 && len(o.files) == 0 && len(o.commands) == 0
+}
+
+// pendingAction 将预演记录转换为待确认的操作
+func (o *recordingFileOperator) pendingAction(instruction, targetPath string) PendingAction {
+	files := append([]string(nil), o.files...)
+	sort.Strings(files)
+
+	fields := []ActionField{
+		{Name: "target_path", Title: "目标路径", Type: "string", Value: targetPath},
+		{Name: "files", Title: "将要写入的文件", Type: "string", Value: summarizeList(files, 20)},
+		{Name: "file_count", Title: "文件数", Type: "integer", Value: len(o.files)},
+	}
+	if len(o.directories) > 0 {
+		fields = append(fields, ActionField{Name: "directories", Title: "将要创建的目录", Type: "string", Value: summarizeList(o.directories, 20)})
+	}
+	if len(o.commands) > 0 {
+		fields = append(fields, ActionField{Name: "commands", Title: "将要执行的命令", Type: "string", Value: summarizeList(o.commands, 20)})
+	}
+
+	return PendingAction{
+		Tool:    "ai_file_manager",
+		Message: fmt.Sprintf("ai_file_manager 即将在 %s 写入 %d 个文件（指令: %s），是否继续？", targetPath, len(o.files), instruction),
+		Fields:  fields,
+	}
+}
@@ -3,6 +3,9 @@ package tools
 import (
 	"context"
 	"fmt"
+	"sort"
+	"sync"
+	"time"
 
 	"mcp-ai-server/internal/config"
 	"mcp-ai-server/internal/mcp"
@@ -15,8 +18,16 @@ type ToolExecutor interface {
 }
 
 // ToolManager 工具管理器
+// 工具可以在运行时启用、禁用、添加或移除，变化通过 SetToolsChangedHandler 设置的回调通知服务器
 type ToolManager struct {
 	toolMap         map[string]ToolExecutor // 工具名到执行器的映射
+	customTools     map[string]mcp.Tool     // 运行时添加的工具
+	disabled        map[string]bool         // 操作员禁用的工具
+	unavailable     map[string]bool         // 缺少依赖（如数据库连接）而暂不可用的工具
+	onToolsChanged  func()
+	policy          *PolicyEngine
+	auditor         *Auditor
+	mu              sync.RWMutex
 	securityManager *config.SecurityManager
 	systemTools     *SystemTools
 	networkTools    *NetworkTools
@@ -40,8 +51,43 @@ func NewToolManager(configPath string) (*ToolManager, error) {
 	}
 
 	networkTools := NewNetworkTools(securityManager)
+	networkConfig, err := config.NewNetworkConfigManager(configPath)
+	if err != nil {
+		return nil, fmt.Errorf("创建网络配置管理器失败: %v", err)
+	}
+	networkTools.SetEgressPolicy(NewEgressPolicy(networkConfig))
 	dataTools := NewDataTools(securityManager)
 	databaseTools := NewDatabaseTools(securityManager)
+	databaseConfig, err := config.NewDatabaseConfigManager(configPath)
+	if err != nil {
+		return nil, fmt.Errorf("创建数据库配置管理器失败: %v", err)
+	}
+	databaseTools.SetSchemaCacheTTL(databaseConfig.GetSchemaCacheTTL())
+
+	// 破坏性操作执行前通过elicitation请求用户确认
+	confirmationConfig, err := config.NewConfirmationConfigManager(configPath)
+	if err != nil {
+		return nil, fmt.Errorf("创建确认配置管理器失败: %v", err)
+	}
+	confirmer := NewConfirmer(confirmationConfig)
+	systemTools.SetConfirmer(confirmer)
+	databaseTools.SetConfirmer(confirmer)
+
+	// 工具调用策略，在所有工具执行前评估
+	policyConfig, err := config.NewPolicyConfigManager(configPath)
+	if err != nil {
+		return nil, fmt.Errorf("创建策略配置管理器失败: %v", err)
+	}
+
+	// 工具调用审计日志
+	auditConfig, err := config.NewAuditConfigManager(configPath)
+	if err != nil {
+		return nil, fmt.Errorf("创建审计配置管理器失败: %v", err)
+	}
+	auditor, err := NewAuditor(auditConfig)
+	if err != nil {
+		return nil, fmt.Errorf("创建审计日志失败: %v", err)
+	}
 
 	// 创建AI工具，传递配置文件路径和所有工具的引用
 	aiTools, err := NewAITools(configPath, databaseTools, systemTools, dataTools, networkTools)
@@ -77,19 +123,61 @@ func NewToolManager(configPath string) (*ToolManager, error) {
 		toolMap[tool.Name] = aiTools
 	}
 
-	return &ToolManager{
+	tm := &ToolManager{
 		toolMap:         toolMap,
+		customTools:     make(map[string]mcp.Tool),
+		disabled:        make(map[string]bool),
+		unavailable:     make(map[string]bool),
+		policy:          NewPolicyEngine(policyConfig),
+		auditor:         auditor,
 		securityManager: securityManager,
 		systemTools:     systemTools,
 		networkTools:    networkTools,
 		dataTools:       dataTools,
 		databaseTools:   databaseTools,
 		aiTools:         aiTools,
-	}, nil
+	}
+
+	// AI工具内部调用的其它工具同样经过策略检查和审计
+	aiTools.SetToolExecutor(tm)
+	aiTools.SetAuditor(auditor)
+
+	// 数据库工具只在存在可用连接时启用
+	tm.refreshDatabaseTools()
+	databaseTools.SetConnectionsChangedHandler(tm.refreshDatabaseTools)
+
+	return tm, nil
 }
 
-// GetTools 获取所有工具
+// GetTools 获取所有已启用的工具
 func (tm *ToolManager) GetTools() []mcp.Tool {
+	tm.mu.RLock()
+	defer tm.mu.RUnlock()
+
+	var tools []mcp.Tool
+	for _, tool := range tm.builtinTools() {
+		// 已移除、已禁用或暂不可用的工具不对外暴露
+		if _, exists := tm.toolMap[tool.Name]; exists && !tm.hidden(tool.Name) {
+			tools = append(tools, tool)
+		}
+	}
+
+	names := make([]string, 0, len(tm.customTools))
+	for name := range tm.customTools {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if !tm.hidden(name) {
+			tools = append(tools, tm.customTools[name])
+		}
+	}
+
+	return tools
+}
+
+// builtinTools 获取所有内置工具的定义
+func (tm *ToolManager) builtinTools() []mcp.Tool {
 	var tools []mcp.Tool
 
 	// 添加系统工具
@@ -111,15 +199,49 @@ func (tm *ToolManager) GetTools() []mcp.Tool {
 }
 
 // ExecuteTool 执行工具 - 优化版本，使用工具映射提高效率
-func (tm *ToolManager) ExecuteTool(ctx context.Context, name string, arguments map[string]interface{}) (*mcp.ToolCallResult, error) {
+// 每次调用（包括被拒绝的调用）都会写入审计日志
+func (tm *ToolManager) ExecuteTool(ctx context.Context, name string, arguments map[string]interface{}) (result *mcp.ToolCallResult, err error) {
+	start := time.Now()
+	callerCtx := ctx
+	defer func() {
+		tm.auditor.Record(callerCtx, name, arguments, time.Since(start), result, err)
+	}()
+
 	// 直接从映射表查找工具执行器
+	tm.mu.RLock()
 	executor, exists := tm.toolMap[name]
+	disabled := tm.disabled[name]
+	unavailable := tm.unavailable[name]
+	tm.mu.RUnlock()
+
 	if !exists {
 		return nil, fmt.Errorf("工具未找到: %s", name)
 	}
+	if disabled {
+		return nil, fmt.Errorf("工具已禁用: %s", name)
+	}
+	if unavailable {
+		return nil, fmt.Errorf("工具暂不可用: %s", name)
+	}
+
+	// 策略拒绝以isError结果返回，便于模型看到命中的规则
+	if err := tm.policy.Check(ctx, name, arguments); err != nil {
+		debugPrint("%v\n", err)
+		return mcp.NewToolErrorResult(err.Error()), nil
+	}
+	if timeout := tm.policy.Timeout(name); timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
 
-	// 执行工具
-	return executor.ExecuteTool(ctx, name, arguments)
+	// 执行工具，工具内部经过ToolManager的嵌套调用在审计记录中注明由该工具发起
+	// 结果和错误信息脱敏后返回，文件内容除外（见unredactedResultTools）
+	result, err = executor.ExecuteTool(withCallingTool(ctx, name), name, arguments)
+	if !unredactedResultTools[name] {
+		result = redactResult(result)
+	}
+	return result, redactError(err)
 }
 
 // GetTool 获取指定工具
@@ -133,6 +255,146 @@ func (tm *ToolManager) GetTool(name string) (mcp.Tool, bool) {
 	return mcp.Tool{}, false
 }
 
+// SetToolsChangedHandler 设置工具列表变化时的回调
+func (tm *ToolManager) SetToolsChangedHandler(handler func()) {
+	tm.mu.Lock()
+	defer tm.mu.Unlock()
+
+	tm.onToolsChanged = handler
+}
+
+// notifyToolsChanged 调用工具列表变化回调，不能在持有锁时调用
+func (tm *ToolManager) notifyToolsChanged() {
+	tm.mu.RLock()
+	handler := tm.onToolsChanged
+	tm.mu.RUnlock()
+
+	if handler != nil {
+		handler()
+	}
+}
+
+// EnableTool 启用工具
+func (tm *ToolManager) EnableTool(name string) error {
+	return tm.setToolsEnabled([]string{name}, true)
+}
+
+// DisableTool 禁用工具，禁用后工具不出现在工具列表中且无法调用
+func (tm *ToolManager) DisableTool(name string) error {
+	return tm.setToolsEnabled([]string{name}, false)
+}
+
+// setToolsEnabled 批量修改操作员设置的启用状态
+func (tm *ToolManager) setToolsEnabled(names []string, enabled bool) error {
+	return tm.setToolFlag(tm.disabled, names, !enabled)
+}
+
+// setToolsAvailable 批量修改工具的可用状态，与操作员设置的启用状态分开记录，互不覆盖
+func (tm *ToolManager) setToolsAvailable(names []string, available bool) error {
+	return tm.setToolFlag(tm.unavailable, names, !available)
+}
+
+// setToolFlag 批量设置或清除工具的禁用标记，工具列表有变化时只发送一次通知
+func (tm *ToolManager) setToolFlag(flags map[string]bool, names []string, set bool) error {
+	tm.mu.Lock()
+	for _, name := range names {
+		if _, exists := tm.toolMap[name]; !exists {
+			tm.mu.Unlock()
+			return fmt.Errorf("工具未找到: %s", name)
+		}
+	}
+
+	changed := false
+	for _, name := range names {
+		wasHidden := tm.hidden(name)
+		if set {
+			flags[name] = true
+		} else {
+			delete(flags, name)
+		}
+		if tm.hidden(name) != wasHidden {
+			changed = true
+		}
+	}
+	tm.mu.Unlock()
+
+	if changed {
+		debugPrint("工具 %v 可见状态已变更\n", names)
+		tm.notifyToolsChanged()
+	}
+	return nil
+}
+
+// hidden 判断工具是否被禁用或暂不可用，调用方需持有锁
+func (tm *ToolManager) hidden(name string) bool {
+	return tm.disabled[name] || tm.unavailable[name]
+}
+
+// AddTool 在运行时添加工具，调用时交给executor执行
+func (tm *ToolManager) AddTool(tool mcp.Tool, executor ToolExecutor) error {
+	if tool.Name == "" {
+		return fmt.Errorf("工具名称不能为空")
+	}
+	if executor == nil {
+		return fmt.Errorf("工具 %s 缺少执行器", tool.Name)
+	}
+
+	tm.mu.Lock()
+	if _, exists := tm.toolMap[tool.Name]; exists {
+		tm.mu.Unlock()
+		return fmt.Errorf("工具已存在: %s", tool.Name)
+	}
+	tm.toolMap[tool.Name] = executor
+	tm.customTools[tool.Name] = tool
+	tm.mu.Unlock()
+
+	tm.notifyToolsChanged()
+	return nil
+}
+
+// RemoveTool 在运行时移除工具
+func (tm *ToolManager) RemoveTool(name string) error {
+	tm.mu.Lock()
+	if _, exists := tm.toolMap[name]; !exists {
+		tm.mu.Unlock()
+		return fmt.Errorf("工具未找到: %s", name)
+	}
+	delete(tm.toolMap, name)
+	delete(tm.customTools, name)
+	delete(tm.disabled, name)
+	delete(tm.unavailable, name)
+	tm.mu.Unlock()
+
+	tm.notifyToolsChanged()
+	return nil
+}
+
+// refreshDatabaseTools 根据是否存在可用的数据库连接标记查询类工具是否可用，不影响操作员的禁用设置
+func (tm *ToolManager) refreshDatabaseTools() {
+	available := len(tm.databaseTools.GetAliases()) > 0
+	if err := tm.setToolsAvailable([]string{"db_query", "db_execute", "db_list_tables", "db_describe_table", "db_relationships"}, available); err != nil {
+		debugPrint("更新数据库工具状态失败: %v\n", err)
+	}
+}
+
+// GetPrompts 获取所有提示词
+func (tm *ToolManager) GetPrompts() []mcp.Prompt {
+	return tm.aiTools.GetPrompts()
+}
+
+// GetPrompt 渲染指定提示词
+func (tm *ToolManager) GetPrompt(ctx context.Context, name string, arguments map[string]string) (*mcp.GetPromptResult, error) {
+	return tm.aiTools.GetPrompt(ctx, name, arguments)
+}
+
+// GetResourceHandlers 获取内置资源处理器，键为URI scheme
+func (tm *ToolManager) GetResourceHandlers() map[string]mcp.ResourceHandler {
+	return map[string]mcp.ResourceHandler{
+		"file": NewFileResourceHandler(tm.securityManager, "."),
+		"db":   NewDatabaseResourceHandler(tm.databaseTools),
+	}
+}
+
 // GetSecurityManager 获取安全管理器
 func (tm *ToolManager) GetSecurityManager() *config.SecurityManager {
 	return tm.securityManager
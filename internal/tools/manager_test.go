@@ -0,0 +1,179 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"mcp-ai-server/internal/logger"
+	"mcp-ai-server/internal/mcp"
+)
+
+// newTestToolManager 只注册数据库工具的工具管理器，数据库工具还没有任何连接，
+// 返回的目录中已准备好测试数据库test.db
+func newTestToolManager(t *testing.T) (*ToolManager, *DatabaseTools, string) {
+	t.Helper()
+
+	prepared, dir := newTestDatabaseTools(t)
+	dt := NewDatabaseTools(prepared.securityManager)
+	t.Cleanup(func() {
+		for _, db := range dt.connections {
+			db.Close()
+		}
+	})
+
+	toolMap := make(map[string]ToolExecutor)
+	for _, tool := range dt.GetTools() {
+		toolMap[tool.Name] = dt
+	}
+	tm := &ToolManager{
+		toolMap:       toolMap,
+		customTools:   make(map[string]mcp.Tool),
+		disabled:      make(map[string]bool),
+		unavailable:   make(map[string]bool),
+		databaseTools: dt,
+	}
+	tm.refreshDatabaseTools()
+	dt.SetConnectionsChangedHandler(tm.refreshDatabaseTools)
+	return tm, dt, dir
+}
+
+// visibleTools 返回数据库工具中会出现在工具列表里的工具
+func visibleTools(tm *ToolManager) map[string]bool {
+	tm.mu.RLock()
+	defer tm.mu.RUnlock()
+
+	visible := make(map[string]bool)
+	for _, tool := range tm.databaseTools.GetTools() {
+		if !tm.hidden(tool.Name) {
+			visible[tool.Name] = true
+		}
+	}
+	return visible
+}
+
+func TestDatabaseToolsAvailability(t *testing.T) {
+	tm, _, dir := newTestToolManager(t)
+	ctx := context.Background()
+
+	notifications := 0
+	tm.SetToolsChangedHandler(func() { notifications++ })
+
+	visible := visibleTools(tm)
+	if !visible["db_connect"] || visible["db_query"] || visible["db_execute"] {
+		t.Fatalf("tools without connection = %v, want only db_connect", visible)
+	}
+	if _, err := tm.ExecuteTool(ctx, "db_query", map[string]interface{}{"alias": "test", "sql": "SELECT 1"}); err == nil {
+		t.Error("db_query without connection succeeded")
+	}
+
+	// 操作员在没有连接时禁用的工具，建立连接后仍保持禁用
+	if err := tm.DisableTool("db_execute"); err != nil {
+		t.Fatal(err)
+	}
+	if notifications != 0 {
+		t.Errorf("notifications = %d after disabling a hidden tool, want 0", notifications)
+	}
+
+	if _, err := tm.ExecuteTool(ctx, "db_connect", map[string]interface{}{
+		"driver": "sqlite3", "dsn": filepath.Join(dir, "test.db"), "alias": "test",
+	}); err != nil {
+		t.Fatalf("db_connect: %v", err)
+	}
+	visible = visibleTools(tm)
+	if !visible["db_query"] || !visible["db_describe_table"] || visible["db_execute"] {
+		t.Errorf("tools after connect = %v, want db_execute still disabled", visible)
+	}
+	if notifications != 1 {
+		t.Errorf("notifications = %d after connect, want 1", notifications)
+	}
+	if _, err := tm.ExecuteTool(ctx, "db_execute", map[string]interface{}{"alias": "test", "sql": "CREATE TABLE t (id INTEGER)"}); err == nil {
+		t.Error("disabled db_execute succeeded")
+	}
+
+	// 重新连接不会覆盖操作员的禁用
+	if err := tm.DisableTool("db_query"); err != nil {
+		t.Fatal(err)
+	}
+	tm.refreshDatabaseTools()
+	if visible = visibleTools(tm); visible["db_query"] || visible["db_execute"] {
+		t.Errorf("tools after refresh = %v, want db_query and db_execute disabled", visible)
+	}
+
+	if err := tm.EnableTool("db_execute"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := tm.ExecuteTool(ctx, "db_execute", map[string]interface{}{"alias": "test", "sql": "CREATE TABLE t (id INTEGER)"}); err != nil {
+		t.Errorf("re-enabled db_execute: %v", err)
+	}
+}
+
+// staticExecutor 返回固定结果和错误的执行器
+type staticExecutor struct {
+	result *mcp.ToolCallResult
+	err    error
+}
+
+func (e staticExecutor) GetTools() []mcp.Tool { return nil }
+
+func (e staticExecutor) ExecuteTool(ctx context.Context, name string, arguments map[string]interface{}) (*mcp.ToolCallResult, error) {
+	return e.result, e.err
+}
+
+func TestExecuteToolRedactsResults(t *testing.T) {
+	const literal = "hunter2-db-password"
+	logger.SetRedactor(logger.NewRedactor(logger.DefaultRedactPatterns(), []string{literal}))
+	t.Cleanup(func() { logger.SetRedactor(logger.NewRedactor(logger.DefaultRedactPatterns(), nil)) })
+
+	content := "api_key: sk-test-0123456789abcdef\nAuthorization: Bearer abc.def.ghi\npassword " + literal + "\n"
+	secrets := []string{"sk-test-0123456789abcdef", "abc.def.ghi", literal}
+	tm := &ToolManager{
+		toolMap: map[string]ToolExecutor{
+			"file_read":  staticExecutor{result: mcp.NewTextResult(content)},
+			"db_query":   staticExecutor{result: mcp.NewStructuredResult(map[string]interface{}{"rows": []interface{}{map[string]interface{}{"dsn": "user:" + literal + "@tcp(db)/app"}}})},
+			"http_fetch": staticExecutor{result: mcp.NewTextResult(content)},
+			"broken":     staticExecutor{err: fmt.Errorf("请求失败: Authorization: Bearer abc.def.ghi")},
+		},
+		customTools: make(map[string]mcp.Tool),
+		disabled:    make(map[string]bool),
+		unavailable: make(map[string]bool),
+	}
+	ctx := context.Background()
+
+	// 读出的文件内容必须原样返回，否则写回文件时会被改写
+	result, err := tm.ExecuteTool(ctx, "file_read", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Content[0].Text != content {
+		t.Errorf("file_read result = %q, want %q", result.Content[0].Text, content)
+	}
+
+	// 其他工具的文本和结构化结果中的密钥都被替换
+	for _, name := range []string{"db_query", "http_fetch"} {
+		result, err := tm.ExecuteTool(ctx, name, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		data, err := json.Marshal(result)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, secret := range secrets {
+			if strings.Contains(string(data), secret) {
+				t.Errorf("%s result contains %q: %s", name, secret, data)
+			}
+		}
+		if !strings.Contains(string(data), logger.RedactedText) {
+			t.Errorf("%s result = %s, want redaction marker", name, data)
+		}
+	}
+
+	_, err = tm.ExecuteTool(ctx, "broken", nil)
+	if err == nil || strings.Contains(err.Error(), "abc.def.ghi") {
+		t.Errorf("error = %v, want redacted", err)
+	}
+}
@@ -2,16 +2,16 @@ package tools
 
 import (
 	"context"
-	"encoding/json"
+	"encoding/base64"
 	"fmt"
 	"io"
 	"net/http"
-	"os"
 	"os/exec"
 	"strings"
 	"time"
 
 	"mcp-ai-server/internal/config"
+	"mcp-ai-server/internal/logger"
 	"mcp-ai-server/internal/mcp"
 )
 
@@ -19,12 +19,13 @@ import (
 type NetworkTools struct {
 	securityManager *config.SecurityManager
 	httpClient      *http.Client
+	egress          *EgressPolicy
 }
 
 // debugPrint 调试输出函数，避免在stdio模式下干扰JSON通信
 func debugPrint(format string, args ...interface{}) {
 	// 在stdio模式下，调试信息输出到stderr
-	fmt.Fprintf(os.Stderr, format, args...)
+	fmt.Fprintf(logger.Stderr, format, args...)
 }
 
 // NewNetworkTools 创建新的网络工具集合
@@ -37,11 +38,18 @@ func NewNetworkTools(securityManager *config.SecurityManager) *NetworkTools {
 	}
 }
 
+// SetEgressPolicy 设置出站访问策略，HTTP请求改用遵守该策略的客户端
+func (t *NetworkTools) SetEgressPolicy(policy *EgressPolicy) {
+	t.egress = policy
+	t.httpClient = policy.newHTTPClient(t.httpClient.Timeout)
+}
+
 // HTTPGetTool HTTP GET请求工具
 func (t *NetworkTools) HTTPGetTool() mcp.Tool {
 	return mcp.Tool{
-		Name:        "http_get",
-		Description: "发送HTTP GET请求",
+		Name:         "http_get",
+		Description:  "发送HTTP GET请求",
+		OutputSchema: httpResponseOutputSchema,
 		InputSchema: map[string]interface{}{
 			"type": "object",
 			"properties": map[string]interface{}{
@@ -66,8 +74,9 @@ func (t *NetworkTools) HTTPGetTool() mcp.Tool {
 // HTTPPostTool HTTP POST请求工具
 func (t *NetworkTools) HTTPPostTool() mcp.Tool {
 	return mcp.Tool{
-		Name:        "http_post",
-		Description: "发送HTTP POST请求",
+		Name:         "http_post",
+		Description:  "发送HTTP POST请求",
+		OutputSchema: httpResponseOutputSchema,
 		InputSchema: map[string]interface{}{
 			"type": "object",
 			"properties": map[string]interface{}{
@@ -96,8 +105,9 @@ func (t *NetworkTools) HTTPPostTool() mcp.Tool {
 // PingTool 网络连通性检查工具
 func (t *NetworkTools) PingTool() mcp.Tool {
 	return mcp.Tool{
-		Name:        "ping",
-		Description: "检查网络连通性",
+		Name:         "ping",
+		Description:  "检查网络连通性",
+		OutputSchema: commandOutputSchema,
 		InputSchema: map[string]interface{}{
 			"type": "object",
 			"properties": map[string]interface{}{
@@ -118,8 +128,9 @@ func (t *NetworkTools) PingTool() mcp.Tool {
 // DNSLookupTool DNS查询工具
 func (t *NetworkTools) DNSLookupTool() mcp.Tool {
 	return mcp.Tool{
-		Name:        "dns_lookup",
-		Description: "DNS域名解析",
+		Name:         "dns_lookup",
+		Description:  "DNS域名解析",
+		OutputSchema: commandOutputSchema,
 		InputSchema: map[string]interface{}{
 			"type": "object",
 			"properties": map[string]interface{}{
@@ -170,9 +181,9 @@ func (t *NetworkTools) executeHTTPGet(ctx context.Context, arguments map[string]
 		return nil, fmt.Errorf("url参数必须是字符串")
 	}
 
-	// 安全检查：只允许HTTP和HTTPS
-	if !strings.HasPrefix(urlStr, "http://") && !strings.HasPrefix(urlStr, "https://") {
-		return nil, fmt.Errorf("只允许HTTP和HTTPS协议")
+	// 安全检查：只允许HTTP和HTTPS，目标主机需要满足出站策略
+	if _, err := t.parseRequestURL(urlStr); err != nil {
+		return nil, fmt.Errorf("出站检查失败: %v", err)
 	}
 
 	// 创建请求
@@ -190,51 +201,58 @@ func (t *NetworkTools) executeHTTPGet(ctx context.Context, arguments map[string]
 		}
 	}
 
-	// 设置超时
+	// 设置超时，复制客户端避免并发请求互相覆盖超时设置
+	client := *t.httpClient
 	if timeout, ok := arguments["timeout"].(int); ok && timeout > 0 {
-		t.httpClient.Timeout = time.Duration(timeout) * time.Second
+		client.Timeout = time.Duration(timeout) * time.Second
 	} else {
 		// 为GET请求设置合理的默认超时时间
-		t.httpClient.Timeout = 60 * time.Second
+		client.Timeout = 60 * time.Second
 	}
 
 	// 发送请求
-	resp, err := t.httpClient.Do(req)
+	resp, err := client.Do(req)
 	if err != nil {
 		return nil, fmt.Errorf("请求失败: %v", err)
 	}
 	defer resp.Body.Close()
 
 	// 读取响应
-	body, err := io.ReadAll(resp.Body)
+	// 最多多读一个字节，超出上限时直接拒绝而不把整个响应读入内存
+	body, err := io.ReadAll(io.LimitReader(resp.Body, t.securityManager.MaxHTTPResponse()+1))
 	if err != nil {
 		return nil, fmt.Errorf("读取响应失败: %v", err)
 	}
 
 	// 检查响应大小
-	if err := t.securityManager.CheckCommandOutput(int64(len(body))); err != nil {
+	if err := t.securityManager.CheckHTTPResponse(int64(len(body))); err != nil {
 		return nil, fmt.Errorf("响应大小检查失败: %v", err)
 	}
 
 	// 构建响应信息
-	responseInfo := map[string]interface{}{
-		"status_code": resp.StatusCode,
-		"status":      resp.Status,
-		"headers":     resp.Header,
-		"body":        string(body),
-		"url":         urlStr,
-	}
-
-	responseJSON, _ := json.MarshalIndent(responseInfo, "", "  ")
-
-	return &mcp.ToolCallResult{
-		Content: []mcp.Content{
-			{
-				Type: "text",
-				Text: string(responseJSON),
-			},
-		},
-	}, nil
+	response := HTTPResponseResult{
+		StatusCode:  resp.StatusCode,
+		Status:      resp.Status,
+		Headers:     resp.Header,
+		URL:         urlStr,
+		ContentType: resp.Header.Get("Content-Type"),
+	}
+	if response.ContentType == "" {
+		response.ContentType = http.DetectContentType(body)
+	}
+	if isTextContent(response.ContentType, body) {
+		response.Body = string(body)
+		return mcp.NewStructuredResult(response), nil
+	}
+
+	// 非文本响应体不放入body，以内嵌资源的形式附加在内容中
+	result := mcp.NewStructuredResult(response)
+	result.Content = append(result.Content, mcp.NewEmbeddedResource(mcp.ResourceContents{
+		URI:      urlStr,
+		MimeType: response.ContentType,
+		Blob:     base64.StdEncoding.EncodeToString(body),
+	}))
+	return result, nil
 }
 
 // executeHTTPPost 执行HTTP POST请求
@@ -244,9 +262,9 @@ func (t *NetworkTools) executeHTTPPost(ctx context.Context, arguments map[string
 		return nil, fmt.Errorf("url参数必须是字符串")
 	}
 
-	// 安全检查：只允许HTTP和HTTPS
-	if !strings.HasPrefix(urlStr, "http://") && !strings.HasPrefix(urlStr, "https://") {
-		return nil, fmt.Errorf("只允许HTTP和HTTPS协议")
+	// 安全检查：只允许HTTP和HTTPS，目标主机需要满足出站策略
+	if _, err := t.parseRequestURL(urlStr); err != nil {
+		return nil, fmt.Errorf("出站检查失败: %v", err)
 	}
 
 	data := ""
@@ -279,17 +297,18 @@ func (t *NetworkTools) executeHTTPPost(ctx context.Context, arguments map[string
 		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
 	}
 
-	// 设置超时
+	// 设置超时，复制客户端避免并发请求互相覆盖超时设置
+	client := *t.httpClient
 	if timeout, ok := arguments["timeout"].(int); ok && timeout > 0 {
-		t.httpClient.Timeout = time.Duration(timeout) * time.Second
+		client.Timeout = time.Duration(timeout) * time.Second
 	} else {
 		// 为POST请求设置更长的默认超时时间（5分钟）
-		t.httpClient.Timeout = 300 * time.Second
+		client.Timeout = 300 * time.Second
 	}
 
 	// 记录请求开始时间和超时设置
 	startTime := time.Now()
-	timeoutDuration := t.httpClient.Timeout
+	timeoutDuration := client.Timeout
 	debugPrint("🚀 开始POST请求: %s\n", urlStr)
 	debugPrint("⏰ 超时设置: %v\n", timeoutDuration)
 	debugPrint("📊 请求数据大小: %d 字节\n", len(data))
@@ -304,7 +323,7 @@ func (t *NetworkTools) executeHTTPPost(ctx context.Context, arguments map[string
 			debugPrint("🔄 重试请求 (第%d次尝试)\n", attempt)
 		}
 
-		resp, requestErr = t.httpClient.Do(req)
+		resp, requestErr = client.Do(req)
 		requestDuration := time.Since(startTime)
 
 		if requestErr != nil {
@@ -325,36 +344,26 @@ func (t *NetworkTools) executeHTTPPost(ctx context.Context, arguments map[string
 	defer resp.Body.Close()
 
 	// 读取响应
-	body, err := io.ReadAll(resp.Body)
+	// 最多多读一个字节，超出上限时直接拒绝而不把整个响应读入内存
+	body, err := io.ReadAll(io.LimitReader(resp.Body, t.securityManager.MaxHTTPResponse()+1))
 	if err != nil {
 		return nil, fmt.Errorf("读取响应失败: %v", err)
 	}
 
 	// 检查响应大小
-	if err := t.securityManager.CheckCommandOutput(int64(len(body))); err != nil {
+	if err := t.securityManager.CheckHTTPResponse(int64(len(body))); err != nil {
 		return nil, fmt.Errorf("响应大小检查失败: %v", err)
 	}
 
 	// 构建响应信息
-	responseInfo := map[string]interface{}{
-		"status_code": resp.StatusCode,
-		"status":      resp.Status,
-		"headers":     resp.Header,
-		"body":        string(body),
-		"url":         urlStr,
-		"data":        data,
-	}
-
-	responseJSON, _ := json.MarshalIndent(responseInfo, "", "  ")
-
-	return &mcp.ToolCallResult{
-		Content: []mcp.Content{
-			{
-				Type: "text",
-				Text: string(responseJSON),
-			},
-		},
-	}, nil
+	return mcp.NewStructuredResult(HTTPResponseResult{
+		StatusCode: resp.StatusCode,
+		Status:     resp.Status,
+		Headers:    resp.Header,
+		Body:       string(body),
+		URL:        urlStr,
+		Data:       data,
+	}), nil
 }
 
 // executePing 执行ping命令
@@ -374,14 +383,7 @@ func (t *NetworkTools) executePing(ctx context.Context, arguments map[string]int
 	output, err := cmd.CombinedOutput()
 
 	if err != nil {
-		return &mcp.ToolCallResult{
-			Content: []mcp.Content{
-				{
-					Type: "text",
-					Text: fmt.Sprintf("Ping失败: %v\n输出: %s", err, string(output)),
-				},
-			},
-		}, nil
+		return commandFailureResult(host, "Ping失败", err, output), nil
 	}
 
 	// 检查输出大小
@@ -389,14 +391,7 @@ func (t *NetworkTools) executePing(ctx context.Context, arguments map[string]int
 		return nil, fmt.Errorf("输出大小检查失败: %v", err)
 	}
 
-	return &mcp.ToolCallResult{
-		Content: []mcp.Content{
-			{
-				Type: "text",
-				Text: string(output),
-			},
-		},
-	}, nil
+	return commandSuccessResult(host, output), nil
 }
 
 // executeDNSLookup 执行DNS查询
@@ -416,14 +411,7 @@ func (t *NetworkTools) executeDNSLookup(ctx context.Context, arguments map[strin
 	output, err := cmd.CombinedOutput()
 
 	if err != nil {
-		return &mcp.ToolCallResult{
-			Content: []mcp.Content{
-				{
-					Type: "text",
-					Text: fmt.Sprintf("DNS查询失败: %v\n输出: %s", err, string(output)),
-				},
-			},
-		}, nil
+		return commandFailureResult(domain, "DNS查询失败", err, output), nil
 	}
 
 	// 检查输出大小
@@ -431,14 +419,30 @@ func (t *NetworkTools) executeDNSLookup(ctx context.Context, arguments map[strin
 		return nil, fmt.Errorf("输出大小检查失败: %v", err)
 	}
 
-	return &mcp.ToolCallResult{
-		Content: []mcp.Content{
-			{
-				Type: "text",
-				Text: string(output),
-			},
-		},
-	}, nil
+	return commandSuccessResult(domain, output), nil
+}
+
+// commandSuccessResult 命令执行成功的结果，文本内容保持原始输出
+func commandSuccessResult(target string, output []byte) *mcp.ToolCallResult {
+	result := mcp.NewTextResult(string(output))
+	result.StructuredContent = CommandOutputResult{
+		Target:  target,
+		Success: true,
+		Output:  string(output),
+	}
+	return result
+}
+
+// commandFailureResult 命令执行失败的结果，标记isError并附带命令输出
+func commandFailureResult(target, summary string, err error, output []byte) *mcp.ToolCallResult {
+	result := mcp.NewToolErrorResult(fmt.Sprintf("%s: %v\n输出: %s", summary, err, string(output)))
+	result.StructuredContent = CommandOutputResult{
+		Target:  target,
+		Success: false,
+		Output:  string(output),
+		Error:   err.Error(),
+	}
+	return result
 }
 
 // GetTools and ExecuteTool methods
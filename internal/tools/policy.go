@@ -0,0 +1,247 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"mcp-ai-server/internal/config"
+	"mcp-ai-server/internal/mcp"
+)
+
+// PolicyDeniedError 工具调用被策略拒绝
+type PolicyDeniedError struct {
+	Tool      string
+	Client    string
+	Principal string
+	Rule      string
+	Message   string
+}
+
+func (e *PolicyDeniedError) Error() string {
+	caller := e.Principal
+	if caller == "" {
+		caller = e.Client
+	}
+	if caller == "" {
+		caller = "未知客户端"
+	}
+	text := fmt.Sprintf("策略拒绝: 规则 %s 禁止 %s 调用工具 %s", e.Rule, caller, e.Tool)
+	if e.Message != "" {
+		text += ": " + e.Message
+	}
+	return text
+}
+
+// PolicyEngine 在工具执行前按顺序评估策略规则，第一条命中的规则决定结果
+// 为nil时所有调用都允许
+type PolicyEngine struct {
+	configManager *config.PolicyConfigManager
+}
+
+// NewPolicyEngine 创建策略引擎
+func NewPolicyEngine(configManager *config.PolicyConfigManager) *PolicyEngine {
+	return &PolicyEngine{
+		configManager: configManager,
+	}
+}
+
+// Check 检查本次工具调用是否被允许，拒绝时返回 *PolicyDeniedError
+func (p *PolicyEngine) Check(ctx context.Context, tool string, arguments map[string]interface{}) error {
+	if p == nil || !p.configManager.IsEnabled() {
+		return nil
+	}
+
+	caller := callerFromContext(ctx)
+	denied := func(rule, message string) error {
+		err := &PolicyDeniedError{Tool: tool, Client: caller.client, Rule: rule, Message: message}
+		if caller.principal != nil {
+			err.Principal = caller.principal.Subject
+		}
+		return err
+	}
+
+	for _, rule := range p.configManager.GetRules() {
+		if !ruleMatches(rule, tool, caller, arguments) {
+			continue
+		}
+		if rule.Effect == config.PolicyEffectAllow {
+			return nil
+		}
+		return denied(rule.Name, rule.Message)
+	}
+
+	if p.configManager.GetDefaultEffect() == config.PolicyEffectDeny {
+		return denied("default", "没有规则允许此调用")
+	}
+	return nil
+}
+
+// Timeout 获取工具的执行超时，未配置时返回0
+func (p *PolicyEngine) Timeout(tool string) time.Duration {
+	if p == nil {
+		return 0
+	}
+	return p.configManager.GetToolTimeout(tool)
+}
+
+// policyCaller 发起工具调用的一方
+type policyCaller struct {
+	// client 初始化时声明的客户端名称
+	client string
+	// principal 连接认证后的主体，未启用认证时为nil
+	principal *mcp.Principal
+}
+
+// callerFromContext 从会话中获取调用方信息
+func callerFromContext(ctx context.Context) policyCaller {
+	var caller policyCaller
+	session, ok := mcp.SessionFromContext(ctx)
+	if !ok {
+		return caller
+	}
+	if info := session.ClientInfo(); info != nil {
+		caller.client = info.Name
+	}
+	caller.principal = session.Principal()
+	return caller
+}
+
+// ruleMatches 判断规则是否命中本次调用
+func ruleMatches(rule config.PolicyRule, tool string, caller policyCaller, arguments map[string]interface{}) bool {
+	if len(rule.Tools) > 0 && !matchAnyGlob(rule.Tools, tool) {
+		return false
+	}
+	if len(rule.Clients) > 0 && !matchAnyGlob(rule.Clients, caller.client) {
+		return false
+	}
+	if len(rule.Principals) > 0 && (caller.principal == nil || !matchAnyGlob(rule.Principals, caller.principal.Subject)) {
+		return false
+	}
+	for _, scope := range rule.Scopes {
+		if !caller.principal.HasScope(scope) {
+			return false
+		}
+	}
+	for name, condition := range rule.Arguments {
+		if !conditionMatches(condition, arguments[name]) {
+			return false
+		}
+	}
+	return true
+}
+
+// conditionMatches 判断参数值是否满足条件，数组参数要求每个元素都满足
+func conditionMatches(condition *config.ArgumentCondition, value interface{}) bool {
+	matched := true
+	if items, ok := value.([]interface{}); ok {
+		for _, item := range items {
+			if !valueMatches(condition, argumentString(item)) {
+				matched = false
+				break
+			}
+		}
+	} else {
+		matched = valueMatches(condition, argumentString(value))
+	}
+
+	if condition.Not {
+		return !matched
+	}
+	return matched
+}
+
+// valueMatches 判断单个参数值是否满足条件中配置的所有项
+func valueMatches(condition *config.ArgumentCondition, value string) bool {
+	if len(condition.In) > 0 && !containsValue(condition.In, value) {
+		return false
+	}
+	if re := condition.Regexp(); re != nil && !re.MatchString(value) {
+		return false
+	}
+	if len(condition.Hosts) > 0 && !matchAnyGlob(condition.Hosts, strings.ToLower(argumentHost(value))) {
+		return false
+	}
+	if len(condition.Extensions) > 0 && !containsValue(condition.Extensions, strings.ToLower(filepath.Ext(value))) {
+		return false
+	}
+	if len(condition.Paths) > 0 && !pathInAny(condition.Paths, value) {
+		return false
+	}
+	if condition.MaxLength > 0 && int64(len(value)) > int64(condition.MaxLength) {
+		return false
+	}
+	return true
+}
+
+// argumentString 将参数值转换为用于匹配的字符串
+func argumentString(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case float64, int, int64, bool:
+		return fmt.Sprint(v)
+	default:
+		data, err := json.Marshal(v)
+		if err != nil {
+			return fmt.Sprint(v)
+		}
+		return string(data)
+	}
+}
+
+// argumentHost 从URL中取出主机名，不是URL时整体作为主机名
+func argumentHost(value string) string {
+	if parsed, err := url.Parse(value); err == nil && parsed.Host != "" {
+		return parsed.Hostname()
+	}
+	return value
+}
+
+// pathInAny 判断路径是否位于任意一个目录内，两边都在解析符号链接后比较
+func pathInAny(roots []string, value string) bool {
+	if value == "" {
+		return false
+	}
+	resolved, err := config.ResolvePath(value)
+	if err != nil {
+		return false
+	}
+	for _, root := range roots {
+		resolvedRoot, err := config.ResolvePath(root)
+		if err != nil {
+			continue
+		}
+		if config.PathWithin(resolvedRoot, resolved) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchAnyGlob 判断值是否匹配任意一个glob模式
+func matchAnyGlob(patterns []string, value string) bool {
+	for _, pattern := range patterns {
+		if matched, _ := path.Match(pattern, value); matched {
+			return true
+		}
+	}
+	return false
+}
+
+// containsValue 判断列表中是否包含指定值
+func containsValue(list []string, value string) bool {
+	for _, item := range list {
+		if item == value {
+			return true
+		}
+	}
+	return false
+}
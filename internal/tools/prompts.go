@@ -0,0 +1,80 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+
+	"mcp-ai-server/internal/config"
+	"mcp-ai-server/internal/mcp"
+)
+
+// analysisPromptName 将分析类型映射到对应的提示词名称
+func analysisPromptName(analysisType string) string {
+	switch analysisType {
+	case "summary":
+		return config.PromptAnalyzeSummary
+	case "insights":
+		return config.PromptAnalyzeInsights
+	case "recommendations":
+		return config.PromptAnalyzeRecommendations
+	case "detailed":
+		return config.PromptAnalyzeDetailed
+	default:
+		return config.PromptAnalyzeGeneral
+	}
+}
+
+// GetPrompts 获取所有可用的MCP提示词
+func (c *AITools) GetPrompts() []mcp.Prompt {
+	if c.promptManager == nil {
+		return nil
+	}
+
+	var prompts []mcp.Prompt
+	for _, name := range c.promptManager.GetPromptNames() {
+		promptConfig, _ := c.promptManager.GetPrompt(name)
+		prompt := mcp.Prompt{
+			Name:        name,
+			Description: promptConfig.Description,
+		}
+		for _, arg := range promptConfig.Arguments {
+			prompt.Arguments = append(prompt.Arguments, mcp.PromptArgument{
+				Name:        arg.Name,
+				Description: arg.Description,
+				Required:    arg.Required,
+			})
+		}
+		prompts = append(prompts, prompt)
+	}
+	return prompts
+}
+
+// GetPrompt 渲染指定提示词，返回单条用户消息
+func (c *AITools) GetPrompt(ctx context.Context, name string, arguments map[string]string) (*mcp.GetPromptResult, error) {
+	if c.promptManager == nil {
+		return nil, fmt.Errorf("提示词配置未初始化")
+	}
+
+	promptConfig, exists := c.promptManager.GetPrompt(name)
+	if !exists {
+		return nil, fmt.Errorf("提示词不存在: %s", name)
+	}
+
+	text, err := c.promptManager.Render(name, arguments)
+	if err != nil {
+		return nil, err
+	}
+
+	return &mcp.GetPromptResult{
+		Description: promptConfig.Description,
+		Messages: []mcp.PromptMessage{
+			{
+				Role: "user",
+				Content: mcp.Content{
+					Type: "text",
+					Text: text,
+				},
+			},
+		},
+	}, nil
+}
@@ -0,0 +1,111 @@
+package tools
+
+import (
+	"encoding/json"
+
+	"mcp-ai-server/internal/logger"
+	"mcp-ai-server/internal/mcp"
+)
+
+// redactedError 脱敏后的错误，保留原始错误供errors.Is/As判断
+type redactedError struct {
+	message string
+	err     error
+}
+
+func (e *redactedError) Error() string { return e.message }
+
+func (e *redactedError) Unwrap() error { return e.err }
+
+// redactError 对错误信息脱敏，没有敏感内容时返回原始错误
+func redactError(err error) error {
+	if err == nil {
+		return nil
+	}
+	message := err.Error()
+	if redacted := logger.Redact(message); redacted != message {
+		return &redactedError{message: redacted, err: err}
+	}
+	return err
+}
+
+// unredactedResultTools 结果原样返回的工具
+// file_read读到的内容可能被file_write写回，脱敏会改写文件内容；这些结果进入日志、审计和AI提示词时各自脱敏
+var unredactedResultTools = map[string]bool{
+	"file_read":  true,
+	"file_write": true,
+}
+
+// redactResult 对工具结果中的文本和结构化内容脱敏，没有敏感内容时返回原始结果
+func redactResult(result *mcp.ToolCallResult) *mcp.ToolCallResult {
+	if result == nil {
+		return nil
+	}
+
+	redacted := *result
+	changed := false
+
+	redacted.Content = make([]mcp.Content, len(result.Content))
+	for i, content := range result.Content {
+		if text := logger.Redact(content.Text); text != content.Text {
+			content.Text = text
+			changed = true
+		}
+		if content.Resource != nil && content.Resource.Text != "" {
+			if text := logger.Redact(content.Resource.Text); text != content.Resource.Text {
+				resource := *content.Resource
+				resource.Text = text
+				content.Resource = &resource
+				changed = true
+			}
+		}
+		redacted.Content[i] = content
+	}
+
+	// 结构化内容转换为通用JSON值后逐个字符串脱敏，只在有变化时替换原始值
+	if result.StructuredContent != nil {
+		if data, err := json.Marshal(result.StructuredContent); err == nil {
+			var value interface{}
+			if json.Unmarshal(data, &value) == nil {
+				if value, valueChanged := redactJSONValue(value); valueChanged {
+					redacted.StructuredContent = value
+					changed = true
+				}
+			}
+		}
+	}
+
+	if !changed {
+		return result
+	}
+	return &redacted
+}
+
+// redactJSONValue 递归脱敏JSON值中的字符串，返回是否有变化
+func redactJSONValue(value interface{}) (interface{}, bool) {
+	switch v := value.(type) {
+	case string:
+		redacted := logger.Redact(v)
+		return redacted, redacted != v
+	case map[string]interface{}:
+		changed := false
+		for key, item := range v {
+			if redacted, itemChanged := redactJSONValue(item); itemChanged {
+				v[key] = redacted
+				changed = true
+			}
+		}
+		return v, changed
+	case []interface{}:
+		changed := false
+		for i, item := range v {
+			if redacted, itemChanged := redactJSONValue(item); itemChanged {
+				v[i] = redacted
+				changed = true
+			}
+		}
+		return v, changed
+	default:
+		return v, false
+	}
+}
@@ -0,0 +1,346 @@
+package tools
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"mime"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"unicode/utf8"
+
+	"mcp-ai-server/internal/config"
+	"mcp-ai-server/internal/mcp"
+)
+
+// dbResourceSampleRows db:// 资源读取时返回的最大行数
+const dbResourceSampleRows = 100
+
+// FileResourceHandler file:// 资源处理器
+type FileResourceHandler struct {
+	securityManager *config.SecurityManager
+	root            string
+}
+
+// NewFileResourceHandler 创建文件资源处理器，root为resources/list列出的目录
+func NewFileResourceHandler(securityManager *config.SecurityManager, root string) *FileResourceHandler {
+	if absRoot, err := filepath.Abs(root); err == nil {
+		root = absRoot
+	}
+	return &FileResourceHandler{
+		securityManager: securityManager,
+		root:            root,
+	}
+}
+
+// fileURIToPath 将file:// URI转换为本地路径
+func fileURIToPath(uri string) (string, error) {
+	u, err := url.Parse(uri)
+	if err != nil || u.Scheme != "file" {
+		return "", fmt.Errorf("无效的文件URI: %s", uri)
+	}
+	// file://relative/path 形式下host部分也属于路径
+	path := u.Path
+	if u.Host != "" && u.Host != "localhost" {
+		path = u.Host + path
+	}
+	if path == "" {
+		return "", fmt.Errorf("无效的文件URI: %s", uri)
+	}
+	return filepath.FromSlash(path), nil
+}
+
+// pathToFileURI 将本地路径转换为file:// URI
+func pathToFileURI(path string) string {
+	u := url.URL{Scheme: "file", Path: filepath.ToSlash(path)}
+	return u.String()
+}
+
+// textMimeTypes 常见源码和配置文件的MIME类型，优先于系统MIME表
+var textMimeTypes = map[string]string{
+	".go":   "text/x-go",
+	".mod":  "text/plain",
+	".sum":  "text/plain",
+	".md":   "text/markdown",
+	".yaml": "application/yaml",
+	".yml":  "application/yaml",
+	".json": "application/json",
+	".txt":  "text/plain",
+	".csv":  "text/csv",
+	".sql":  "application/sql",
+}
+
+// mediaMimeTypes file_read默认允许的图片和音频扩展名，不依赖系统MIME表
+var mediaMimeTypes = map[string]string{
+	".png":  "image/png",
+	".jpg":  "image/jpeg",
+	".jpeg": "image/jpeg",
+	".gif":  "image/gif",
+	".webp": "image/webp",
+	".wav":  "audio/wav",
+	".mp3":  "audio/mpeg",
+	".ogg":  "audio/ogg",
+}
+
+// detectMimeType 根据扩展名和内容推断MIME类型
+func detectMimeType(path string, data []byte) string {
+	ext := strings.ToLower(filepath.Ext(path))
+	if mimeType, ok := textMimeTypes[ext]; ok {
+		return mimeType
+	}
+	if mimeType, ok := mediaMimeTypes[ext]; ok {
+		return mimeType
+	}
+	if mimeType := mime.TypeByExtension(ext); mimeType != "" {
+		return mimeType
+	}
+	return http.DetectContentType(data)
+}
+
+// isTextContent 判断内容是否可以作为文本返回
+func isTextContent(mimeType string, data []byte) bool {
+	if !utf8.Valid(data) {
+		return false
+	}
+	base := strings.TrimSpace(strings.Split(mimeType, ";")[0])
+	switch {
+	case strings.HasPrefix(base, "text/"):
+		return true
+	case base == "application/json", base == "application/xml", base == "application/yaml",
+		base == "application/x-yaml", base == "application/javascript", base == "application/sql":
+		return true
+	case base == "application/octet-stream":
+		// 无法识别类型时，合法的UTF-8内容按文本处理
+		return true
+	default:
+		return false
+	}
+}
+
+// binaryContent 将二进制数据转换为工具内容
+// 图片和音频直接作为对应类型返回，其余类型作为内嵌资源的blob返回
+func binaryContent(uri, mimeType string, data []byte) mcp.Content {
+	base := strings.TrimSpace(strings.Split(mimeType, ";")[0])
+	switch {
+	case strings.HasPrefix(base, "image/"):
+		return mcp.NewImageContent(data, base)
+	case strings.HasPrefix(base, "audio/"):
+		return mcp.NewAudioContent(data, base)
+	default:
+		return mcp.NewEmbeddedResource(mcp.ResourceContents{
+			URI:      uri,
+			MimeType: mimeType,
+			Blob:     base64.StdEncoding.EncodeToString(data),
+		})
+	}
+}
+
+// Read 读取文件资源，文本返回text，二进制返回base64 blob
+func (h *FileResourceHandler) Read(ctx context.Context, uri string) (*mcp.ResourceReadResult, error) {
+	path, err := fileURIToPath(uri)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := h.securityManager.IsPathAllowed(path); err != nil {
+		return nil, fmt.Errorf("安全检查失败: %v", err)
+	}
+	if err := checkPathInRoots(ctx, path); err != nil {
+		return nil, fmt.Errorf("根目录检查失败: %v", err)
+	}
+
+	fileInfo, err := os.Stat(path)
+	if err != nil {
+		return nil, fmt.Errorf("获取文件信息失败: %v", err)
+	}
+	if fileInfo.IsDir() {
+		return nil, fmt.Errorf("%s 是目录", path)
+	}
+	if err := h.securityManager.CheckFileSize(fileInfo.Size()); err != nil {
+		return nil, fmt.Errorf("文件大小检查失败: %v", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("读取文件失败: %v", err)
+	}
+
+	contents := mcp.ResourceContents{
+		URI:      uri,
+		MimeType: detectMimeType(path, data),
+	}
+	if isTextContent(contents.MimeType, data) {
+		contents.Text = string(data)
+	} else {
+		contents.Blob = base64.StdEncoding.EncodeToString(data)
+	}
+
+	return &mcp.ResourceReadResult{
+		Contents: []mcp.ResourceContents{contents},
+	}, nil
+}
+
+// Write 写入文件资源
+func (h *FileResourceHandler) Write(ctx context.Context, uri string, content []mcp.Content) error {
+	path, err := fileURIToPath(uri)
+	if err != nil {
+		return err
+	}
+
+	if err := h.securityManager.IsPathAllowed(path); err != nil {
+		return fmt.Errorf("安全检查失败: %v", err)
+	}
+	if err := checkPathInRoots(ctx, path); err != nil {
+		return fmt.Errorf("根目录检查失败: %v", err)
+	}
+
+	var builder strings.Builder
+	for _, c := range content {
+		builder.WriteString(c.Text)
+	}
+	if err := h.securityManager.CheckFileSize(int64(builder.Len())); err != nil {
+		return fmt.Errorf("内容大小检查失败: %v", err)
+	}
+
+	return os.WriteFile(path, []byte(builder.String()), 0644)
+}
+
+// List 列出根目录下的文件
+func (h *FileResourceHandler) List(ctx context.Context) ([]mcp.Resource, error) {
+	entries, err := os.ReadDir(h.root)
+	if err != nil {
+		return nil, fmt.Errorf("读取目录失败: %v", err)
+	}
+	if err := h.securityManager.CheckDirectoryItems(len(entries)); err != nil {
+		return nil, fmt.Errorf("目录项数检查失败: %v", err)
+	}
+
+	resources := make([]mcp.Resource, 0, len(entries))
+	for _, entry := range entries {
+		if !entry.Type().IsRegular() || strings.HasPrefix(entry.Name(), ".") {
+			continue
+		}
+		path := filepath.Join(h.root, entry.Name())
+		resources = append(resources, mcp.Resource{
+			URI:      pathToFileURI(path),
+			Name:     entry.Name(),
+			MimeType: detectMimeType(path, nil),
+		})
+	}
+	return resources, nil
+}
+
+// Templates 文件资源模板
+func (h *FileResourceHandler) Templates() []mcp.ResourceTemplate {
+	return []mcp.ResourceTemplate{
+		{
+			URITemplate: "file:///{path}",
+			Name:        "本地文件",
+			Description: "按绝对路径读取本地文件，二进制文件以base64返回",
+		},
+	}
+}
+
+// DatabaseResourceHandler db://<alias>/<table> 资源处理器
+type DatabaseResourceHandler struct {
+	databaseTools *DatabaseTools
+}
+
+// NewDatabaseResourceHandler 创建数据库资源处理器
+func NewDatabaseResourceHandler(databaseTools *DatabaseTools) *DatabaseResourceHandler {
+	return &DatabaseResourceHandler{
+		databaseTools: databaseTools,
+	}
+}
+
+// parseDBURI 解析 db://<alias>/<table>
+func parseDBURI(uri string) (alias, table string, err error) {
+	rest := strings.TrimPrefix(uri, "db://")
+	if rest == uri {
+		return "", "", fmt.Errorf("无效的数据库URI: %s", uri)
+	}
+	parts := strings.SplitN(rest, "/", 2)
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return "", "", fmt.Errorf("数据库URI格式应为 db://<alias>/<table>: %s", uri)
+	}
+	table, err = url.PathUnescape(parts[1])
+	if err != nil {
+		return "", "", fmt.Errorf("无效的表名: %v", err)
+	}
+	return parts[0], table, nil
+}
+
+// Read 读取表中的样例数据
+func (h *DatabaseResourceHandler) Read(ctx context.Context, uri string) (*mcp.ResourceReadResult, error) {
+	alias, table, err := parseDBURI(uri)
+	if err != nil {
+		return nil, err
+	}
+
+	columns, rows, err := h.databaseTools.SampleTable(ctx, alias, table, dbResourceSampleRows)
+	if err != nil {
+		return nil, err
+	}
+
+	output := map[string]interface{}{
+		"alias":     alias,
+		"table":     table,
+		"columns":   columns,
+		"rows":      rows,
+		"row_count": len(rows),
+		"limited":   len(rows) >= dbResourceSampleRows,
+	}
+	outputJSON, _ := json.MarshalIndent(output, "", "  ")
+
+	return &mcp.ResourceReadResult{
+		Contents: []mcp.ResourceContents{
+			{
+				URI:      uri,
+				MimeType: "application/json",
+				Text:     string(outputJSON),
+			},
+		},
+	}, nil
+}
+
+// Write 数据库资源为只读
+func (h *DatabaseResourceHandler) Write(ctx context.Context, uri string, content []mcp.Content) error {
+	return fmt.Errorf("数据库资源为只读，请使用db_execute工具")
+}
+
+// List 列出所有连接中的表
+func (h *DatabaseResourceHandler) List(ctx context.Context) ([]mcp.Resource, error) {
+	var resources []mcp.Resource
+	for _, alias := range h.databaseTools.GetAliases() {
+		tables, err := h.databaseTools.ListTables(ctx, alias)
+		if err != nil {
+			debugPrint("列出数据库 %s 的表失败: %v\n", alias, err)
+			continue
+		}
+		for _, table := range tables {
+			resources = append(resources, mcp.Resource{
+				URI:         fmt.Sprintf("db://%s/%s", alias, url.PathEscape(table)),
+				Name:        alias + "." + table,
+				Description: fmt.Sprintf("数据库 %s 中的表 %s", alias, table),
+				MimeType:    "application/json",
+			})
+		}
+	}
+	return resources, nil
+}
+
+// Templates 数据库资源模板
+func (h *DatabaseResourceHandler) Templates() []mcp.ResourceTemplate {
+	return []mcp.ResourceTemplate{
+		{
+			URITemplate: "db://{alias}/{table}",
+			Name:        "数据库表",
+			Description: fmt.Sprintf("读取指定连接中表的前%d行数据", dbResourceSampleRows),
+			MimeType:    "application/json",
+		},
+	}
+}
@@ -0,0 +1,290 @@
+package tools
+
+// 工具的结构化输出类型，JSON字段与工具原有的文本输出保持一致，
+// 每种类型都有对应的outputSchema声明在工具定义中。
+
+// DBConnectResult db_connect 的结构化输出
+type DBConnectResult struct {
+	Alias           string `json:"alias"`
+	Driver          string `json:"driver"`
+	ConnectionIndex int    `json:"connection_index"`
+}
+
+// DBQueryResult db_query 的结构化输出
+type DBQueryResult struct {
+	Columns  []string                 `json:"columns"`
+	Rows     []map[string]interface{} `json:"rows"`
+	RowCount int                      `json:"row_count"`
+	Limited  bool                     `json:"limited"`
+}
+
+// DBExecuteResult db_execute 的结构化输出
+type DBExecuteResult struct {
+	RowsAffected int64  `json:"rows_affected"`
+	LastInsertID int64  `json:"last_insert_id"`
+	Status       string `json:"status"`
+}
+
+// DBListTablesResult db_list_tables 的结构化输出
+type DBListTablesResult struct {
+	Alias   string      `json:"alias"`
+	Dialect string      `json:"dialect"`
+	Tables  []TableInfo `json:"tables"`
+}
+
+// DBDescribeTableResult db_describe_table 的结构化输出
+type DBDescribeTableResult struct {
+	Alias   string `json:"alias"`
+	Dialect string `json:"dialect"`
+	*TableSchema
+}
+
+// DBRelationshipsResult db_relationships 的结构化输出
+type DBRelationshipsResult struct {
+	Alias         string       `json:"alias"`
+	Relationships []ForeignKey `json:"relationships"`
+}
+
+// HTTPResponseResult http_get / http_post 的结构化输出
+type HTTPResponseResult struct {
+	StatusCode  int                 `json:"status_code"`
+	Status      string              `json:"status"`
+	Headers     map[string][]string `json:"headers"`
+	Body        string              `json:"body,omitempty"`
+	URL         string              `json:"url"`
+	Data        string              `json:"data,omitempty"`
+	ContentType string              `json:"content_type,omitempty"`
+}
+
+// CommandOutputResult ping / dns_lookup 等系统命令的结构化输出
+type CommandOutputResult struct {
+	Target  string `json:"target"`
+	Success bool   `json:"success"`
+	Output  string `json:"output"`
+	Error   string `json:"error,omitempty"`
+}
+
+// AIChatResult ai_chat 的结构化输出
+type AIChatResult struct {
+	Response string `json:"response"`
+	Provider string `json:"provider"`
+	Model    string `json:"model"`
+}
+
+// QueryAnalysisResult ai_query_with_analysis 的结构化输出
+type QueryAnalysisResult struct {
+	Tool             string        `json:"tool"`
+	Status           string        `json:"status"`
+	Description      string        `json:"description"`
+	AnalysisType     string        `json:"analysis_type"`
+	GeneratedSQL     string        `json:"generated_sql"`
+	EmployeeData     []interface{} `json:"employee_data"`
+	Analysis         string        `json:"analysis"`
+	SQLProvider      string        `json:"sql_provider"`
+	SQLModel         string        `json:"sql_model"`
+	AnalysisProvider string        `json:"analysis_provider"`
+	AnalysisModel    string        `json:"analysis_model"`
+}
+
+// FileManagerResult ai_file_manager 的结构化输出
+type FileManagerResult struct {
+	AIAnalysis       string   `json:"ai_analysis"`
+	OperationMode    string   `json:"operation_mode"`
+	TargetPath       string   `json:"target_path"`
+	ExecutionResults []string `json:"execution_results"`
+}
+
+// DataProcessorResult ai_data_processor 的结构化输出
+type DataProcessorResult struct {
+	Tool              string                 `json:"tool"`
+	Status            string                 `json:"status"`
+	Instruction       string                 `json:"instruction"`
+	AIAnalysis        string                 `json:"ai_analysis"`
+	DataType          string                 `json:"data_type"`
+	OutputFormat      string                 `json:"output_format"`
+	ProcessingResults map[string]interface{} `json:"processing_results"`
+	Duration          string                 `json:"duration"`
+}
+
+// APIClientResult ai_api_client 的结构化输出
+type APIClientResult struct {
+	Tool                    string                 `json:"tool"`
+	Status                  string                 `json:"status"`
+	Instruction             string                 `json:"instruction"`
+	BaseURL                 string                 `json:"base_url"`
+	AIAnalysis              string                 `json:"ai_analysis"`
+	RequestMode             string                 `json:"request_mode"`
+	ResponseAnalysisEnabled bool                   `json:"response_analysis_enabled"`
+	ExecutionResults        map[string]interface{} `json:"execution_results"`
+	Duration                string                 `json:"duration"`
+}
+
+// objectSchema 构建object类型的JSON Schema
+func objectSchema(properties map[string]interface{}, required ...string) map[string]interface{} {
+	schema := map[string]interface{}{
+		"type":       "object",
+		"properties": properties,
+	}
+	if len(required) > 0 {
+		schema["required"] = required
+	}
+	return schema
+}
+
+// propertySchema 构建单个属性的JSON Schema
+func propertySchema(typeName, description string) map[string]interface{} {
+	return map[string]interface{}{
+		"type":        typeName,
+		"description": description,
+	}
+}
+
+// arraySchema 构建数组属性的JSON Schema
+func arraySchema(items map[string]interface{}, description string) map[string]interface{} {
+	return map[string]interface{}{
+		"type":        "array",
+		"items":       items,
+		"description": description,
+	}
+}
+
+// 各工具的输出Schema
+var (
+	dbConnectOutputSchema = objectSchema(map[string]interface{}{
+		"alias":            propertySchema("string", "连接别名"),
+		"driver":           propertySchema("string", "数据库驱动类型"),
+		"connection_index": propertySchema("integer", "连接索引"),
+	}, "alias", "driver", "connection_index")
+
+	dbQueryOutputSchema = objectSchema(map[string]interface{}{
+		"columns":   arraySchema(map[string]interface{}{"type": "string"}, "列名"),
+		"rows":      arraySchema(map[string]interface{}{"type": "object"}, "结果行，键为列名"),
+		"row_count": propertySchema("integer", "返回的行数"),
+		"limited":   propertySchema("boolean", "结果是否因limit被截断"),
+	}, "columns", "rows", "row_count", "limited")
+
+	dbExecuteOutputSchema = objectSchema(map[string]interface{}{
+		"rows_affected":  propertySchema("integer", "受影响的行数"),
+		"last_insert_id": propertySchema("integer", "最后插入的ID（驱动支持时）"),
+		"status":         propertySchema("string", "执行状态"),
+	}, "rows_affected", "last_insert_id", "status")
+
+	tableInfoSchema = objectSchema(map[string]interface{}{
+		"name":      propertySchema("string", "表名或视图名"),
+		"type":      propertySchema("string", "TABLE 或 VIEW"),
+		"row_count": propertySchema("integer", "估算行数，没有统计信息时省略"),
+	}, "name", "type")
+
+	foreignKeySchema = objectSchema(map[string]interface{}{
+		"name":               propertySchema("string", "外键约束名"),
+		"table":              propertySchema("string", "外键所在的表"),
+		"columns":            arraySchema(map[string]interface{}{"type": "string"}, "外键列"),
+		"referenced_table":   propertySchema("string", "引用的表"),
+		"referenced_columns": arraySchema(map[string]interface{}{"type": "string"}, "引用的列，与columns按位置对应"),
+	}, "name", "table", "columns", "referenced_table", "referenced_columns")
+
+	dbListTablesOutputSchema = objectSchema(map[string]interface{}{
+		"alias":   propertySchema("string", "连接别名"),
+		"dialect": propertySchema("string", "数据库方言"),
+		"tables":  arraySchema(tableInfoSchema, "表和视图"),
+	}, "alias", "dialect", "tables")
+
+	dbDescribeTableOutputSchema = objectSchema(map[string]interface{}{
+		"alias":     propertySchema("string", "连接别名"),
+		"dialect":   propertySchema("string", "数据库方言"),
+		"name":      propertySchema("string", "表名或视图名"),
+		"type":      propertySchema("string", "TABLE 或 VIEW"),
+		"row_count": propertySchema("integer", "估算行数，没有统计信息时省略"),
+		"columns": arraySchema(objectSchema(map[string]interface{}{
+			"name":        propertySchema("string", "列名"),
+			"type":        propertySchema("string", "列类型"),
+			"nullable":    propertySchema("boolean", "是否可空"),
+			"default":     propertySchema("string", "默认值表达式，没有默认值时省略"),
+			"primary_key": propertySchema("boolean", "是否属于主键"),
+		}, "name", "type", "nullable", "primary_key"), "列，按定义顺序排列"),
+		"primary_key": arraySchema(map[string]interface{}{"type": "string"}, "主键列"),
+		"indexes": arraySchema(objectSchema(map[string]interface{}{
+			"name":    propertySchema("string", "索引名"),
+			"unique":  propertySchema("boolean", "是否唯一索引"),
+			"columns": arraySchema(map[string]interface{}{"type": "string"}, "索引列，表达式索引中的表达式不列出"),
+		}, "name", "unique", "columns"), "索引"),
+		"foreign_keys":  arraySchema(foreignKeySchema, "本表引用其他表的外键"),
+		"referenced_by": arraySchema(foreignKeySchema, "其他表引用本表的外键"),
+	}, "alias", "dialect", "name", "type", "columns", "primary_key", "indexes", "foreign_keys", "referenced_by")
+
+	dbRelationshipsOutputSchema = objectSchema(map[string]interface{}{
+		"alias":         propertySchema("string", "连接别名"),
+		"relationships": arraySchema(foreignKeySchema, "外键关系"),
+	}, "alias", "relationships")
+
+	httpResponseOutputSchema = objectSchema(map[string]interface{}{
+		"status_code": propertySchema("integer", "HTTP状态码"),
+		"status":      propertySchema("string", "HTTP状态行"),
+		"headers": map[string]interface{}{
+			"type":                 "object",
+			"description":          "响应头",
+			"additionalProperties": arraySchema(map[string]interface{}{"type": "string"}, "响应头的值"),
+		},
+		"body":         propertySchema("string", "文本响应体，二进制响应以内嵌资源返回"),
+		"url":          propertySchema("string", "请求的URL"),
+		"data":         propertySchema("string", "请求发送的数据"),
+		"content_type": propertySchema("string", "响应的Content-Type"),
+	}, "status_code", "status", "headers", "url")
+
+	commandOutputSchema = objectSchema(map[string]interface{}{
+		"target":  propertySchema("string", "目标主机或域名"),
+		"success": propertySchema("boolean", "命令是否执行成功"),
+		"output":  propertySchema("string", "命令输出"),
+		"error":   propertySchema("string", "失败原因"),
+	}, "target", "success", "output")
+
+	aiChatOutputSchema = objectSchema(map[string]interface{}{
+		"response": propertySchema("string", "AI回复内容"),
+		"provider": propertySchema("string", "使用的AI提供商"),
+		"model":    propertySchema("string", "使用的模型"),
+	}, "response", "provider", "model")
+
+	queryAnalysisOutputSchema = objectSchema(map[string]interface{}{
+		"tool":              propertySchema("string", "工具名称"),
+		"status":            propertySchema("string", "执行状态"),
+		"description":       propertySchema("string", "查询需求描述"),
+		"analysis_type":     propertySchema("string", "分析类型"),
+		"generated_sql":     propertySchema("string", "生成并执行的SQL"),
+		"employee_data":     arraySchema(map[string]interface{}{"type": "object"}, "查询结果行"),
+		"analysis":          propertySchema("string", "AI分析结果"),
+		"sql_provider":      propertySchema("string", "生成SQL的AI提供商"),
+		"sql_model":         propertySchema("string", "生成SQL的模型"),
+		"analysis_provider": propertySchema("string", "分析数据的AI提供商"),
+		"analysis_model":    propertySchema("string", "分析数据的模型"),
+	}, "tool", "status", "generated_sql", "employee_data", "analysis")
+
+	fileManagerOutputSchema = objectSchema(map[string]interface{}{
+		"ai_analysis":       propertySchema("string", "AI对指令的分析和操作计划"),
+		"operation_mode":    propertySchema("string", "操作模式"),
+		"target_path":       propertySchema("string", "目标路径"),
+		"execution_results": arraySchema(map[string]interface{}{"type": "string"}, "各步骤的执行结果"),
+	}, "ai_analysis", "operation_mode", "target_path", "execution_results")
+
+	dataProcessorOutputSchema = objectSchema(map[string]interface{}{
+		"tool":               propertySchema("string", "工具名称"),
+		"status":             propertySchema("string", "执行状态"),
+		"instruction":        propertySchema("string", "处理指令"),
+		"ai_analysis":        propertySchema("string", "AI分析结果"),
+		"data_type":          propertySchema("string", "数据类型"),
+		"output_format":      propertySchema("string", "输出格式"),
+		"processing_results": propertySchema("object", "数据处理结果"),
+		"duration":           propertySchema("string", "执行耗时"),
+	}, "tool", "status", "ai_analysis", "processing_results")
+
+	apiClientOutputSchema = objectSchema(map[string]interface{}{
+		"tool":                      propertySchema("string", "工具名称"),
+		"status":                    propertySchema("string", "执行状态"),
+		"instruction":               propertySchema("string", "请求指令"),
+		"base_url":                  propertySchema("string", "API基础URL"),
+		"ai_analysis":               propertySchema("string", "AI分析结果"),
+		"request_mode":              propertySchema("string", "请求模式"),
+		"response_analysis_enabled": propertySchema("boolean", "是否分析响应"),
+		"execution_results":         propertySchema("object", "请求执行结果"),
+		"duration":                  propertySchema("string", "执行耗时"),
+	}, "tool", "status", "ai_analysis", "execution_results")
+)
@@ -0,0 +1,45 @@
+package tools
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"mcp-ai-server/internal/config"
+	"mcp-ai-server/internal/mcp"
+)
+
+// checkPathInRoots 检查路径是否位于客户端声明的根目录内
+// 客户端不支持roots时不做限制；路径和根目录都在解析符号链接后比较，避免通过链接逃逸
+func checkPathInRoots(ctx context.Context, path string) error {
+	roots, err := mcp.Roots(ctx)
+	if errors.Is(err, mcp.ErrRootsNotSupported) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("获取客户端根目录失败: %v", err)
+	}
+	if len(roots) == 0 {
+		return fmt.Errorf("客户端没有声明任何根目录，拒绝访问 %s", path)
+	}
+
+	resolved, err := config.ResolvePath(path)
+	if err != nil {
+		return fmt.Errorf("解析路径失败: %v", err)
+	}
+
+	for _, root := range roots {
+		rootPath, err := fileURIToPath(root.URI)
+		if err != nil {
+			continue
+		}
+		resolvedRoot, err := config.ResolvePath(rootPath)
+		if err != nil {
+			continue
+		}
+		if config.PathWithin(resolvedRoot, resolved) {
+			return nil
+		}
+	}
+	return fmt.Errorf("路径 %s 不在客户端声明的根目录内", path)
+}
@@ -0,0 +1,199 @@
+package tools
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"mcp-ai-server/internal/config"
+)
+
+// SandboxHelperCommand 沙箱辅助进程的子命令名
+// 服务器以此子命令重新启动自身，设置资源限制后再执行目标命令，见 RunSandboxHelper
+const SandboxHelperCommand = "__sandbox_exec"
+
+// sandboxWaitDelay 终止进程组后等待输出管道关闭的时间
+const sandboxWaitDelay = time.Second
+
+// sandboxResult 沙箱中命令的执行结果
+type sandboxResult struct {
+	Output    []byte
+	Truncated bool
+}
+
+// runSandboxed 在沙箱中执行命令
+// 命令在独立进程组中运行，使用清理后的环境变量和资源限制；超时、取消或输出超过上限时终止整个进程组
+// 沙箱关闭时命令继承服务器的环境变量且不设资源限制，超时和输出上限仍然生效
+// 返回的错误描述失败原因，执行失败时结果中仍包含已产生的输出
+func runSandboxed(ctx context.Context, sm *config.SecurityManager, command string, args []string, dir string) (*sandboxResult, error) {
+	settings := sm.CommandSandbox()
+	enabled := sm.SandboxEnabled()
+	env := os.Environ()
+	if enabled {
+		env = sandboxEnv(settings)
+	}
+
+	path, err := lookCommand(command, dir, env)
+	if err != nil {
+		return nil, err
+	}
+
+	timeout := sm.CommandTimeout()
+	ctx, cancelTimeout := context.WithTimeout(ctx, timeout)
+	defer cancelTimeout()
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var cmd *exec.Cmd
+	if enabled {
+		if cmd, err = sandboxCommand(ctx, path, command, args, sandboxLimitArgs(settings, timeout)); err != nil {
+			return nil, err
+		}
+	} else {
+		cmd = exec.CommandContext(ctx, path, args...)
+	}
+	configureProcessGroup(cmd)
+	cmd.Dir = dir
+	cmd.Env = env
+	cmd.WaitDelay = sandboxWaitDelay
+
+	// stdout和stderr合并写入同一个有上限的缓冲区，超过上限立即终止进程组
+	output := &cappedBuffer{limit: sm.MaxCommandOutput(), onExceed: cancel}
+	cmd.Stdout = output
+	cmd.Stderr = output
+
+	err = cmd.Run()
+	result := &sandboxResult{
+		Output:    output.Bytes(),
+		Truncated: output.Exceeded(),
+	}
+
+	switch {
+	case result.Truncated:
+		return result, fmt.Errorf("命令输出超过上限 %s，进程已终止", config.ByteSize(sm.MaxCommandOutput()))
+	case errors.Is(ctx.Err(), context.DeadlineExceeded):
+		return result, fmt.Errorf("命令执行超时（%v），进程已终止", timeout)
+	case ctx.Err() != nil:
+		return result, fmt.Errorf("命令已取消，进程已终止")
+	case err != nil:
+		return result, describeExitError(err)
+	}
+	return result, nil
+}
+
+// sandboxEnv 构造命令的环境变量：只继承配置的变量，再加上固定设置的变量
+func sandboxEnv(settings *config.CommandSandbox) []string {
+	var env []string
+	for _, name := range settings.Env {
+		if value, ok := os.LookupEnv(name); ok {
+			env = append(env, name+"="+value)
+		}
+	}
+
+	names := make([]string, 0, len(settings.SetEnv))
+	for name := range settings.SetEnv {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		env = append(env, name+"="+settings.SetEnv[name])
+	}
+	return env
+}
+
+// lookCommand 按命令环境中的PATH查找可执行文件，带路径的相对命令基于工作目录解析
+func lookCommand(command, dir string, env []string) (string, error) {
+	if strings.ContainsRune(command, filepath.Separator) {
+		if !filepath.IsAbs(command) {
+			command = filepath.Join(dir, command)
+		}
+		path, err := exec.LookPath(command)
+		if err != nil {
+			return "", fmt.Errorf("找不到命令 %s: %v", command, err)
+		}
+		return path, nil
+	}
+
+	searchPath := ""
+	for _, item := range env {
+		if value, ok := strings.CutPrefix(item, "PATH="); ok {
+			searchPath = value
+		}
+	}
+	for _, directory := range filepath.SplitList(searchPath) {
+		if directory == "" || !filepath.IsAbs(directory) {
+			continue
+		}
+		if path, err := exec.LookPath(filepath.Join(directory, command)); err == nil {
+			return path, nil
+		}
+	}
+	return "", fmt.Errorf("找不到命令 %s", command)
+}
+
+// sandboxLimitArgs 将资源限制编码为辅助进程参数
+func sandboxLimitArgs(settings *config.CommandSandbox, timeout time.Duration) []string {
+	cpuTime := settings.CPUTime
+	if cpuTime <= 0 {
+		cpuTime = int((timeout + time.Second - 1) / time.Second)
+	}
+	return []string{
+		"cpu=" + strconv.Itoa(cpuTime),
+		"as=" + strconv.FormatInt(int64(settings.MaxMemory), 10),
+		"nofile=" + strconv.Itoa(settings.MaxOpenFiles),
+		"nproc=" + strconv.Itoa(settings.MaxProcesses),
+	}
+}
+
+// cappedBuffer 有大小上限的输出缓冲区，超过上限后丢弃后续输出并调用onExceed
+type cappedBuffer struct {
+	mu       sync.Mutex
+	buf      bytes.Buffer
+	limit    int64
+	exceeded bool
+	onExceed func()
+}
+
+// Write 写入不超过上限的部分，总是报告全部写入，避免子进程因管道错误提前退出而掩盖超限原因
+func (b *cappedBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.exceeded {
+		return len(p), nil
+	}
+	remaining := b.limit - int64(b.buf.Len())
+	if int64(len(p)) <= remaining {
+		return b.buf.Write(p)
+	}
+
+	b.buf.Write(p[:remaining])
+	b.exceeded = true
+	if b.onExceed != nil {
+		b.onExceed()
+	}
+	return len(p), nil
+}
+
+// Bytes 获取已写入的内容
+func (b *cappedBuffer) Bytes() []byte {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return bytes.Clone(b.buf.Bytes())
+}
+
+// Exceeded 输出是否超过上限
+func (b *cappedBuffer) Exceeded() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.exceeded
+}
@@ -0,0 +1,29 @@
+//go:build !unix
+
+package tools
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+)
+
+// sandboxCommand 当前平台不支持资源限制，直接执行目标命令
+func sandboxCommand(ctx context.Context, path, name string, args []string, limits []string) (*exec.Cmd, error) {
+	return exec.CommandContext(ctx, path, args...), nil
+}
+
+// configureProcessGroup 当前平台只终止命令进程本身
+func configureProcessGroup(cmd *exec.Cmd) {}
+
+// describeExitError 描述命令的退出原因
+func describeExitError(err error) error {
+	return fmt.Errorf("命令执行失败: %v", err)
+}
+
+// RunSandboxHelper 当前平台不支持沙箱辅助进程
+func RunSandboxHelper(args []string) {
+	fmt.Fprintln(os.Stderr, "当前平台不支持命令沙箱")
+	os.Exit(126)
+}
@@ -0,0 +1,102 @@
+package tools
+
+import (
+	"bytes"
+	"os"
+	"reflect"
+	"testing"
+	"time"
+
+	"mcp-ai-server/internal/config"
+)
+
+// TestMain 沙箱以辅助子命令重新启动当前可执行文件，测试中即测试程序本身，
+// 与cmd/server一样在这里转入辅助进程入口
+func TestMain(m *testing.M) {
+	if len(os.Args) > 1 && os.Args[1] == SandboxHelperCommand {
+		RunSandboxHelper(os.Args[2:])
+	}
+	os.Exit(m.Run())
+}
+
+func TestCappedBuffer(t *testing.T) {
+	tests := []struct {
+		name     string
+		limit    int64
+		writes   []string
+		want     string
+		exceeded bool
+	}{
+		{name: "under limit", limit: 10, writes: []string{"abc", "def"}, want: "abcdef"},
+		{name: "exactly at limit", limit: 6, writes: []string{"abc", "def"}, want: "abcdef"},
+		{name: "single write over limit", limit: 4, writes: []string{"abcdef"}, want: "abcd", exceeded: true},
+		{name: "later write over limit", limit: 5, writes: []string{"abc", "def", "ghi"}, want: "abcde", exceeded: true},
+		{name: "zero limit", limit: 0, writes: []string{"a"}, want: "", exceeded: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls := 0
+			buf := &cappedBuffer{limit: tt.limit, onExceed: func() { calls++ }}
+			for _, write := range tt.writes {
+				// 超过上限后仍报告全部写入，子进程不会因写入错误提前退出
+				if n, err := buf.Write([]byte(write)); n != len(write) || err != nil {
+					t.Errorf("Write(%q) = %d, %v, want %d, nil", write, n, err, len(write))
+				}
+			}
+			if got := string(buf.Bytes()); got != tt.want {
+				t.Errorf("Bytes() = %q, want %q", got, tt.want)
+			}
+			if buf.Exceeded() != tt.exceeded {
+				t.Errorf("Exceeded() = %v, want %v", buf.Exceeded(), tt.exceeded)
+			}
+			wantCalls := 0
+			if tt.exceeded {
+				wantCalls = 1
+			}
+			if calls != wantCalls {
+				t.Errorf("onExceed called %d times, want %d", calls, wantCalls)
+			}
+		})
+	}
+
+	// Bytes返回副本，修改不影响缓冲区
+	buf := &cappedBuffer{limit: 10}
+	buf.Write([]byte("abc"))
+	buf.Bytes()[0] = 'x'
+	if !bytes.Equal(buf.Bytes(), []byte("abc")) {
+		t.Errorf("Bytes() = %q after modifying a copy", buf.Bytes())
+	}
+}
+
+func TestSandboxLimitArgs(t *testing.T) {
+	tests := []struct {
+		name     string
+		settings config.CommandSandbox
+		timeout  time.Duration
+		want     []string
+	}{
+		{
+			name:     "explicit limits",
+			settings: config.CommandSandbox{CPUTime: 5, MaxMemory: 512 << 20, MaxOpenFiles: 256, MaxProcesses: 32},
+			timeout:  30 * time.Second,
+			want:     []string{"cpu=5", "as=536870912", "nofile=256", "nproc=32"},
+		},
+		{
+			name:    "cpu time defaults to timeout",
+			timeout: 30 * time.Second,
+			want:    []string{"cpu=30", "as=0", "nofile=0", "nproc=0"},
+		},
+		{
+			name:    "partial second rounds up",
+			timeout: 1500 * time.Millisecond,
+			want:    []string{"cpu=2", "as=0", "nofile=0", "nproc=0"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := sandboxLimitArgs(&tt.settings, tt.timeout); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("sandboxLimitArgs() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
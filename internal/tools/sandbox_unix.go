@@ -0,0 +1,137 @@
+//go:build unix
+
+package tools
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strconv"
+	"strings"
+	"syscall"
+)
+
+// sandboxCommand 创建通过沙箱辅助进程执行目标命令的Cmd
+func sandboxCommand(ctx context.Context, path, name string, args []string, limits []string) (*exec.Cmd, error) {
+	self, err := os.Executable()
+	if err != nil {
+		return nil, fmt.Errorf("无法启动命令沙箱: %v", err)
+	}
+
+	helperArgs := append([]string{SandboxHelperCommand}, limits...)
+	helperArgs = append(helperArgs, "--", path, name)
+	helperArgs = append(helperArgs, args...)
+	return exec.CommandContext(ctx, self, helperArgs...), nil
+}
+
+// configureProcessGroup 让命令在独立的进程组中运行，取消时终止整个进程组
+func configureProcessGroup(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	cmd.Cancel = func() error {
+		return syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL)
+	}
+}
+
+// describeExitError 描述命令的退出原因
+func describeExitError(err error) error {
+	var exitErr *exec.ExitError
+	if !errors.As(err, &exitErr) {
+		return fmt.Errorf("命令执行失败: %v", err)
+	}
+	if status, ok := exitErr.Sys().(syscall.WaitStatus); ok && status.Signaled() {
+		switch status.Signal() {
+		case syscall.SIGXCPU:
+			return fmt.Errorf("命令CPU时间超过限制，进程已终止")
+		case syscall.SIGKILL:
+			return fmt.Errorf("命令被终止（可能超过资源限制）")
+		}
+	}
+	return fmt.Errorf("命令执行失败: %v", err)
+}
+
+// RunSandboxHelper 沙箱辅助进程入口，参数格式为 "cpu=N as=N nofile=N nproc=N -- 程序路径 argv0 参数..."
+// 设置资源限制后用目标命令替换当前进程，成功时不会返回
+func RunSandboxHelper(args []string) {
+	separator := -1
+	for i, arg := range args {
+		if arg == "--" {
+			separator = i
+			break
+		}
+	}
+	if separator < 0 || len(args) < separator+3 {
+		fmt.Fprintln(os.Stderr, "沙箱参数无效")
+		os.Exit(126)
+	}
+
+	for _, limit := range args[:separator] {
+		if err := applyRlimit(limit); err != nil {
+			fmt.Fprintf(os.Stderr, "设置资源限制失败: %v\n", err)
+			os.Exit(126)
+		}
+	}
+
+	path := args[separator+1]
+	argv := args[separator+2:]
+	err := syscall.Exec(path, argv, os.Environ())
+	fmt.Fprintf(os.Stderr, "执行命令 %s 失败: %v\n", argv[0], err)
+	os.Exit(127)
+}
+
+// applyRlimit 应用一项 "名称=值" 形式的资源限制，值为0时不限制
+func applyRlimit(limit string) error {
+	name, value, _ := strings.Cut(limit, "=")
+	n, err := strconv.ParseUint(value, 10, 64)
+	if err != nil {
+		return fmt.Errorf("无效的资源限制 %s", limit)
+	}
+	if n == 0 {
+		return nil
+	}
+
+	var resource int
+	var hard uint64 = n
+	switch name {
+	case "cpu":
+		resource = syscall.RLIMIT_CPU
+		// 软限制到达时发送SIGXCPU，再多1秒发送SIGKILL
+		hard = n + 1
+	case "as":
+		resource = syscall.RLIMIT_AS
+	case "nofile":
+		resource = syscall.RLIMIT_NOFILE
+	case "nproc":
+		var ok bool
+		if resource, ok = rlimitNPROC(); !ok {
+			return nil
+		}
+	default:
+		return fmt.Errorf("未知的资源限制 %s", name)
+	}
+
+	// 只能收紧已有的硬限制
+	var current syscall.Rlimit
+	if err := syscall.Getrlimit(resource, &current); err == nil && current.Max < hard {
+		hard = current.Max
+		n = min(n, hard)
+	}
+	return syscall.Setrlimit(resource, &syscall.Rlimit{Cur: n, Max: hard})
+}
+
+// rlimitNPROC 当前平台RLIMIT_NPROC的编号，syscall包没有导出这个常量
+func rlimitNPROC() (int, bool) {
+	switch runtime.GOOS {
+	case "linux":
+		switch runtime.GOARCH {
+		case "mips", "mipsle", "mips64", "mips64le":
+			return 8, true
+		}
+		return 6, true
+	case "darwin", "freebsd", "netbsd", "openbsd", "dragonfly":
+		return 7, true
+	}
+	return 0, false
+}
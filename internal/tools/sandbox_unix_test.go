@@ -0,0 +1,141 @@
+//go:build unix
+
+package tools
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"mcp-ai-server/internal/config"
+)
+
+// newSandboxSecurityManager 以临时目录为允许路径，按给定的超时秒数、输出上限和沙箱设置加载安全配置
+func newSandboxSecurityManager(t *testing.T, timeout int, maxOutput string, sandbox string) (*config.SecurityManager, string) {
+	t.Helper()
+
+	dir := t.TempDir()
+	configYAML := fmt.Sprintf(`security:
+  paths:
+    allowed: [%q]
+  commands:
+    validation:
+      timeout: %d
+    sandbox:
+      env: ["PATH"]
+%s
+  resources:
+    max_command_output: %q
+`, dir, timeout, sandbox, maxOutput)
+	configPath := filepath.Join(dir, "config.yaml")
+	if err := os.WriteFile(configPath, []byte(configYAML), 0644); err != nil {
+		t.Fatal(err)
+	}
+	sm, err := config.NewSecurityManager(configPath)
+	if err != nil {
+		t.Fatalf("NewSecurityManager: %v", err)
+	}
+	return sm, dir
+}
+
+func TestApplyRlimit(t *testing.T) {
+	tests := []struct {
+		limit   string
+		wantErr bool
+	}{
+		{limit: "cpu=0"},
+		{limit: "as=0"},
+		{limit: "nofile=0"},
+		{limit: "nproc=0"},
+		{limit: "cpu=abc", wantErr: true},
+		{limit: "cpu=-1", wantErr: true},
+		{limit: "cpu", wantErr: true},
+		{limit: "stack=1", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.limit, func(t *testing.T) {
+			// 只覆盖不会改变测试进程限制的情况，实际生效的限制在辅助进程中验证
+			if err := applyRlimit(tt.limit); (err != nil) != tt.wantErr {
+				t.Errorf("applyRlimit(%q) = %v, wantErr %v", tt.limit, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestRunSandboxedAppliesLimits(t *testing.T) {
+	sm, dir := newSandboxSecurityManager(t, 10, "1MB", "      max_open_files: 64\n      set_env: {SANDBOX_TEST: \"1\"}")
+	t.Setenv("SANDBOX_SECRET", "leak")
+
+	result, err := runSandboxed(context.Background(), sm, "sh", []string{"-c", `ulimit -n; echo "$SANDBOX_TEST$SANDBOX_SECRET"`}, dir)
+	if err != nil {
+		t.Fatalf("runSandboxed: %v", err)
+	}
+	if got := string(result.Output); got != "64\n1\n" {
+		t.Errorf("output = %q, want open file limit 64 and only the configured environment", got)
+	}
+}
+
+func TestRunSandboxedKills(t *testing.T) {
+	tests := []struct {
+		name      string
+		timeout   int
+		maxOutput string
+		args      []string
+		wantErr   string
+		truncated bool
+	}{
+		{
+			name:      "output over limit",
+			timeout:   10,
+			maxOutput: "1KB",
+			args:      []string{"-c", "while :; do echo xxxxxxxxxxxxxxxx; done"},
+			wantErr:   "输出超过上限",
+			truncated: true,
+		},
+		{
+			// 子进程派生的后台进程与它在同一进程组中，一并终止
+			name:      "timeout",
+			timeout:   1,
+			maxOutput: "1MB",
+			args:      []string{"-c", "sleep 30 & echo started; wait"},
+			wantErr:   "超时",
+		},
+	}
+	// 关闭沙箱时超时和输出上限同样生效
+	sandboxes := []struct {
+		name     string
+		settings string
+	}{
+		{name: "sandbox", settings: "      enabled: true"},
+		{name: "no sandbox", settings: "      enabled: false"},
+	}
+	for _, sandbox := range sandboxes {
+		for _, tt := range tests {
+			t.Run(sandbox.name+"/"+tt.name, func(t *testing.T) {
+				sm, dir := newSandboxSecurityManager(t, tt.timeout, tt.maxOutput, sandbox.settings)
+
+				start := time.Now()
+				result, err := runSandboxed(context.Background(), sm, "sh", tt.args, dir)
+				if elapsed := time.Since(start); elapsed > 5*time.Second {
+					t.Errorf("runSandboxed took %v, want the process killed promptly", elapsed)
+				}
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("runSandboxed error = %v, want %q", err, tt.wantErr)
+				}
+				if result.Truncated != tt.truncated {
+					t.Errorf("Truncated = %v, want %v", result.Truncated, tt.truncated)
+				}
+				if limit := sm.MaxCommandOutput(); int64(len(result.Output)) > limit {
+					t.Errorf("output length %d exceeds limit %d", len(result.Output), limit)
+				}
+				if !tt.truncated && string(result.Output) != "started\n" {
+					t.Errorf("output = %q, want output produced before the kill", result.Output)
+				}
+			})
+		}
+	}
+}
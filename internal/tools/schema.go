@@ -0,0 +1,398 @@
+package tools
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"regexp"
+	"sync"
+	"time"
+)
+
+// TableInfo 表或视图的概要信息
+type TableInfo struct {
+	Name string `json:"name"`
+	Type string `json:"type"` // TABLE 或 VIEW
+	// RowCount 估算行数，数据库没有统计信息时为空
+	RowCount *int64 `json:"row_count,omitempty"`
+}
+
+// IndexInfo 索引信息
+type IndexInfo struct {
+	Name    string   `json:"name"`
+	Unique  bool     `json:"unique"`
+	Columns []string `json:"columns"`
+}
+
+// ForeignKey 外键关系，列与引用列按位置一一对应
+type ForeignKey struct {
+	Name              string   `json:"name"`
+	Table             string   `json:"table"`
+	Columns           []string `json:"columns"`
+	ReferencedTable   string   `json:"referenced_table"`
+	ReferencedColumns []string `json:"referenced_columns"`
+}
+
+// TableSchema 表的完整结构
+type TableSchema struct {
+	Name         string       `json:"name"`
+	Type         string       `json:"type"`
+	RowCount     *int64       `json:"row_count,omitempty"`
+	Columns      []ColumnInfo `json:"columns"`
+	PrimaryKey   []string     `json:"primary_key"`
+	Indexes      []IndexInfo  `json:"indexes"`
+	ForeignKeys  []ForeignKey `json:"foreign_keys"`
+	ReferencedBy []ForeignKey `json:"referenced_by"`
+}
+
+// schemaCacheItem 缓存项
+type schemaCacheItem struct {
+	value   interface{}
+	expires time.Time
+}
+
+// schemaCache 按连接别名缓存数据库结构，过期、重新连接或执行DDL后失效
+// 为nil时不缓存
+type schemaCache struct {
+	mu      sync.Mutex
+	ttl     time.Duration
+	entries map[string]map[string]schemaCacheItem // 别名 -> 缓存键 -> 缓存项
+}
+
+// newSchemaCache 创建结构缓存，ttl不大于0时返回nil
+func newSchemaCache(ttl time.Duration) *schemaCache {
+	if ttl <= 0 {
+		return nil
+	}
+	return &schemaCache{
+		ttl:     ttl,
+		entries: make(map[string]map[string]schemaCacheItem),
+	}
+}
+
+// Get 获取未过期的缓存项
+func (c *schemaCache) Get(alias, key string) (interface{}, bool) {
+	if c == nil {
+		return nil, false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	item, ok := c.entries[alias][key]
+	if !ok || time.Now().After(item.expires) {
+		return nil, false
+	}
+	return item.value, true
+}
+
+// Put 写入缓存项
+func (c *schemaCache) Put(alias, key string, value interface{}) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.entries[alias] == nil {
+		c.entries[alias] = make(map[string]schemaCacheItem)
+	}
+	c.entries[alias][key] = schemaCacheItem{value: value, expires: time.Now().Add(c.ttl)}
+}
+
+// Invalidate 清除别名下的所有缓存项
+func (c *schemaCache) Invalidate(alias string) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	delete(c.entries, alias)
+}
+
+// 缓存键
+const (
+	schemaKeyTables        = "tables"
+	schemaKeyRelationships = "relationships"
+	schemaKeyTablePrefix   = "table:"
+)
+
+// ddlStatementPattern 会改变数据库结构的语句，执行成功后清除结构缓存
+var ddlStatementPattern = regexp.MustCompile(`(?i)^\s*(create|drop|alter|rename|truncate|comment)\b`)
+
+// SetSchemaCacheTTL 设置数据库结构缓存的有效期，0表示不缓存
+func (t *DatabaseTools) SetSchemaCacheTTL(ttl time.Duration) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.schemaCache = newSchemaCache(ttl)
+}
+
+// getSchemaCache 获取结构缓存
+func (t *DatabaseTools) getSchemaCache() *schemaCache {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	return t.schemaCache
+}
+
+// InvalidateSchema 清除别名的结构缓存
+func (t *DatabaseTools) InvalidateSchema(alias string) {
+	t.getSchemaCache().Invalidate(alias)
+}
+
+// ListTableInfos 列出表和视图及其估算行数
+func (t *DatabaseTools) ListTableInfos(ctx context.Context, alias string) ([]TableInfo, error) {
+	cache := t.getSchemaCache()
+	if cached, ok := cache.Get(alias, schemaKeyTables); ok {
+		return cached.([]TableInfo), nil
+	}
+
+	db, dialect, exists := t.getConnection(alias)
+	if !exists {
+		return nil, fmt.Errorf("未找到别名为 %s 的数据库连接", alias)
+	}
+
+	rows, err := db.QueryContext(ctx, dialect.ListObjectsQuery())
+	if err != nil {
+		return nil, fmt.Errorf("查询表列表失败: %v", err)
+	}
+	defer rows.Close()
+
+	tables := []TableInfo{}
+	for rows.Next() {
+		var table TableInfo
+		if err := rows.Scan(&table.Name, &table.Type); err != nil {
+			return nil, fmt.Errorf("扫描表名失败: %v", err)
+		}
+		tables = append(tables, table)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("遍历表列表失败: %v", err)
+	}
+	rows.Close()
+
+	// 行数只是参考信息，统计失败不影响表列表
+	counts, err := loadRowCounts(ctx, db, dialect, tables)
+	if err != nil {
+		debugPrint("获取 %s 的表行数失败: %v\n", alias, err)
+	}
+	for i := range tables {
+		if count, ok := counts[tables[i].Name]; ok {
+			tables[i].RowCount = &count
+		}
+	}
+
+	cache.Put(alias, schemaKeyTables, tables)
+	return tables, nil
+}
+
+// loadRowCounts 获取各表的估算行数，方言没有统计信息时逐表计数
+func loadRowCounts(ctx context.Context, db *sql.DB, dialect Dialect, tables []TableInfo) (map[string]int64, error) {
+	counts := make(map[string]int64)
+
+	query := dialect.RowCountsQuery()
+	if query == "" {
+		for _, table := range tables {
+			if table.Type != "TABLE" {
+				continue
+			}
+			var count int64
+			if err := db.QueryRowContext(ctx, "SELECT COUNT(*) FROM "+dialect.QuoteIdentifier(table.Name)).Scan(&count); err != nil {
+				return counts, err
+			}
+			counts[table.Name] = count
+		}
+		return counts, nil
+	}
+
+	rows, err := db.QueryContext(ctx, query)
+	if err != nil {
+		return counts, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var name string
+		var count sql.NullInt64
+		if err := rows.Scan(&name, &count); err != nil {
+			return counts, err
+		}
+		if count.Valid {
+			counts[name] = count.Int64
+		}
+	}
+	return counts, rows.Err()
+}
+
+// DescribeTable 获取表的列、主键、索引、外键和估算行数
+func (t *DatabaseTools) DescribeTable(ctx context.Context, alias, table string) (*TableSchema, error) {
+	cache := t.getSchemaCache()
+	if cached, ok := cache.Get(alias, schemaKeyTablePrefix+table); ok {
+		return cached.(*TableSchema), nil
+	}
+
+	tables, err := t.ListTableInfos(ctx, alias)
+	if err != nil {
+		return nil, err
+	}
+	var info *TableInfo
+	for i := range tables {
+		if tables[i].Name == table {
+			info = &tables[i]
+			break
+		}
+	}
+	if info == nil {
+		return nil, fmt.Errorf("表 %s 在 %s 中不存在", table, alias)
+	}
+
+	columns, err := t.ListColumns(ctx, alias, table)
+	if err != nil {
+		return nil, err
+	}
+	db, dialect, _ := t.getConnection(alias)
+
+	primaryKey, err := queryStrings(ctx, db, dialect.PrimaryKeyQuery(), table)
+	if err != nil {
+		return nil, fmt.Errorf("查询主键失败: %v", err)
+	}
+	for i := range columns {
+		for _, name := range primaryKey {
+			if columns[i].Name == name {
+				columns[i].PrimaryKey = true
+			}
+		}
+	}
+
+	indexes, err := loadIndexes(ctx, db, dialect, table)
+	if err != nil {
+		return nil, fmt.Errorf("查询索引失败: %v", err)
+	}
+
+	relationships, err := t.Relationships(ctx, alias)
+	if err != nil {
+		return nil, err
+	}
+
+	schema := &TableSchema{
+		Name:         info.Name,
+		Type:         info.Type,
+		RowCount:     info.RowCount,
+		Columns:      columns,
+		PrimaryKey:   primaryKey,
+		Indexes:      indexes,
+		ForeignKeys:  []ForeignKey{},
+		ReferencedBy: []ForeignKey{},
+	}
+	for _, fk := range relationships {
+		if fk.Table == table {
+			schema.ForeignKeys = append(schema.ForeignKeys, fk)
+		}
+		if fk.ReferencedTable == table {
+			schema.ReferencedBy = append(schema.ReferencedBy, fk)
+		}
+	}
+
+	cache.Put(alias, schemaKeyTablePrefix+table, schema)
+	return schema, nil
+}
+
+// loadIndexes 获取表的索引，同一索引的多行合并为一项
+func loadIndexes(ctx context.Context, db *sql.DB, dialect Dialect, table string) ([]IndexInfo, error) {
+	rows, err := db.QueryContext(ctx, dialect.IndexesQuery(), table)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	indexes := []IndexInfo{}
+	for rows.Next() {
+		var name string
+		var unique int64
+		var column sql.NullString
+		if err := rows.Scan(&name, &unique, &column); err != nil {
+			return nil, err
+		}
+		if len(indexes) == 0 || indexes[len(indexes)-1].Name != name {
+			indexes = append(indexes, IndexInfo{Name: name, Unique: unique != 0, Columns: []string{}})
+		}
+		if column.Valid {
+			last := &indexes[len(indexes)-1]
+			last.Columns = append(last.Columns, column.String)
+		}
+	}
+	return indexes, rows.Err()
+}
+
+// Relationships 获取数据库中的所有外键关系
+func (t *DatabaseTools) Relationships(ctx context.Context, alias string) ([]ForeignKey, error) {
+	cache := t.getSchemaCache()
+	if cached, ok := cache.Get(alias, schemaKeyRelationships); ok {
+		return cached.([]ForeignKey), nil
+	}
+
+	db, dialect, exists := t.getConnection(alias)
+	if !exists {
+		return nil, fmt.Errorf("未找到别名为 %s 的数据库连接", alias)
+	}
+
+	rows, err := db.QueryContext(ctx, dialect.ForeignKeysQuery())
+	if err != nil {
+		return nil, fmt.Errorf("查询外键失败: %v", err)
+	}
+	defer rows.Close()
+
+	relationships := []ForeignKey{}
+	for rows.Next() {
+		var name, table, column, referencedTable string
+		var referencedColumn sql.NullString
+		if err := rows.Scan(&name, &table, &column, &referencedTable, &referencedColumn); err != nil {
+			return nil, fmt.Errorf("扫描外键失败: %v", err)
+		}
+		if n := len(relationships); n == 0 || relationships[n-1].Name != name || relationships[n-1].Table != table {
+			relationships = append(relationships, ForeignKey{Name: name, Table: table, ReferencedTable: referencedTable})
+		}
+		last := &relationships[len(relationships)-1]
+		last.Columns = append(last.Columns, column)
+		last.ReferencedColumns = append(last.ReferencedColumns, referencedColumn.String)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("遍历外键失败: %v", err)
+	}
+	rows.Close()
+
+	// 未写明引用列的外键引用的是对方的主键
+	for i := range relationships {
+		fk := &relationships[i]
+		if fk.ReferencedColumns[0] != "" {
+			continue
+		}
+		primaryKey, err := queryStrings(ctx, db, dialect.PrimaryKeyQuery(), fk.ReferencedTable)
+		if err == nil && len(primaryKey) == len(fk.Columns) {
+			fk.ReferencedColumns = primaryKey
+		}
+	}
+
+	cache.Put(alias, schemaKeyRelationships, relationships)
+	return relationships, nil
+}
+
+// queryStrings 执行只返回一列的查询
+func queryStrings(ctx context.Context, db *sql.DB, query string, args ...interface{}) ([]string, error) {
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	values := []string{}
+	for rows.Next() {
+		var value string
+		if err := rows.Scan(&value); err != nil {
+			return nil, err
+		}
+		values = append(values, value)
+	}
+	return values, rows.Err()
+}
@@ -0,0 +1,123 @@
+package tools
+
+import (
+	"fmt"
+	"strings"
+)
+
+// splitStatements 按顶层分号拆分SQL文本，跳过字符串、标识符引号和注释中的分号
+// 只包含空白或注释的片段会被丢弃。各数据库的词法差异按保守方向处理：
+// 反斜杠不视为转义、不识别$$引用、MySQL的/*!...*/可执行注释按正文扫描，
+// 宁可把单条语句误判为多条，也不把多条语句漏判为一条
+func splitStatements(query string) []string {
+	var statements []string
+	start := 0
+	hasContent := false
+
+	flush := func(end int) {
+		if hasContent {
+			statements = append(statements, strings.TrimSpace(query[start:end]))
+		}
+		start = end + 1
+		hasContent = false
+	}
+
+	for i := 0; i < len(query); i++ {
+		c := query[i]
+		switch {
+		case c == ';':
+			flush(i)
+		case strings.HasPrefix(query[i:], "--") && (i+2 == len(query) || isSQLSpace(query[i+2])):
+			i = skipUntil(query, i+2, "\n") - 1
+		case strings.HasPrefix(query[i:], "/*") && !strings.HasPrefix(query[i:], "/*!"):
+			i = skipUntil(query, i+2, "*/") - 1
+		case c == '\'' || c == '"' || c == '`':
+			hasContent = true
+			i = skipQuoted(query, i+1, c) - 1
+		case c == '[':
+			hasContent = true
+			i = skipQuoted(query, i+1, ']') - 1
+		case isSQLSpace(c):
+		default:
+			hasContent = true
+		}
+	}
+	flush(len(query))
+	return statements
+}
+
+// skipUntil 返回terminator在from之后首次出现的结束位置，未找到时返回文本长度
+func skipUntil(query string, from int, terminator string) int {
+	if from > len(query) {
+		return len(query)
+	}
+	idx := strings.Index(query[from:], terminator)
+	if idx < 0 {
+		return len(query)
+	}
+	return from + idx + len(terminator)
+}
+
+// skipQuoted 跳过引号内容，连续两个结束引号视为转义，返回结束引号之后的位置
+func skipQuoted(query string, from int, quote byte) int {
+	for i := from; i < len(query); i++ {
+		if query[i] != quote {
+			continue
+		}
+		if quote != ']' && i+1 < len(query) && query[i+1] == quote {
+			i++
+			continue
+		}
+		return i + 1
+	}
+	return len(query)
+}
+
+// isSQLSpace 判断是否为SQL中的空白字符
+func isSQLSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == '\f' || c == '\v'
+}
+
+// singleStatement 校验SQL文本只包含一条语句，返回去掉结尾分号的语句
+func singleStatement(query string) (string, error) {
+	statements := splitStatements(query)
+	switch len(statements) {
+	case 0:
+		return "", fmt.Errorf("sql参数不能为空")
+	case 1:
+		return statements[0], nil
+	default:
+		return "", fmt.Errorf("不允许一次执行多条SQL语句，检测到 %d 条", len(statements))
+	}
+}
+
+// leadingKeyword 返回语句跳过开头空白和注释后的第一个关键字（大写），用于判断语句类型
+// executableComments为true（MySQL）时/*!...*/中的内容会被执行，不能当作注释跳过，此时返回空字符串；
+// 其它数据库把/*!...*/当作普通注释。开头不是关键字时同样返回空字符串，调用方应拒绝无法识别的语句
+func leadingKeyword(statement string, executableComments bool) string {
+	i := 0
+	for i < len(statement) {
+		switch {
+		case isSQLSpace(statement[i]):
+			i++
+		case strings.HasPrefix(statement[i:], "--"):
+			i = skipUntil(statement, i+2, "\n")
+		case strings.HasPrefix(statement[i:], "/*!") && executableComments:
+			return ""
+		case strings.HasPrefix(statement[i:], "/*"):
+			i = skipUntil(statement, i+2, "*/")
+		default:
+			end := i
+			for end < len(statement) && isSQLWordChar(statement[end]) {
+				end++
+			}
+			return strings.ToUpper(statement[i:end])
+		}
+	}
+	return ""
+}
+
+// isSQLWordChar 判断是否为关键字中的字符
+func isSQLWordChar(c byte) bool {
+	return c == '_' || ('a' <= c && c <= 'z') || ('A' <= c && c <= 'Z') || ('0' <= c && c <= '9')
+}
@@ -0,0 +1,73 @@
+package tools
+
+import "testing"
+
+func TestSingleStatement(t *testing.T) {
+	tests := []struct {
+		name    string
+		query   string
+		want    string
+		wantErr bool
+	}{
+		{name: "plain", query: "DELETE FROM t WHERE id = 1", want: "DELETE FROM t WHERE id = 1"},
+		{name: "trailing semicolon", query: "DELETE FROM t WHERE id = 1;  \n", want: "DELETE FROM t WHERE id = 1"},
+		{name: "trailing comment", query: "UPDATE t SET a = 1; -- done", want: "UPDATE t SET a = 1"},
+		{name: "semicolon in string", query: "INSERT INTO t VALUES ('a;b')", want: "INSERT INTO t VALUES ('a;b')"},
+		{name: "doubled quote", query: "INSERT INTO t VALUES ('it''s; ok')", want: "INSERT INTO t VALUES ('it''s; ok')"},
+		{name: "quoted identifiers", query: "SELECT \"a;b\", `c;d`, [e;f] FROM t", want: "SELECT \"a;b\", `c;d`, [e;f] FROM t"},
+		{name: "semicolon in block comment", query: "SELECT 1 /* ; DROP TABLE t */", want: "SELECT 1 /* ; DROP TABLE t */"},
+		{name: "empty", query: " ; -- nothing\n", wantErr: true},
+		{name: "stacked statement", query: "DELETE FROM t WHERE id = 1; DROP TABLE t", wantErr: true},
+		{name: "where swallowing", query: "UPDATE t SET a = 1 WHERE 1 = 1;DROP TABLE t;", wantErr: true},
+		{name: "backslash escape", query: `SELECT 'a\'; DROP TABLE t; --'`, wantErr: true},
+		{name: "dash without space", query: "SELECT 1--1; DROP TABLE t", wantErr: true},
+		{name: "mysql executable comment", query: "SELECT 1 /*!; DROP TABLE t */", wantErr: true},
+		{name: "newline ends line comment", query: "SELECT 1 -- c\n; DROP TABLE t", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := singleStatement(tt.query)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("singleStatement(%q) = %q, want error", tt.query, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("singleStatement(%q) error: %v", tt.query, err)
+			}
+			if got != tt.want {
+				t.Errorf("singleStatement(%q) = %q, want %q", tt.query, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLeadingKeyword(t *testing.T) {
+	tests := []struct {
+		statement          string
+		executableComments bool
+		want               string
+	}{
+		{statement: "select 1", want: "SELECT"},
+		{statement: "  \n\tAttach 'x.db' AS x", want: "ATTACH"},
+		{statement: "-- comment\nALTER TABLE t", want: "ALTER"},
+		{statement: "/* a */ /* b */truncate t", want: "TRUNCATE"},
+		{statement: "VACUUM", want: "VACUUM"},
+		{statement: "/*! */ ATTACH DATABASE 'x.db' AS x", want: "ATTACH"},
+		{statement: "/*!ATTACH */ SELECT 1", want: "SELECT"},
+		{statement: "/*! */ ALTER TABLE t", executableComments: true, want: ""},
+		{statement: "/*!50000 ALTER TABLE t */", executableComments: true, want: ""},
+		{statement: "/* a */ UPDATE t SET a = 1", executableComments: true, want: "UPDATE"},
+		{statement: "(SELECT 1)", want: ""},
+		{statement: "-- only a comment", want: ""},
+		{statement: "", want: ""},
+	}
+
+	for _, tt := range tests {
+		if got := leadingKeyword(tt.statement, tt.executableComments); got != tt.want {
+			t.Errorf("leadingKeyword(%q, %v) = %q, want %q", tt.statement, tt.executableComments, got, tt.want)
+		}
+	}
+}
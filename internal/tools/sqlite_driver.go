@@ -0,0 +1,14 @@
+package tools
+
+import (
+	"database/sql"
+
+	"modernc.org/sqlite"
+)
+
+// SQLite驱动单独放在这里注册。
+// 使用纯Go实现的modernc.org/sqlite，不需要cgo；它自身注册的驱动名是sqlite，
+// 这里再以sqlite3注册一次，与配置、方言表和文档中的驱动名保持一致
+func init() {
+	sql.Register("sqlite3", &sqlite.Driver{})
+}
@@ -4,7 +4,6 @@ import (
 	"context"
 	"fmt"
 	"os"
-	"os/exec"
 	"path/filepath"
 	"strings"
 
@@ -15,6 +14,7 @@ import (
 // SystemTools 系统工具集合
 type SystemTools struct {
 	securityManager *config.SecurityManager
+	confirmer       *Confirmer
 }
 
 // NewSystemTools 创建新的系统工具集合
@@ -29,6 +29,11 @@ func NewSystemTools(configPath string) (*SystemTools, error) {
 	}, nil
 }
 
+// SetConfirmer 设置破坏性操作的确认器
+func (t *SystemTools) SetConfirmer(confirmer *Confirmer) {
+	t.confirmer = confirmer
+}
+
 // FileReadTool 文件读取工具
 func (t *SystemTools) FileReadTool() mcp.Tool {
 	return mcp.Tool{
@@ -151,6 +156,9 @@ func (t *SystemTools) executeFileRead(ctx context.Context, arguments map[string]
 	if err := t.securityManager.IsPathAllowed(path); err != nil {
 		return nil, fmt.Errorf("安全检查失败: %v", err)
 	}
+	if err := checkPathInRoots(ctx, path); err != nil {
+		return nil, fmt.Errorf("根目录检查失败: %v", err)
+	}
 
 	// 获取文件信息
 	fileInfo, err := os.Stat(path)
@@ -168,6 +176,18 @@ func (t *SystemTools) executeFileRead(ctx context.Context, arguments map[string]
 		return nil, fmt.Errorf("读取文件失败: %v", err)
 	}
 
+	// 二进制文件按图片、音频或内嵌资源返回，避免转换为字符串后损坏
+	mimeType := detectMimeType(path, content)
+	if !isTextContent(mimeType, content) {
+		absPath, err := filepath.Abs(path)
+		if err != nil {
+			absPath = path
+		}
+		return &mcp.ToolCallResult{
+			Content: []mcp.Content{binaryContent(pathToFileURI(absPath), mimeType, content)},
+		}, nil
+	}
+
 	return &mcp.ToolCallResult{
 		Content: []mcp.Content{
 			{
@@ -194,12 +214,32 @@ func (t *SystemTools) executeFileWrite(ctx context.Context, arguments map[string
 	if err := t.securityManager.IsPathAllowed(path); err != nil {
 		return nil, fmt.Errorf("安全检查失败: %v", err)
 	}
+	if err := checkPathInRoots(ctx, path); err != nil {
+		return nil, fmt.Errorf("根目录检查失败: %v", err)
+	}
 
 	// 检查内容大小
 	if err := t.securityManager.CheckFileSize(int64(len(content))); err != nil {
 		return nil, fmt.Errorf("内容大小检查失败: %v", err)
 	}
 
+	// 覆盖或创建文件前请求用户确认
+	operation := "创建新文件"
+	if _, err := os.Stat(path); err == nil {
+		operation = "覆盖已有文件"
+	}
+	if err := t.confirmer.Confirm(ctx, PendingAction{
+		Tool:    "file_write",
+		Message: fmt.Sprintf("即将%s %s（%d 字节），是否继续？", operation, path, len(content)),
+		Fields: []ActionField{
+			{Name: "path", Title: "文件路径", Type: "string", Value: path},
+			{Name: "operation", Title: "操作", Type: "string", Value: operation},
+			{Name: "size", Title: "写入字节数", Type: "integer", Value: len(content)},
+		},
+	}); err != nil {
+		return declinedResult("file_write", err), nil
+	}
+
 	// 确保目录存在
 	dir := filepath.Dir(path)
 	if err := os.MkdirAll(dir, 0755); err != nil {
@@ -248,33 +288,31 @@ func (t *SystemTools) executeCommand(ctx context.Context, arguments map[string]i
 		return nil, fmt.Errorf("命令安全检查失败: %v", err)
 	}
 
-	cmd := exec.CommandContext(ctx, command, args...)
-	if workingDir != "" {
-		cmd.Dir = workingDir
-	}
-
-	output, err := cmd.CombinedOutput()
+	// 工作目录限制在允许路径内，未指定时使用沙箱的默认工作目录
+	dir, err := t.securityManager.CommandWorkingDir(workingDir)
 	if err != nil {
-		return &mcp.ToolCallResult{
-			Content: []mcp.Content{
-				{
-					Type: "text",
-					Text: fmt.Sprintf("命令执行失败: %v\n输出: %s", err, string(output)),
-				},
-			},
-		}, nil
+		return nil, fmt.Errorf("工作目录安全检查失败: %v", err)
+	}
+	if err := checkPathInRoots(ctx, dir); err != nil {
+		return nil, fmt.Errorf("工作目录安全检查失败: %v", err)
+	}
+	if err := t.securityManager.CheckCommandArguments(command, args, dir); err != nil {
+		return nil, fmt.Errorf("命令参数检查失败: %v", err)
 	}
 
-	// 检查输出大小
-	if err := t.securityManager.CheckCommandOutput(int64(len(output))); err != nil {
-		return nil, fmt.Errorf("命令输出大小检查失败: %v", err)
+	result, err := runSandboxed(ctx, t.securityManager, command, args, dir)
+	if err != nil {
+		if result == nil {
+			return nil, err
+		}
+		return mcp.NewToolErrorResult(fmt.Sprintf("%v\n输出: %s", err, string(result.Output))), nil
 	}
 
 	return &mcp.ToolCallResult{
 		Content: []mcp.Content{
 			{
 				Type: "text",
-				Text: string(output),
+				Text: string(result.Output),
 			},
 		},
 	}, nil
@@ -291,6 +329,9 @@ func (t *SystemTools) executeDirectoryList(ctx context.Context, arguments map[st
 	if err := t.securityManager.IsPathAllowed(path); err != nil {
 		return nil, fmt.Errorf("安全检查失败: %v", err)
 	}
+	if err := checkPathInRoots(ctx, path); err != nil {
+		return nil, fmt.Errorf("根目录检查失败: %v", err)
+	}
 
 	entries, err := os.ReadDir(path)
 	if err != nil {
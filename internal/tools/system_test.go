@@ -0,0 +1,92 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"mcp-ai-server/internal/config"
+	"mcp-ai-server/internal/mcp"
+)
+
+// newDefaultPolicyFileReader 按仓库默认配置的工具策略执行file_read，安全配置只允许返回的临时目录
+func newDefaultPolicyFileReader(t *testing.T) (*ToolManager, string) {
+	t.Helper()
+
+	policyConfig, err := config.NewPolicyConfigManager(filepath.Join("..", "..", "configs", "config.yaml"))
+	if err != nil {
+		t.Fatalf("加载默认配置失败: %v", err)
+	}
+
+	dir := t.TempDir()
+	configPath := filepath.Join(dir, "config.yaml")
+	configYAML := fmt.Sprintf("security:\n  paths:\n    allowed: [%q]\n", dir)
+	if err := os.WriteFile(configPath, []byte(configYAML), 0644); err != nil {
+		t.Fatal(err)
+	}
+	systemTools, err := NewSystemTools(configPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return &ToolManager{
+		toolMap:     map[string]ToolExecutor{"file_read": systemTools},
+		customTools: make(map[string]mcp.Tool),
+		disabled:    make(map[string]bool),
+		unavailable: make(map[string]bool),
+		policy:      NewPolicyEngine(policyConfig),
+	}, dir
+}
+
+func TestFileReadDefaultConfig(t *testing.T) {
+	tm, dir := newDefaultPolicyFileReader(t)
+
+	png := []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR\x00\x00\x00\x01\x00\x00\x00\x01\x08\x06\x00\x00\x00")
+	wav := []byte("RIFF\x24\x00\x00\x00WAVEfmt \x10\x00\x00\x00\x01\x00\x01\x00\x44\xac\x00\x00")
+	tests := []struct {
+		file     string
+		data     []byte
+		wantType string
+		wantMime string
+	}{
+		{file: "pixel.png", data: png, wantType: mcp.ContentTypeImage, wantMime: "image/png"},
+		{file: "photo.JPG", data: []byte("\xff\xd8\xff\xe0\x00\x10JFIF\x00"), wantType: mcp.ContentTypeImage, wantMime: "image/jpeg"},
+		{file: "beep.wav", data: wav, wantType: mcp.ContentTypeAudio, wantMime: "audio/wav"},
+		{file: "notes.txt", data: []byte("hello"), wantType: "text"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.file, func(t *testing.T) {
+			path := filepath.Join(dir, tt.file)
+			if err := os.WriteFile(path, tt.data, 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			result, err := tm.ExecuteTool(context.Background(), "file_read", map[string]interface{}{"path": path})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if result.IsError || len(result.Content) != 1 {
+				t.Fatalf("file_read(%s) = %+v, want one content item", tt.file, result)
+			}
+			content := result.Content[0]
+			if content.Type != tt.wantType || content.MimeType != tt.wantMime {
+				t.Errorf("content type = %s %s, want %s %s", content.Type, content.MimeType, tt.wantType, tt.wantMime)
+			}
+			if tt.wantMime != "" && content.Data == "" {
+				t.Errorf("content has no data")
+			}
+		})
+	}
+
+	// 默认列表之外的扩展名仍被策略拒绝
+	path := filepath.Join(dir, "tool.exe")
+	if err := os.WriteFile(path, []byte("MZ\x90\x00"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	result, err := tm.ExecuteTool(context.Background(), "file_read", map[string]interface{}{"path": path})
+	if err != nil || !result.IsError {
+		t.Errorf("file_read(tool.exe) = %+v, %v, want policy denial", result, err)
+	}
+}